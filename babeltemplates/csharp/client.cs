@@ -32,17 +32,17 @@ namespace {{index .Namespaces "csharp"}}
 
 #region Synchronous methods
 {{range $i, $m := .Methods}}
-{{setindent "\t\t"}}{{template "METHODCOMMENTS" .}}		public {{formatType .Returns}} {{toPascalCase .Name}} ({{range $i, $v := .Parameters}}{{formatType .Type}} {{.Name}}{{if .Initializer}} = null{{end}}{{if last $i $m.Parameters | not}}, {{else}}{{end}}{{end}})
+{{setindent "\t\t"}}{{template "METHODCOMMENTS" .}}		public {{formatType .Returns}} {{toPascalCase .Name}} ({{range $i, $v := .Parameters}}{{formatType .Type}} {{escape .Name}}{{if .Initializer}} = null{{end}}{{if last $i $m.Parameters | not}}, {{else}}{{end}}{{end}})
 		{
-			{{if isVoid .Returns}}Send{{else}}return MakeRequestAndDeserialize<{{formatType .Returns}}>{{end}}("{{.Name}}", new Dictionary<string, object>() { {{range $i, $v := .Parameters}}{"{{.Name}}", {{.Name}} }{{if last $i $m.Parameters | not}}, {{end}}{{end}} });
+			{{if isVoid .Returns}}Send{{else}}return MakeRequestAndDeserialize<{{formatType .Returns}}>{{end}}("{{.Name}}", new Dictionary<string, object>() { {{range $i, $v := .Parameters}}{"{{.WireName}}", {{escape .Name}} }{{if last $i $m.Parameters | not}}, {{end}}{{end}} });
 		}
 {{end}}
 #endregion
 #region Asynchronous methods
 {{range $i, $m := .Methods}}
-{{setindent "\t\t"}}{{template "METHODCOMMENTS" .}}		public {{if isVoid .Returns}}Task{{else}}Task<{{formatType .Returns}}>{{end}} {{toPascalCase .Name}}Async ({{range $i, $v := .Parameters}}{{formatType .Type}} {{.Name}}{{if .Initializer}} = null{{end}}{{if last $i $m.Parameters | not}}, {{else}}{{end}}{{end}})
+{{setindent "\t\t"}}{{template "METHODCOMMENTS" .}}		public {{if isVoid .Returns}}Task{{else}}Task<{{formatType .Returns}}>{{end}} {{toPascalCase .Name}}Async ({{range $i, $v := .Parameters}}{{formatType .Type}} {{escape .Name}}{{if .Initializer}} = null{{end}}{{if last $i $m.Parameters | not}}, {{else}}{{end}}{{end}})
 		{
-			{{if isVoid .Returns}}return SendAsync{{else}}return MakeRequestAndDeserializeAsync<{{formatType .Returns}}>{{end}}("{{.Name}}", new Dictionary<string, object>() { {{range $i, $v := .Parameters}}{"{{.Name}}", {{.Name}} }{{if last $i $m.Parameters | not}}, {{end}}{{end}} });
+			{{if isVoid .Returns}}return SendAsync{{else}}return MakeRequestAndDeserializeAsync<{{formatType .Returns}}>{{end}}("{{.Name}}", new Dictionary<string, object>() { {{range $i, $v := .Parameters}}{"{{.WireName}}", {{escape .Name}} }{{if last $i $m.Parameters | not}}, {{end}}{{end}} });
 		}
 {{end}}
 #endregion
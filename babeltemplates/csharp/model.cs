@@ -4,6 +4,7 @@
 {{template "SIMPLECOMMENTS" .Comments }}
 using System;
 using System.Collections.Generic;
+using System.Linq;
 using System.Text;
 using Concur.Babel;
 {{range usings}}using {{.}};
@@ -15,13 +16,13 @@ namespace {{index .Namespaces "csharp"}}
 	public enum {{.Name}}
 	{
 {{range $i,$v := .Values}}{{if $i}},
-{{end}}		{{.Name}} = {{formatValue .}}{{end}}
+{{end}}		{{escape .Name}} = {{formatValue .}}{{end}}
 	}
 {{end}}{{range .Consts}}
 {{setindent "\t"}}{{template "COMMENTS" .Comments }}	[System.CodeDom.Compiler.GeneratedCode("Babel", "")]
 	public sealed class {{.Name}}
 	{
-{{range .Values}}		public const {{constType .DataType}} {{.Name}} = {{formatValue .}};
+{{range .Values}}		public const {{constType .DataType}} {{escape .Name}} = {{formatValue .}};
 {{end}}	}
 {{end}}{{range $is, $xs :=  .Structs}}
 {{setindent "\t"}}{{template "COMMENTS" .Comments }}{{template "ATTRS" .Attributes}}	public {{if .Abstract}}abstract {{end}}class {{.Name}}{{if .Extends}} : {{.Extends}}, IBabelModel{{else}} : IBabelModel{{end}}
@@ -34,7 +35,25 @@ namespace {{index .Namespaces "csharp"}}
 			{{toPascalCase .Name}} = {{cast .Type}}{{formatValue .Initializer}};{{end}}{{if .Type.IsList}}
 			{{toPascalCase .Name}} = new {{formatType .Type}}();{{end}}{{if .Type.IsMap}}
 			{{toPascalCase .Name}} = new {{formatType .Type}}();{{end}}{{end}}
-		}{{range .Fields}}
+		}{{if .DecimalFields}}
+
+		/// <summary>
+		/// Rounds the decimal properties to their declared scale.
+		/// </summary>
+		public {{if baseHasDecimals .}}override{{else}}virtual{{end}} void RoundDecimals()
+		{ {{if baseHasDecimals .}}
+			base.RoundDecimals();{{end}}{{range .DecimalFields}}
+			{{roundDecimal . (toPascalCase .Name)}}{{end}}
+		}
+
+		/// <summary>
+		/// Throws an exception if a decimal property does not fit its declared precision and scale.
+		/// </summary>
+		public {{if baseHasDecimals .}}override{{else}}virtual{{end}} void CheckDecimals()
+		{ {{if baseHasDecimals .}}
+			base.CheckDecimals();{{end}}{{range .DecimalFields}}{{range checkDecimal . (toPascalCase .Name)}}
+			{{.}}{{end}}{{end}}
+		}{{end}}{{range .Fields}}
 
 {{setindent "\t\t"}}{{template "COMMENTS" .Comments }}{{template "ATTRS" .Attributes}}		public {{formatType .Type}} {{toPascalCase .Name}} { get; set; }{{end}}
 
@@ -46,11 +65,56 @@ namespace {{index .Namespaces "csharp"}}
 				return (new UTF8Encoding(false)).GetString(strm.ToArray());
 			}
 		}
+{{$s := .}}{{$fields := allFields .}}
+		/// <summary>
+		/// Returns a deep copy of the object.
+		/// </summary>
+		public {{if .Extends}}new {{end}}{{.Name}} Clone()
+		{
+			return ({{.Name}})CloneObject();
+		}
+
+		/// <summary>
+		/// Returns a deep copy of the object as its actual type.
+		/// </summary>
+		protected {{if .Extends}}override{{else}}virtual{{end}} object CloneObject()
+		{
+			var c = ({{.Name}}){{if .Extends}}base.CloneObject(){{else}}MemberwiseClone(){{end}};{{range .Fields}}{{if not (isImmutable .Type)}}
+			c.{{toPascalCase .Name}} = {{copyOf .Type (toPascalCase .Name)}};{{end}}{{end}}
+			return c;
+		}
+
+		public override bool Equals(object obj)
+		{
+			if (ReferenceEquals(this, obj)) return true;
+			if (obj == null || obj.GetType() != GetType()) return false;{{if $fields}}
+			var other = ({{.Name}})obj;
+			return {{range $i, $f := $fields}}{{if $i}}
+				&& {{end}}{{equalsOf .Type (toPascalCase .Name) (printf "other.%s" (toPascalCase .Name))}}{{end}};{{else}}
+			return true;{{end}}
+		}
+
+		public override int GetHashCode()
+		{
+			unchecked
+			{
+				int hash = 17;{{range $fields}}{{if not .Type.IsCollection}}{{if not .Type.IsBinary}}
+				hash = hash * 31 + EqualityComparer<{{formatType .Type}}>.Default.GetHashCode({{toPascalCase .Name}});{{end}}{{end}}{{end}}
+				return hash;
+			}
+		}{{if builders}}{{range $fields}}
+
+		public {{if not (declares $s .)}}new {{end}}{{$s.Name}} With{{toPascalCase .Name}}({{formatType .Type}} value)
+		{
+			{{toPascalCase .Name}} = value;
+			return this;
+		}{{end}}{{end}}
+
 		#region IBabelModel
 		public {{if .Extends}}override {{else}}virtual {{end}}void RunOnChildren<T>(BabelModelAction<T> method, T auxData, bool runOnAll = true)
 		{
 			if(method == null) throw new ArgumentNullException("method");
-{{range .Fields}}			{{if isTrivialProperty .Type}}if(runOnAll) {{end}}{{toPascalCase .Name}} = ({{formatType .Type}}) method("{{.Name}}", typeof({{formatType .Type}}), {{toPascalCase .Name}}, auxData);
+{{range .Fields}}			{{if isTrivialProperty .Type}}if(runOnAll) {{end}}{{toPascalCase .Name}} = ({{formatType .Type}}) method("{{.WireName}}", typeof({{formatType .Type}}), {{toPascalCase .Name}}, auxData);
 {{end}}{{if .Extends}}
 			base.RunOnChildren<T>(method, auxData, runOnAll);{{end}}
 		}
@@ -60,7 +124,7 @@ namespace {{index .Namespaces "csharp"}}
 			if(method == null) throw new ArgumentNullException("method");
 			switch(name)
 			{
-{{range .Fields}}				case "{{.Name}}": {{toPascalCase .Name}} = ({{formatType .Type}}) method("{{.Name}}", typeof({{formatType .Type}}), {{toPascalCase .Name}}, auxData); return true;
+{{range .Fields}}				case "{{.WireName}}": {{toPascalCase .Name}} = ({{formatType .Type}}) method("{{.WireName}}", typeof({{formatType .Type}}), {{toPascalCase .Name}}, auxData); return true;
 {{end}}				default: {{if .Extends}}return base.RunOnChild<T>(name, method, auxData);{{else}}return false;{{end}}
 			}
 		}
@@ -20,14 +20,14 @@ namespace {{index .Namespaces "csharp"}}
 			#region IBabelRequest
 			public void RunOnChildren<T>(BabelModelAction<T> method, T auxData, bool runOnAll = true)
 			{
-{{range .Parameters}}				{{if isTrivialProperty .Type}}if(runOnAll) {{end}}{{toPascalCase .Name}} = ({{formatType .Type}}) method("{{.Name}}", typeof({{formatType .Type}}), {{toPascalCase .Name}}, auxData);
+{{range .Parameters}}				{{if isTrivialProperty .Type}}if(runOnAll) {{end}}{{toPascalCase .Name}} = ({{formatType .Type}}) method("{{.WireName}}", typeof({{formatType .Type}}), {{toPascalCase .Name}}, auxData);
 {{end}}			}
 
 			public bool RunOnChild<T>(string name, BabelModelAction<T> method, T auxData)
 			{
 				switch(name)
 				{
-{{range .Parameters}}					case "{{.Name}}": {{toPascalCase .Name}} = ({{formatType .Type}}) method("{{.Name}}", typeof({{formatType .Type}}), {{toPascalCase .Name}}, auxData); return true;
+{{range .Parameters}}					case "{{.WireName}}": {{toPascalCase .Name}} = ({{formatType .Type}}) method("{{.WireName}}", typeof({{formatType .Type}}), {{toPascalCase .Name}}, auxData); return true;
 {{end}}					default: return false;
 				}
 			}
@@ -39,7 +39,7 @@ namespace {{index .Namespaces "csharp"}}
 			#endregion
 		}
 {{end}}
-{{setindent "\t\t"}}{{template "METHODCOMMENTS" .}}{{template "ATTRS" .Attributes}}		public {{if isVoid .Returns}}System.Threading.Tasks.Task{{else}}System.Threading.Tasks.Task<{{formatType .Returns}}>{{end}} {{.Name}}()
+{{setindent "\t\t"}}{{template "METHODCOMMENTS" .}}{{template "ATTRS" .Attributes}}		public {{if isVoid .Returns}}System.Threading.Tasks.Task{{else}}System.Threading.Tasks.Task<{{formatType .Returns}}>{{end}} {{escape .Name}}()
 		{
 			{{if .Parameters}}var requestData = DeserializeRequest<{{.Name}}Request>();
 			{{end}}return m_businessLogic.{{toPascalCase .Name}}Async({{range $i, $x := .Parameters}}{{if $i}}, {{end}}requestData.{{toPascalCase .Name}}{{end}});
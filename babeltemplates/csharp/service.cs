@@ -13,11 +13,11 @@ namespace {{index .Namespaces "csharp"}}
 {{setindent "\t"}}{{template "COMMENTS" .Comments }}{{template "ATTRS" .Attributes}}	[System.CodeDom.Compiler.GeneratedCode("Babel", "")]
 	public interface I{{.Name}}
 	{ {{range .Methods}}
-{{setindent "\t\t"}}{{template "METHODCOMMENTS" .}}{{template "ATTRS" .Attributes}}		{{formatType .Returns}} {{toPascalCase .Name}}({{range $i, $x := .Parameters}}{{if $i}}, {{end}}{{formatType .Type}} {{.Name}}{{end}});
+{{setindent "\t\t"}}{{template "METHODCOMMENTS" .}}{{template "ATTRS" .Attributes}}		{{formatType .Returns}} {{toPascalCase .Name}}({{range $i, $x := .Parameters}}{{if $i}}, {{end}}{{formatType .Type}} {{escape .Name}}{{end}});
 {{end}}	}
 {{setindent "\t"}}{{template "COMMENTS" .Comments }}{{template "ATTRS" .Attributes}}	[System.CodeDom.Compiler.GeneratedCode("Babel", "")]
 	public interface I{{.Name}}Async
 	{ {{range .Methods}}
-{{setindent "\t\t"}}{{template "METHODCOMMENTS" .}}{{template "ATTRS" .Attributes}}		{{if isVoid .Returns}}System.Threading.Tasks.Task{{else}}System.Threading.Tasks.Task<{{formatType .Returns}}>{{end}} {{toPascalCase .Name}}Async({{range $i, $x := .Parameters}}{{if $i}}, {{end}}{{formatType .Type}} {{.Name}}{{end}});
+{{setindent "\t\t"}}{{template "METHODCOMMENTS" .}}{{template "ATTRS" .Attributes}}		{{if isVoid .Returns}}System.Threading.Tasks.Task{{else}}System.Threading.Tasks.Task<{{formatType .Returns}}>{{end}} {{toPascalCase .Name}}Async({{range $i, $x := .Parameters}}{{if $i}}, {{end}}{{formatType .Type}} {{escape .Name}}{{end}});
 {{end}}	}{{end}}
 }
@@ -6,3 +6,4 @@
 {{end}}{{range .Parameters}}{{indent}}// {{.Name}}: {{range $i,$m := .Comments}}{{if $i}}
 {{indent}}// {{end}}{{.}}{{end}}
 {{end}}{{end}}
+{{define "PARAMS"}}{{if useContext}}{{unusedName . "ctx"}} context.Context{{if .Parameters}}, {{end}}{{end}}{{range $i, $x := .Parameters}}{{if $i}}, {{end}}{{escape .Name}} {{formatType .Type}}{{end}}{{end}}
@@ -4,19 +4,22 @@ package {{package}}
 // *** AUTO-GENERATED FILE - DO NOT MODIFY ***
 // *** Generated from {{.Filename}} ***
 
-import ({{if modelUsesType "decimal"}}
-	"math/big"{{end}}{{if modelUsesType "datetime"}}
-	"time"{{end}}
-{{range imports}}	"{{.}}"
-{{end}})
+import ({{range imports}}
+	{{.}}{{end}}
+)
 
 {{range .Enums}}{{$nm := .Name}}{{setindent ""}}{{template "COMMENTS" .Comments }}type {{$nm}} string
 
 // Values for type {{$nm}}
 const (
-{{range $i,$v := .Values}}	{{$nm}}{{.Name}} = "{{.Name}}"
+{{range $i,$v := .Values}}	{{$nm}}{{.Name}} {{$nm}} = "{{.Name}}"
 {{end}})
 
+var (
+	_{{$nm}}_names  = []{{$nm}}{ {{- range $i,$v := .Values}}{{if $i}}, {{end}}{{$nm}}{{.Name}}{{end -}} }
+	_{{$nm}}_values = map[{{$nm}}]int{ {{- range $i,$v := .Values}}{{if $i}}, {{end}}{{$nm}}{{.Name}}: {{formatValue .}}{{end -}} }
+)
+
 // Get{{$nm}} returns the {{$nm}} for a given integer value.
 func Get{{$nm}}(value int) ({{$nm}}, bool) {
 	switch value {
@@ -33,12 +36,57 @@ func (s *{{$nm}}) Value() (int, bool) {
 	if s == nil {
 		return 0, false
 	}
-	switch *s {
-{{range $i,$v := .Values}}	case {{$nm}}{{.Name}}:
-		return {{formatValue .}}, true
-{{end}}	default:
-		return 0, false
+	v, ok := _{{$nm}}_values[*s]
+	return v, ok
+}
+
+// {{$nm}}Values returns the values of {{$nm}} in the order they are declared.
+func {{$nm}}Values() []{{$nm}} {
+	return append([]{{$nm}}(nil), _{{$nm}}_names...)
+}
+
+// Parse{{$nm}} returns the {{$nm}} with the given name, or an error if there is none.
+func Parse{{$nm}}(s string) ({{$nm}}, error) {
+	if v := {{$nm}}(s); v.IsValid() {
+		return v, nil
+	}
+	return "", fmt.Errorf("%q is not a valid {{$nm}}", s)
+}
+
+// String returns the name of the value.
+func (s {{$nm}}) String() string {
+	return string(s)
+}
+
+// IsValid returns true if the value is one of the declared values of {{$nm}}.
+func (s {{$nm}}) IsValid() bool {
+	_, ok := _{{$nm}}_values[s]
+	return ok
+}
+
+// MarshalText implements encoding.TextMarshaler.{{if eq unknownEnums "error"}} Values other than the
+// declared ones and the empty value are an error.{{end}}
+func (s {{$nm}}) MarshalText() ([]byte, error) {
+{{if eq unknownEnums "error"}}	if s != "" && !s.IsValid() {
+		return nil, fmt.Errorf("%q is not a valid {{$nm}}", string(s))
 	}
+{{end}}	return []byte(s), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. {{if eq unknownEnums "error"}}Names that are not declared
+// are an error.{{else if eq unknownEnums "empty"}}Names that are not declared,
+// such as values added by a newer version of the service, become the empty value.{{else}}Names that are not declared,
+// such as values added by a newer version of the service, are kept.{{end}}
+func (s *{{$nm}}) UnmarshalText(b []byte) error {
+	v := {{$nm}}(b)
+{{if eq unknownEnums "error"}}	if v != "" && !v.IsValid() {
+		return fmt.Errorf("%q is not a valid {{$nm}}", string(b))
+	}
+{{else if eq unknownEnums "empty"}}	if !v.IsValid() {
+		v = ""
+	}
+{{end}}	*s = v
+	return nil
 }
 
 {{end}}
@@ -49,18 +97,57 @@ func (s *{{$nm}}) Value() (int, bool) {
 {{end}}
 {{range $is, $xs :=  .Structs}}
 {{setindent ""}}{{template "COMMENTS" .Comments }}type {{.Name}} struct {{"{"}}{{if .Extends}}
-	{{.Extends}}
+	{{fullNameOf .Extends}}
 {{end}}{{range .Fields}}
-{{setindent "\t"}}{{template "COMMENTS" .Comments }}	{{toPascalCase .Name}} {{formatType .Type}} `json:"{{.Name}}{{serializerOptions .Type}}"`
+{{setindent "\t"}}{{template "COMMENTS" .Comments }}	{{toPascalCase .Name}} {{formatType .Type}} `json:"{{.WireName}}{{serializerOptions .Type}}"`
 {{end}}
 }
 
 // Init sets default values for a {{.Name}}
-func (obj *{{.Name}}) Init() *{{.Name}} {{"{"}}{{range .Fields}}{{if .Initializer}}
-	obj.{{toPascalCase .Name}} = new({{notptr (formatType .Type)}})
-	*obj.{{toPascalCase .Name}} = {{formatValue .Initializer}}{{end}}{{if .Type.IsList}}
+func (obj *{{.Name}}) Init() *{{.Name}} {{"{"}}{{range .Fields}}{{if .Initializer}}{{setDefault .}}{{end}}{{if .Type.IsList}}
 	obj.{{toPascalCase .Name}} = make({{formatType .Type}}, 0){{end}}{{if .Type.IsMap}}
 	obj.{{toPascalCase .Name}} = make({{formatType .Type}}, 0){{end}}{{end}}
 	return obj
 }
-{{end}}
\ No newline at end of file
+{{if .DecimalFields}}
+// RoundDecimals rounds the decimal fields of a {{.Name}} to their declared scale.
+func (obj *{{.Name}}) RoundDecimals() {{"{"}}{{if baseHasDecimals .}}
+	obj.{{.Extends}}.RoundDecimals(){{end}}{{range .DecimalFields}}{{roundDecimal .}}{{end}}
+}
+
+// CheckDecimals returns an error if a decimal field of a {{.Name}} does not fit its declared precision and scale.
+func (obj *{{.Name}}) CheckDecimals() error {{"{"}}{{if baseHasDecimals .}}
+	if err := obj.{{.Extends}}.CheckDecimals(); err != nil {
+		return err
+	}{{end}}{{range .DecimalFields}}{{checkDecimal .}}{{end}}
+	return nil
+}
+{{end}}
+// Clone returns a deep copy of a {{.Name}}.
+func (obj *{{.Name}}) Clone() *{{.Name}} {
+	if obj == nil {
+		return nil
+	}
+	c := *obj{{if .Extends}}
+	c.{{.Extends}} = *obj.{{.Extends}}.Clone(){{end}}{{range .Fields}}{{cloneField .}}{{end}}
+	return &c
+}
+
+// Equal returns true if obj and other hold the same values. Nil and empty
+// lists, maps and binary values are equal.
+func (obj *{{.Name}}) Equal(other *{{.Name}}) bool {
+	if obj == nil || other == nil {
+		return obj == other
+	}{{if .Extends}}
+	if !obj.{{.Extends}}.Equal(&other.{{.Extends}}) {
+		return false
+	}{{end}}{{range .Fields}}{{equalField .}}{{end}}
+	return true
+}
+{{if builders}}{{$s := .}}{{range allFields .}}
+// With{{toPascalCase .Name}} sets {{toPascalCase .Name}} and returns obj.
+func (obj *{{$s.Name}}) With{{toPascalCase .Name}}(value {{builderType .Type}}) *{{$s.Name}} {
+	{{builderSet .}}
+	return obj
+}
+{{end}}{{end}}{{end}}
\ No newline at end of file
@@ -4,15 +4,46 @@ package {{package}}
 // *** AUTO-GENERATED FILE - DO NOT MODIFY ***
 // *** Generated from {{.Filename}} ***
 
-import ({{if serviceUsesType "decimal"}}
-	"math/big"{{end}}{{if serviceUsesType "datetime"}}
-	"time"{{end}}
-{{range imports}}	"{{.}}"
-{{end}})
+import ({{range imports}}
+	{{.}}{{end}}
+)
 
 {{range $k, $s := .Services}}{{if $k}}
 {{end}}
 {{setindent ""}}{{template "COMMENTS" .Comments }}
 type I{{.Name}} interface { {{range .Methods}}
-{{setindent "\t"}}{{template "METHODCOMMENTS" .}}{{indent}}{{toPascalCase .Name}}({{range $i, $x := .Parameters}}{{if $i}}, {{end}}{{.Name}} {{formatType .Type}}{{end}}) {{if formatType .Returns}}({{formatType .Returns}}, error){{else}}error{{end}} 
+{{setindent "\t"}}{{template "METHODCOMMENTS" .}}{{indent}}{{toPascalCase .Name}}({{template "PARAMS" .}}) {{if formatType .Returns}}({{formatType .Returns}}, error){{else}}error{{end}} 
 {{end}}}{{end}}
+
+{{range $k, $s := .Services}}{{if $k}}
+{{end}}{{range .Methods}}
+{{setindent ""}}// {{$s.Name}}{{.Name}}Request is the request structure used for invoking the {{.Name}} method on the {{$s.Name}} service.
+type {{$s.Name}}{{.Name}}Request struct {{"{"}}
+{{range $i, $x := .Parameters}}
+{{setindent "\t"}}{{template "COMMENTS" .Comments }}	{{toPascalCase .Name}} {{formatType .Type}} `json:"{{.WireName}}{{serializerOptions .Type}}"`
+{{end}}
+}
+
+// Init sets default values for a {{.Name}}Request
+func (obj *{{$s.Name}}{{.Name}}Request) Init() *{{$s.Name}}{{.Name}}Request {{"{"}}{{range .Parameters}}{{if .Initializer}}{{setDefault .}}{{end}}{{if .Type.IsList}}
+	obj.{{toPascalCase .Name}} = make({{formatType .Type}}, 0){{end}}{{if .Type.IsMap}}
+	obj.{{toPascalCase .Name}} = make({{formatType .Type}}, 0){{end}}{{end}}
+	return obj
+}
+
+{{setindent ""}}// {{$s.Name}}{{.Name}}Response is the response structure used for invoking the {{.Name}} method on the {{$s.Name}} service.
+type {{$s.Name}}{{.Name}}Response struct {{"{"}}
+{{if formatType .Returns}}
+{{setindent "\t"}}	Value {{formatType .Returns}} `json:"Value{{serializerOptions .Returns}}"`
+{{end}}
+}
+
+// Init sets default values for a {{.Name}}Response
+func (obj *{{$s.Name}}{{.Name}}Response) Init() *{{$s.Name}}{{.Name}}Response {{"{"}}{{if formatType .Returns}}{{if .Returns.IsList}}
+	obj.Value = make({{formatType .Returns}}, 0){{end}}{{if .Returns.IsMap}}
+	obj.Value = make({{formatType .Returns}}, 0){{end}}{{end}}
+	return obj
+}
+
+{{end}}
+{{end}}
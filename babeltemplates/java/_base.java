@@ -7,4 +7,20 @@
 {{end}}{{end}}
 {{define "ATTRS"}}{{$attrs := filterAttrs .}}{{if len $attrs}}{{indent}}{{range $i, $x := $attrs}}{{if $i}}
 {{indent}}{{end}}@{{.Name}}{{if len .Parameters}}({{range $j, $y := .Parameters}}{{if $j}}, {{end}}{{if .Name}}{{.Name}} = {{end}}{{formatValue .}}{{end}}){{end}}{{end}}
-{{end}}{{end}}
\ No newline at end of file
+{{end}}{{end}}
+{{define "JAVAREQUESTS"}}{{range .Methods}}{{if .Parameters}}
+
+{{indent}}private static class {{toPascalCase .Name}}Request {
+{{range .Parameters}}
+{{indent}}{{indent}}@SerializedName("{{.WireName}}")
+{{indent}}{{indent}}private {{formatType .Type}} {{toCamelCase .Name}}{{if .Initializer}} = {{formatInitializerLiteral .}}{{end}};
+{{end}}{{indent}}}{{end}}{{end}}{{end}}
+{{define "JAVAINVOKE"}}{{$r := unusedName . "req"}}{{if .Parameters}}{{toPascalCase .Name}}Request.class{{else}}Object.class{{end}}, {{$r}} -> {{if isVoid .Returns}}{ {{end}}this.service.{{toCamelCase .Name}}({{range $i, $v := .Parameters}}{{if $i}}, {{end}}{{$r}}.{{toCamelCase .Name}}{{end}}){{if isVoid .Returns}}; return null; }, null{{else}}, new TypeToken<{{formatType .Returns}}>() {}.getType(){{end}}{{end}}
+{{define "JACKSONIMPORTS"}}
+import com.fasterxml.jackson.annotation.JsonAutoDetect;
+import com.fasterxml.jackson.annotation.JsonFormat;
+import com.fasterxml.jackson.annotation.JsonInclude;
+import com.fasterxml.jackson.annotation.JsonProperty;{{end}}
+{{define "JACKSONCLASS"}}@JsonInclude(JsonInclude.Include.NON_NULL)
+@JsonAutoDetect(fieldVisibility = JsonAutoDetect.Visibility.ANY, getterVisibility = JsonAutoDetect.Visibility.NONE, isGetterVisibility = JsonAutoDetect.Visibility.NONE, setterVisibility = JsonAutoDetect.Visibility.NONE)
+{{end}}
\ No newline at end of file
@@ -6,7 +6,7 @@ package {{package}};
 {{template "COMMENTS" .Comments }}
 public class {{.Name}} { {{setindent "\t"}}
 	
-{{range .Values}}{{indent}}public static final {{constType .DataType}} {{.Name}} = {{formatValue .}};
+{{range .Values}}{{indent}}public static final {{constType .DataType}} {{escape .Name}} = {{formatValue .}};
 {{end}}
 	
 }
\ No newline at end of file
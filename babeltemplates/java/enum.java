@@ -8,7 +8,7 @@ import java.io.Serializable;
 {{template "COMMENTS" .Comments }}
 public enum {{.Name}} implements com.concur.babel.model.BabelEnum, Serializable {
 {{setindent "\t"}}{{ $e := .}}{{range $i, $v := .Values}}
-{{indent}}{{.Name}}({{.Value}}){{if last $i $e.Values | not}},{{else}};{{end}}{{end}}
+{{indent}}{{escape .Name}}({{.Value}}){{if last $i $e.Values | not}},{{else}};{{end}}{{end}}
 
 {{indent}}private final int value;
 
@@ -21,7 +21,7 @@ public enum {{.Name}} implements com.concur.babel.model.BabelEnum, Serializable
 {{indent}}public static {{.Name}} findByValue(int value) {
 {{indent}}{{indent}}switch(value) { {{indent}}{{indent}}{{indent}}{{range .Values}}
 {{indent}}{{indent}}{{indent}}case {{.Value}}:
-{{indent}}{{indent}}{{indent}}{{indent}}return {{.Name}};{{end}}
+{{indent}}{{indent}}{{indent}}{{indent}}return {{escape .Name}};{{end}}
 {{indent}}{{indent}}{{indent}}default:
 {{indent}}{{indent}}{{indent}}{{indent}}return null;
 {{indent}}{{indent}}}
@@ -6,6 +6,8 @@ package {{package}};
 import java.util.HashMap;
 import java.util.Map;
 
+import com.google.gson.annotations.SerializedName;
+
 import com.concur.babel.ServiceMethod;
 import com.concur.babel.BabelService;
 import com.concur.babel.ResponseServiceMethod;
@@ -65,7 +67,7 @@ public class {{.Name}} implements BabelServiceDefinition { {{setindent "\t"}}
 
 {{indent}}{{indent}}public Map<String, Class<? extends ServiceMethod>> initServiceMethods() {
 {{indent}}{{indent}}{{indent}}Map<String, Class<? extends ServiceMethod>> map = new HashMap<String, Class<? extends ServiceMethod>>();
-{{indent}}{{indent}}{{indent}}{{range $i, $m := .Methods}}map.put("{{toCamelCase .Name}}", {{toPascalCase .Name}}.class);{{end}}
+{{indent}}{{indent}}{{indent}}{{range $i, $m := .Methods}}map.put("{{rawCamelCase .Name}}", {{toPascalCase .Name}}.class);{{end}}
 {{indent}}{{indent}}{{indent}}return map;
 {{indent}}{{indent}}}
 
@@ -77,6 +79,7 @@ public class {{.Name}} implements BabelServiceDefinition { {{setindent "\t"}}
 {{range $i, $m := .Methods}}
 {{indent}}private static class {{toPascalCase .Name}} extends {{if isVoid .Returns}}VoidServiceMethod{{else}}ResponseServiceMethod<{{parseType .Returns}}>{{end}} {	
 {{range $i, $v := .Parameters}}
+{{indent}}{{indent}}@SerializedName("{{.WireName}}")
 {{indent}}{{indent}}private {{formatType .Type}} {{toCamelCase .Name}}{{if .Initializer}} = {{formatInitializerLiteral .}}{{end}};{{end}}
 
 {{if len .Parameters}}{{indent}}{{indent}}public {{toPascalCase .Name}}() {}{{end}}
@@ -86,7 +89,7 @@ public class {{.Name}} implements BabelServiceDefinition { {{setindent "\t"}}
 {{end}}{{indent}}{{indent}}}
 
 {{indent}}{{indent}}public String getServiceName() { return "{{$srv.Name}}"; }
-{{indent}}{{indent}}public String getMethodName() { return "{{toCamelCase .Name}}"; }
+{{indent}}{{indent}}public String getMethodName() { return "{{rawCamelCase .Name}}"; }
 
 {{indent}}{{indent}}public Object[] getMethodParameters() {
 {{indent}}{{indent}}{{indent}}return new Object[] { {{range $i, $v := .Parameters}}this.{{toCamelCase .Name}}{{if last $i $m.Parameters | not}}, {{else}}{{end}}{{end}} };
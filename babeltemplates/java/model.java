@@ -4,16 +4,16 @@
 package {{package}};
 
 import com.google.gson.annotations.SerializedName;
-import java.io.Serializable;
+import java.io.Serializable;{{if jackson}}{{template "JACKSONIMPORTS"}}{{end}}
 {{range imports}}import {{.}}.*;
 {{end}}
 {{$md := .}}
 {{template "COMMENTS" .Comments }}{{template "ATTRS" .Attributes }}
-public{{if .Abstract}} abstract{{end}} class {{.Name}}{{if .Extends}} extends {{.Extends}}{{end}} implements Serializable {	
+{{if jackson}}{{template "JACKSONCLASS"}}{{end}}public{{if .Abstract}} abstract{{end}} class {{.Name}}{{if .Extends}} extends {{.Extends}}{{end}} implements Serializable {	
 {{range .Fields}}{{setindent "\t"}}
 {{template "COMMENTS" .Comments }}
-{{indent}}@SerializedName("{{.Name}}")
-{{indent}}private {{formatType .Type}} {{toCamelCase .Name}}{{if .Initializer}} = {{formatInitializerLiteral .}}{{end}}{{if .Type.IsList}} = new {{formatListInit .Type}}(){{end}}{{if .Type.IsMap}} = new {{formatMapInit .Type}}(){{end}};{{end}}
+{{range jsonAttrs .}}{{indent}}{{.}}
+{{end}}{{indent}}private {{formatType .Type}} {{toCamelCase .Name}}{{if .Initializer}} = {{formatInitializerLiteral .}}{{end}}{{if .Type.IsList}} = new {{formatListInit .Type}}(){{end}}{{if .Type.IsMap}} = new {{formatMapInit .Type}}(){{end}};{{end}}
 
 {{setindent "\t"}}{{indent}}public {{.Name}}() {}
 
@@ -24,17 +24,77 @@ public{{if .Abstract}} abstract{{end}} class {{.Name}}{{if .Extends}} extends {{
 {{indent}}{{indent}}this.{{toCamelCase .Name}} = {{toCamelCase .Name}};{{end}}
 {{indent}}}{{end}}
 {{range .Fields}}
-{{setindent "\t"}}{{indent}}public {{formatType .Type}} {{getterName .}}() { return this.{{toCamelCase .Name}}; };
+{{setindent "\t"}}{{indent}}public {{if isOptional .}}java.util.Optional<{{formatType .Type}}> {{getterName .}}() { return java.util.Optional.ofNullable(this.{{toCamelCase .Name}}); };{{else}}{{formatType .Type}} {{getterName .}}() { return this.{{toCamelCase .Name}}; };{{end}}
 {{setindent "\t"}}{{indent}}public void {{setterName .}}({{formatType .Type}} {{toCamelCase .Name}}) {
 {{indent}}{{indent}}this.{{toCamelCase .Name}} = {{toCamelCase .Name}};
 {{indent}}}
+{{end}}{{if .DecimalFields}}
+{{setindent "\t"}}{{indent}}/**
+{{indent}} * Rounds the decimal fields to their declared scale.
+{{indent}} */{{if baseHasDecimals .}}
+{{indent}}@Override{{end}}
+{{indent}}public void roundDecimals() {{"{"}}{{if baseHasDecimals .}}
+{{indent}}{{indent}}super.roundDecimals();{{end}}{{range .DecimalFields}}
+{{indent}}{{indent}}{{roundDecimal . (printf "this.%s" (toCamelCase .Name))}}{{end}}
+{{indent}}}
+
+{{indent}}/**
+{{indent}} * Throws an exception if a decimal field does not fit its declared precision and scale.
+{{indent}} */{{if baseHasDecimals .}}
+{{indent}}@Override{{end}}
+{{indent}}public void checkDecimals() {{"{"}}{{if baseHasDecimals .}}
+{{indent}}{{indent}}super.checkDecimals();{{end}}{{range .DecimalFields}}{{range checkDecimal . (printf "this.%s" (toCamelCase .Name))}}
+{{indent}}{{indent}}{{.}}{{end}}{{end}}
+{{indent}}}
 {{end}}	
 {{setindent "\t"}}{{indent}}public String toString() {
 {{if len .Fields}}{{indent}}{{indent}}StringBuilder sb = new StringBuilder("{{.Name}}(");{{ $s := .}}
-{{range $i, $v := .Fields}}{{indent}}{{indent}}sb.append("{{toCamelCase .Name}}:");
+{{range $i, $v := .Fields}}{{indent}}{{indent}}sb.append("{{rawCamelCase .Name}}:");
 {{indent}}{{indent}}sb.append(this.{{toCamelCase .Name}} + "{{if last $i $s.Fields | not}}, {{else}}){{end}}");
 {{end}}{{indent}}{{indent}}return sb.toString();{{else}}
 {{indent}}{{indent}}return new StringBuilder("{{.Name}}()").toString();
 {{end}}
 {{indent}}}
-}
\ No newline at end of file
+{{$s := .}}{{$fields := allFields .}}
+{{indent}}/**
+{{indent}} * Returns a deep copy of this object.
+{{indent}} */{{if .Extends}}
+{{indent}}@Override{{end}}{{if .Abstract}}
+{{indent}}public abstract {{.Name}} copy();{{else}}
+{{indent}}public {{.Name}} copy() {
+{{indent}}{{indent}}{{.Name}} c = new {{.Name}}();{{range $fields}}
+{{indent}}{{indent}}c.{{setterName .}}({{copyOf .Type (fieldRef $s . "this")}});{{end}}
+{{indent}}{{indent}}return c;
+{{indent}}}{{end}}
+
+{{indent}}@Override
+{{indent}}public boolean equals(Object o) {
+{{indent}}{{indent}}if (this == o) return true;
+{{indent}}{{indent}}if (o == null || getClass() != o.getClass()) return false;{{if $fields}}
+{{indent}}{{indent}}{{.Name}} other = ({{.Name}}) o;
+{{indent}}{{indent}}return {{range $i, $f := $fields}}{{if $i}}
+{{indent}}{{indent}}{{indent}}&& {{end}}{{equalsOf .Type (fieldRef $s . "this") (fieldRef $s . "other")}}{{end}};{{else}}
+{{indent}}{{indent}}return true;{{end}}
+{{indent}}}
+
+{{indent}}@Override
+{{indent}}public int hashCode() {
+{{indent}}{{indent}}return java.util.Objects.hash({{range $i, $f := $fields}}{{if $i}}, {{end}}{{hashOf .Type (fieldRef $s . "this")}}{{end}});
+{{indent}}}
+{{if and builders (not .Abstract)}}
+{{indent}}/**
+{{indent}} * Builds a {{.Name}}. Each call to build returns a new object.
+{{indent}} */
+{{indent}}public static class Builder {
+{{indent}}{{indent}}private final {{.Name}} obj = new {{.Name}}();
+{{range $fields}}
+{{indent}}{{indent}}public Builder {{toCamelCase .Name}}({{formatType .Type}} value) {
+{{indent}}{{indent}}{{indent}}obj.{{setterName .}}(value);
+{{indent}}{{indent}}{{indent}}return this;
+{{indent}}{{indent}}}
+{{end}}
+{{indent}}{{indent}}public {{.Name}} build() {
+{{indent}}{{indent}}{{indent}}return obj.copy();
+{{indent}}{{indent}}}
+{{indent}}}
+{{end}}}
\ No newline at end of file
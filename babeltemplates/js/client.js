@@ -17,7 +17,7 @@ var client = function(baseUrl, timeoutSeconds, jsonRpc)
 
 {{range $i, $m := .Methods}}
 {{setindent "\t"}}{{template "METHODCOMMENTS" .}}	this.{{toCamelCase .Name}} = function({{range $i, $v := .Parameters}}{{toCamelCase .Name}}, {{end}}callback){
-		client.sendRequest("{{$ns}}.{{$cls}}", "{{.Name}}", { {{range $i, $v := .Parameters}}"{{.Name}}": {{.Name}}{{if last $i $m.Parameters | not}}, {{end}}{{end}} }, callback );			
+		client.sendRequest("{{$ns}}.{{$cls}}", "{{.Name}}", { {{range $i, $v := .Parameters}}"{{.WireName}}": {{toCamelCase .Name}}{{if last $i $m.Parameters | not}}, {{end}}{{end}} }, callback );			
 	}
 {{end}}
 }
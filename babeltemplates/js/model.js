@@ -9,7 +9,33 @@ var BABELRPC = require("babelrpc")
 {{end}}
 
 var ns = BABELRPC.utils.namespace(global, '{{index .Namespaces "js"}}');
+{{if .Structs}}
+// Returns a deep copy of the value of a model field.
+function cloneValue(v) {
+	if (v === null || typeof v !== 'object') return v;
+	if (typeof v.clone === 'function') return v.clone();
+	if (Array.isArray(v)) return v.map(cloneValue);
+	var c = {};
+	for (var k in v) {
+		if (Object.prototype.hasOwnProperty.call(v, k)) c[k] = cloneValue(v[k]);
+	}
+	return c;
+}
 
+// Returns true if the values of two model fields are structurally equal.
+function valuesEqual(a, b) {
+	if (a === b) return true;
+	if (a === null || b === null || typeof a !== 'object' || typeof b !== 'object') return false;
+	if (typeof a.equals === 'function') return a.equals(b);
+	if (Array.isArray(a) !== Array.isArray(b)) return false;
+	var keys = Object.keys(a);
+	if (keys.length !== Object.keys(b).length) return false;
+	for (var i = 0; i < keys.length; i++) {
+		if (!Object.prototype.hasOwnProperty.call(b, keys[i]) || !valuesEqual(a[keys[i]], b[keys[i]])) return false;
+	}
+	return true;
+}
+{{end}}
 {{range .Enums}}{{template "COMMENTS" .Comments }}
 ns['{{.Name}}'] = {
 {{range $i,$v := .Values}}{{if $i}},
@@ -29,10 +55,10 @@ ns['{{.Name}}'] ={
 {
 
 {{range .Fields}}{{template "COMMENTS" .Comments }}{{template "ATTRS" .Attributes}}{{if .Initializer}}
-	this.{{.Name}} = {{cast .Type}}{{formatValue .Initializer}};{{else if .Type.IsList}}
-	this.{{.Name}} = [];{{else if .Type.IsMap}}
-	this.{{.Name}} = {};{{else}}
-	this.{{.Name}} = null;{{end}}
+	this.{{.WireName}} = {{cast .Type}}{{formatValue .Initializer}};{{else if .Type.IsList}}
+	this.{{.WireName}} = [];{{else if .Type.IsMap}}
+	this.{{.WireName}} = {};{{else}}
+	this.{{.WireName}} = null;{{end}}
 {{end}}
 	{{if .Extends}}{{fullNameOf .Extends}}.call(this);{{end}}
 
@@ -42,5 +68,28 @@ ns['{{.Name}}'] ={
 }
 
 {{if .Extends}}BABELRPC.utils.extend(ns['{{.Name}}'], {{fullNameOf .Extends}});{{end}}
-{{end}}
+{{$fields := allFields .}}
+// Returns a deep copy of the object.
+ns['{{.Name}}'].prototype.clone = function()
+{
+	var c = new ns['{{.Name}}']();
+{{range $fields}}	c.{{.WireName}} = cloneValue(this.{{.WireName}});
+{{end}}	return c;
+}
+
+// Returns true if other holds the same values as the object.
+ns['{{.Name}}'].prototype.equals = function(other)
+{
+	if (this === other) return true;
+	if (other === null || typeof other !== 'object') return false;
+	return {{range $i, $f := $fields}}{{if $i}} &&
+		{{end}}valuesEqual(this.{{.WireName}}, other.{{.WireName}}){{else}}true{{end}};
+}
+{{if builders}}{{$s := .}}{{range .Fields}}
+ns['{{$s.Name}}'].prototype.with{{toPascalCase .Name}} = function(value)
+{
+	this.{{.WireName}} = value;
+	return this;
+}
+{{end}}{{end}}{{end}}
 
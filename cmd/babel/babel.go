@@ -20,6 +20,9 @@ import (
 	"github.com/babelrpc/babel/parser"
 )
 
+// diags reports errors and warnings, as text until -diagnostics chooses a format.
+var diags = idl.TextDiagnostics("babel", os.Stderr)
+
 // main entry point
 func main() {
 	// recover panicking parser
@@ -27,15 +30,22 @@ func main() {
 		if r := recover(); r != nil {
 			e, y := r.(*idl.Error)
 			if y {
-				fmt.Fprintln(os.Stderr, e.Error())
-				os.Exit(e.Code)
+				diags.Fatalf(e.Code, e, e.Category, e.Code, "%s\n", e)
 			} else {
-				fmt.Fprintf(os.Stderr, "Exiting due to fatal error:\n%s\n", r)
-				os.Exit(1)
+				err := fmt.Errorf("%s", r)
+				diags.Fatalf(1, err, "Generation", idl.CodeGenerate, "Exiting due to fatal error:\n%s\n", err)
 			}
 		}
 	}()
 
+	if len(os.Args) > 1 && os.Args[1] == "bundle" {
+		status := bundle(os.Args[2:])
+		diags.Exit(status)
+	} else if len(os.Args) > 1 && os.Args[1] == "rename" {
+		status := rename(os.Args[2:])
+		diags.Exit(status)
+	}
+
 	templatesDir := flag.String("templates", generator.LocateTemplateDir(), "Overrides the location of the templates folder")
 	outputJson := flag.Bool("json", false, "Output parse tree in JSON format")
 	lang := flag.String("lang", "", "Generate code with given language csharp|java")
@@ -50,7 +60,15 @@ func main() {
 	serverType := flag.String("servertype", "", "Optional language-specific server type")
 	ver := flag.Bool("version", false, "Display Babel version number")
 	nsMatch := flag.String("ns", "", "Optionally matches files only if namespace starts with this")
+	only := flag.String("only", "", "Comma-separated list of services or Service.Method names to generate, with only the definitions they use")
+	diagFormat := flag.String("diagnostics", "", idl.DiagnosticsUsage)
 	flag.Parse()
+	d, err := idl.StartDiagnostics("babel", *diagFormat, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	diags = d
 
 	if *getHelp {
 		fmt.Printf("The babel command generates source files from Babel IDL files.\n\n")
@@ -61,7 +79,27 @@ func main() {
 		fmt.Printf(`
 If none of -model, -client, or -server are specified, then all three are generated.
 
-Use -scopes to enable attributes that are qualified with a scope.
+Use -scopes to enable attributes that are qualified with a scope. Structs, fields,
+methods and services marked with @babel [Scope("name")] are only generated when
+one of their scopes is enabled.
+
+Use -only to generate just what some services or methods need, including the structs
+that extend the structs they use. Combine it with -inc to also generate the definitions
+they use from imported files, for example:
+
+	babel -lang js -inc -only Users,Orders.Get api.babel
+
+Use "babel bundle" to merge IDL files and their imports into one file, and
+"babel rename" to rename a definition or field everywhere it is used. Run them
+with -help for their options.
+
+Use -servertype to choose the server code generated with -server. C# supports "mvc", the
+default, and "mvcAsync". Java supports "spring" for Spring controllers and "jaxrs" for JAX-RS
+resources that route requests to the implementations of the service interfaces.
+
+Use -diagnostics to write errors and warnings to standard error as msbuild lines,
+json or a SARIF log for build tools and editors. Each record has the file, line,
+column, severity and a stable error code; the codes are listed in the README.
 
 -options are values that are specific to each language. See the documenation for more information.
 	ASP supports "ext", which can be "vbs" or "asp".
@@ -69,6 +107,36 @@ Use -scopes to enable attributes that are qualified with a scope.
 	C# supports "output", which can be used to define output directory options.  Supported options are:
 		ns-flat - code is generated in a single (flat) output directory corresponding to the namespace.
 		ns-nested - code is generated in a multiple (nested) output directories corresponding to the namespace.
+	Go supports "module", which can be "auto" to read the module from the go.mod file in or above
+		the output directory, or a module path for code written to a module of its own. Namespaces
+		inside the module are written to their folder in the module and imported by module path.
+	Go supports "gomod", which can be "true" to write a go.mod file for a module path given with "module".
+	Go supports "ctx", which can be "true" to pass a context.Context as the first parameter of service
+		methods in interfaces, invokers and clients.
+	Go supports options that choose the types of fields, parameters and return values:
+		primitives=pointer|value - pointers to primitive and enum types, or the types themselves.
+		optional=<path>.<Type> - wraps primitive and enum fields in a generic type, such as
+			example.com/opt.Value, which must have a Set method and should have an IsZero method.
+			int64 and decimal fields are not wrapped, so they stay strings in JSON. Wrapped fields
+			are tagged omitzero, which needs Go 1.24, unless omitempty=none is used.
+		datetime=time|string - time.Time or the ISO 8601 string.
+		decimal=rat|float|string - *big.Rat, *big.Float or the decimal string.
+		omitempty=all|nil|none - whether omitempty is used on every field, only on fields that
+			can be nil, or on none.
+	Go supports "unknownenums", which chooses what enums do with names they don't declare when they
+		are unmarshalled: "keep" them (the default), return an "error", or use the "empty" value.
+	Java supports "rest", which can be "true" to also route the operations of methods with a
+		@rest [Op] attribute in the servers generated with -servertype spring or jaxrs.
+	Java supports "style", which chooses how models are written: "beans" with getters and setters
+		(the default), "records", which need Java 16 and implement an interface for each abstract
+		struct they extend, or "immutable" classes with final fields and a Builder.
+	Java supports "jackson", which can be "true" to also annotate models for Jackson. Dates and
+		durations then need Jackson's JavaTimeModule.
+	Java supports "optional", which can be "true" for getters of beans and immutable classes
+		that return an Optional of fields that aren't lists or maps.
+	C#, Go, Java and JavaScript support "builders", which can be "true" to generate a way to set the
+		fields of a model in one expression: With methods in C#, Go and JavaScript, and a Builder
+		class in Java. Models always have methods to make deep copies and compare values.
 
 Babel accepts quite flexible file patterns - and accepts more than one. Here are some examples:
 
@@ -92,8 +160,8 @@ Babel accepts quite flexible file patterns - and accepts more than one. Here are
 	}
 
 	if strings.TrimSpace(*lang) == "" {
-		fmt.Fprintf(os.Stderr, "-lang must be specified\n")
-		os.Exit(1)
+		err := fmt.Errorf("-lang must be specified")
+		diags.Fatalf(1, err, "Command line", idl.CodeUsage, "%s\n", err)
 	}
 
 	if !*genClient && !*genServer && !*genModel {
@@ -109,18 +177,11 @@ Babel accepts quite flexible file patterns - and accepts more than one. Here are
 	if *outputDir != "" {
 		err := os.MkdirAll(*outputDir, os.ModePerm)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating output directory: %s\n", err)
-			os.Exit(2)
+			diags.Fatalf(2, err, "Command line", idl.CodeOutput, "Error creating output directory: %s\n", err)
 		}
 	}
 
-	theScopes := make([]string, 0)
-	for _, s := range strings.Split(*scopes, ",") {
-		sc := strings.TrimSpace(s)
-		if sc != "" {
-			theScopes = append(theScopes, sc)
-		}
-	}
+	theScopes := idl.ParseScopes(*scopes)
 
 	theOptions := make(map[string]string)
 	for _, s := range strings.Split(*options, ",") {
@@ -128,8 +189,8 @@ Babel accepts quite flexible file patterns - and accepts more than one. Here are
 		if sv != "" {
 			kv := strings.Split(sv, "=")
 			if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
-				fmt.Fprintf(os.Stderr, "Invalid options: %s\n", s)
-				os.Exit(3)
+				err := fmt.Errorf("Invalid options: %s", s)
+				diags.Fatalf(3, err, "Command line", idl.CodeOptions, "%s\n", err)
 			}
 			theOptions[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
 		}
@@ -145,97 +206,149 @@ Babel accepts quite flexible file patterns - and accepts more than one. Here are
 		Options:     theOptions,
 		ServerType:  *serverType})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating %s generator: %s\n", *lang, err)
-		os.Exit(4)
+		diags.Fatalf(4, err, "Command line", idl.CodeGenerator, "Error creating %s generator: %s\n", *lang, err)
 	}
 
-	processedFiles := make(map[string]bool)
-	generatedFiles := make(map[string]bool)
-
+	infiles := make([]string, 0)
 	for _, infilePat := range flag.Args() {
-		infiles, err := filepath.Glob(infilePat)
+		matches, err := filepath.Glob(infilePat)
+		if err != nil {
+			diags.Fatalf(5, err, "Command line", idl.CodeNoFiles, "Cannot glob files:\n%s\n", err)
+		}
+		if len(matches) == 0 {
+			diags.Warnf(fmt.Errorf("No files match \"%s\"", infilePat), "Command line", idl.CodeNoFiles, "Warning: No files match \"%s\"\n", infilePat)
+		}
+		infiles = append(infiles, matches...)
+	}
+
+	// parse each file once, keeping the definitions of the scopes
+	parsed := make(map[string]*idl.Idl)
+	parse := func(infile string) *idl.Idl {
+		if bidl, ok := parsed[infile]; ok {
+			return bidl
+		}
+		bidl, err := parser.ParseIdl(infile, *lang)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Cannot glob files:\n%s\n", err)
-			os.Exit(5)
+			diags.Fatalf(6, err, "Parsing", idl.CodeSyntax, "Parsing error:\n%s\n", err)
 		}
-		if len(infiles) == 0 {
-			fmt.Fprintf(os.Stderr, "Warning: No files match \"%s\"\n", infilePat)
+		err = bidl.Prune(theScopes)
+		if err != nil {
+			diags.Fatalf(6, err, "Validation", idl.CodeScopeMismatch, "Scope error:\n%s\n", err)
 		}
+		parsed[infile] = bidl
+		return bidl
+	}
+
+	// the definitions used by -only are found across all files, so that an
+	// import shared by several of them keeps what each one needs
+	foundRoots := make(map[string]bool)
+	var reach *idl.Reachable
+	if *only != "" {
+		files := make([]*idl.Idl, 0)
+		roots := make([]string, 0)
 		for _, infile := range infiles {
-			_, ok := processedFiles[infile]
-			if ok {
-				fmt.Fprintf(os.Stderr, "Already processed %s\n", infile)
-			} else {
-				processedFiles[infile] = true
-				fmt.Printf("%s:\n", infile)
-				bidl, err := parser.ParseIdl(infile, *lang)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Parsing error:\n%s\n", err)
-					os.Exit(6)
+			bidl := parse(infile)
+			files = append(files, bidl)
+			// keep the roots whose services are defined in a file or its imports
+			for _, root := range strings.Split(*only, ",") {
+				root = strings.TrimSpace(root)
+				if root != "" && !foundRoots[root] && bidl.FindService(strings.Split(root, ".")[0]) != nil {
+					roots = append(roots, root)
+					foundRoots[root] = true
 				}
+			}
+		}
+		var err error
+		reach, err = idl.ReachableIn(files, roots...)
+		if err != nil {
+			diags.Fatalf(6, err, "Command line", idl.CodeUsage, "Error in -only:\n%s\n", err)
+		}
+	}
 
-				if strings.HasPrefix(bidl.Namespaces["#default"], *nsMatch) {
-					if *outputJson {
-						b, err := json.MarshalIndent(bidl, "", "  ")
-						if err != nil {
-							fmt.Fprintf(os.Stderr, "json error: %s\n", err)
-						}
-						fmt.Println(string(b))
-						continue
-					}
+	processedFiles := make(map[string]bool)
+	generatedFiles := make(map[string]bool)
+
+	for _, infile := range infiles {
+		_, ok := processedFiles[infile]
+		if ok {
+			diags.Notef("Already processed %s\n", infile)
+		} else {
+			processedFiles[infile] = true
+			fmt.Printf("%s:\n", infile)
+			bidl := parse(infile)
+			if reach != nil {
+				bidl.Retain(reach)
+			}
 
-					files, err := gen.GenerateCode(bidl)
+			if strings.HasPrefix(bidl.Namespaces["#default"], *nsMatch) {
+				if *outputJson {
+					b, err := json.MarshalIndent(bidl, "", "  ")
 					if err != nil {
-						fmt.Fprintf(os.Stderr, "Error generating files for %s: %s\n", filepath.FromSlash(bidl.Filename), err)
-						os.Exit(7)
+						diags.Errorf(err, "Generation", idl.CodeOutput, "json error: %s\n", err)
 					}
-					fmt.Println("\t" + strings.Join(files, "\n\t"))
-					for _, gfn := range files {
-						_, ok := generatedFiles[gfn]
-						if ok {
-							fmt.Fprintf(os.Stderr, "Error - file %s generated in a prior step and overwritten while processing %s\n", gfn, filepath.FromSlash(bidl.Filename))
-							os.Exit(8)
-						} else {
-							generatedFiles[gfn] = true
-						}
+					fmt.Println(string(b))
+					continue
+				}
+
+				files, err := gen.GenerateCode(bidl)
+				if err != nil {
+					e := &idl.Error{Source: bidl.Filename, Category: "Generation", Code: idl.CodeGenerate, Message: err}
+					diags.Fatalf(7, e, "Generation", idl.CodeGenerate, "Error generating files for %s: %s\n", filepath.FromSlash(bidl.Filename), err)
+				}
+				fmt.Println("\t" + strings.Join(files, "\n\t"))
+				for _, gfn := range files {
+					_, ok := generatedFiles[gfn]
+					if ok {
+						e := &idl.Error{Source: bidl.Filename, Category: "Generation", Code: idl.CodeOverwrite, Message: fmt.Errorf("File %s generated in a prior step and overwritten", gfn)}
+						diags.Fatalf(8, e, "Generation", idl.CodeOverwrite, "Error - file %s generated in a prior step and overwritten while processing %s\n", gfn, filepath.FromSlash(bidl.Filename))
+					} else {
+						generatedFiles[gfn] = true
 					}
-				} else {
-					fmt.Println("\tSkipped.")
 				}
+			} else {
+				fmt.Println("\tSkipped.")
+			}
 
-				if *inc {
-					for _, imp := range bidl.UniqueImports() {
-						impFn := filepath.FromSlash(imp.Filename)
-						_, ok := processedFiles[impFn]
-						if ok {
-							fmt.Fprintf(os.Stderr, "Already processed %s\n", impFn)
-						} else {
-							processedFiles[impFn] = true
-							fmt.Printf("%s:\n", impFn)
-
-							if strings.HasPrefix(imp.Namespaces["#default"], *nsMatch) {
-								ifiles, err := gen.GenerateCode(imp)
-								if err != nil {
-									fmt.Fprintf(os.Stderr, "Error generating files for %s: %s\n", impFn, err)
-									os.Exit(9)
-								}
-								fmt.Println("\t" + strings.Join(ifiles, "\n\t"))
-								for _, gfn := range ifiles {
-									_, ok := generatedFiles[gfn]
-									if ok {
-										fmt.Fprintf(os.Stderr, "Error - file %s generated in a prior step and overwritten while processing %s\n", gfn, impFn)
-										os.Exit(10)
-									} else {
-										generatedFiles[gfn] = true
-									}
+			if *inc {
+				for _, imp := range bidl.UniqueImports() {
+					impFn := filepath.FromSlash(imp.Filename)
+					_, ok := processedFiles[impFn]
+					if ok {
+						diags.Notef("Already processed %s\n", impFn)
+					} else {
+						processedFiles[impFn] = true
+						fmt.Printf("%s:\n", impFn)
+
+						if strings.HasPrefix(imp.Namespaces["#default"], *nsMatch) {
+							ifiles, err := gen.GenerateCode(imp)
+							if err != nil {
+								e := &idl.Error{Source: imp.Filename, Category: "Generation", Code: idl.CodeGenerate, Message: err}
+								diags.Fatalf(9, e, "Generation", idl.CodeGenerate, "Error generating files for %s: %s\n", impFn, err)
+							}
+							fmt.Println("\t" + strings.Join(ifiles, "\n\t"))
+							for _, gfn := range ifiles {
+								_, ok := generatedFiles[gfn]
+								if ok {
+									e := &idl.Error{Source: imp.Filename, Category: "Generation", Code: idl.CodeOverwrite, Message: fmt.Errorf("File %s generated in a prior step and overwritten", gfn)}
+									diags.Fatalf(10, e, "Generation", idl.CodeOverwrite, "Error - file %s generated in a prior step and overwritten while processing %s\n", gfn, impFn)
+								} else {
+									generatedFiles[gfn] = true
 								}
-							} else {
-								fmt.Println("\tSkipped.")
 							}
+						} else {
+							fmt.Println("\tSkipped.")
 						}
 					}
 				}
 			}
 		}
 	}
+
+	for _, root := range strings.Split(*only, ",") {
+		root = strings.TrimSpace(root)
+		if root != "" && !foundRoots[root] {
+			diags.Warnf(fmt.Errorf("Service for \"%s\" was not found", root), "Command line", idl.CodeUsage, "Warning: Service for \"%s\" was not found\n", root)
+		}
+	}
+	diags.Exit(0)
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/babelrpc/babel/idl"
+	"github.com/babelrpc/babel/parser"
+)
+
+// bundle implements "babel bundle", which merges IDL files and their imports
+// into a single file. It returns the exit code.
+func bundle(args []string) int {
+	flags := flag.NewFlagSet("bundle", flag.ExitOnError)
+	out := flags.String("out", "", "Output file, defaults to standard output")
+	scopes := flags.String("scopes", "", "Comma-separated list of scopes to enable (no spaces)")
+	only := flags.String("only", "", "Comma-separated list of services or Service.Method names to keep, with only the definitions they use")
+	diagFormat := flags.String("diagnostics", "", idl.DiagnosticsUsage)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "The babel bundle command merges Babel IDL files and everything they import into one file.\n\n")
+		fmt.Fprintf(os.Stderr, "babel bundle [optional flags] <filePattern> [filePattern...]\n\n")
+		flags.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+The first file supplies the namespace, comments and attributes of the bundle.
+Definitions with the same name in different files are renamed by prefixing the
+last part of their namespace, for example Users.Address becomes UsersAddress.
+Services are never renamed; services with the same name are an error.
+`)
+	}
+	flags.Parse(args)
+	d, err := idl.StartDiagnostics("babel", *diagFormat, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	diags = d
+
+	if len(flags.Args()) == 0 {
+		flags.Usage()
+		return 1
+	}
+
+	theScopes := idl.ParseScopes(*scopes)
+	files := make([]*idl.Idl, 0)
+	processedFiles := make(map[string]bool)
+	for _, infilePat := range flags.Args() {
+		infiles, err := filepath.Glob(infilePat)
+		if err != nil {
+			diags.Errorf(err, "Command line", idl.CodeNoFiles, "Cannot glob files:\n%s\n", err)
+			return 5
+		}
+		if len(infiles) == 0 {
+			diags.Warnf(fmt.Errorf("No files match \"%s\"", infilePat), "Command line", idl.CodeNoFiles, "Warning: No files match \"%s\"\n", infilePat)
+		}
+		for _, infile := range infiles {
+			if processedFiles[infile] {
+				continue
+			}
+			processedFiles[infile] = true
+			bidl, err := parser.ParseIdl(infile, "test")
+			if err != nil {
+				diags.Errorf(err, "Parsing", idl.CodeSyntax, "Parsing error:\n%s\n", err)
+				return 6
+			}
+			err = bidl.Prune(theScopes)
+			if err != nil {
+				diags.Errorf(err, "Validation", idl.CodeScopeMismatch, "Scope error:\n%s\n", err)
+				return 6
+			}
+			files = append(files, bidl)
+		}
+	}
+	if len(files) == 0 {
+		return 5
+	}
+
+	b, err := idl.Bundle(files...)
+	if err != nil {
+		diags.Errorf(err, "Generation", idl.CodeGenerate, "Bundle error:\n%s\n", err)
+		return 7
+	}
+	if *only != "" {
+		roots := make([]string, 0)
+		for _, root := range strings.Split(*only, ",") {
+			if root = strings.TrimSpace(root); root != "" {
+				roots = append(roots, root)
+			}
+		}
+		reach, err := b.Reachable(roots...)
+		if err != nil {
+			diags.Errorf(err, "Command line", idl.CodeUsage, "Error in -only:\n%s\n", err)
+			return 6
+		}
+		b.Retain(reach)
+	}
+	err = b.Validate("test")
+	if err != nil {
+		diags.Errorf(err, "Validation", idl.CodeGenerate, "Bundle does not validate:\n%s\n", err)
+		return 7
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			diags.Errorf(err, "Generation", idl.CodeOutput, "Error creating output file: %s\n", err)
+			return 2
+		}
+		defer f.Close()
+		w = f
+	}
+	err = b.Print(w)
+	if err != nil {
+		diags.Errorf(err, "Generation", idl.CodeOutput, "Error writing bundle: %s\n", err)
+		return 8
+	}
+	return 0
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/babelrpc/babel/idl"
+	"github.com/babelrpc/babel/parser"
+)
+
+// rename implements "babel rename", which renames a definition or field and
+// updates every reference to it. It returns the exit code.
+func rename(args []string) int {
+	flags := flag.NewFlagSet("rename", flag.ExitOnError)
+	defFile := flags.String("file", "", "File that declares the name, if more than one file declares it")
+	keepWire := flags.Bool("keepwire", false, "Add a WireName attribute to a renamed field so its serialized name does not change")
+	dryRun := flags.Bool("n", false, "Show the changes without writing them")
+	diagFormat := flags.String("diagnostics", "", idl.DiagnosticsUsage)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "The babel rename command renames a definition or field in Babel IDL files.\n\n")
+		fmt.Fprintf(os.Stderr, "babel rename [optional flags] <oldName> <newName> <filePattern> [filePattern...]\n\n")
+		flags.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+The old name is a const, enum, struct or service, or a field written as
+Struct.Field. The new name is a plain identifier. All of the files that use the
+name must match the file patterns, for example:
+
+	babel rename -keepwire User.Name Login *.babel */*.babel
+
+Struct, enum and const names are not part of the serialized data. Service names
+are part of the URL, so renaming a service is always a breaking change.
+`)
+	}
+	flags.Parse(args)
+	d, err := idl.StartDiagnostics("babel", *diagFormat, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	diags = d
+
+	if len(flags.Args()) < 3 {
+		flags.Usage()
+		return 1
+	}
+	oldName, newName := flags.Arg(0), flags.Arg(1)
+
+	files := make([]*idl.Idl, 0)
+	processedFiles := make(map[string]bool)
+	for _, infilePat := range flags.Args()[2:] {
+		infiles, err := filepath.Glob(infilePat)
+		if err != nil {
+			diags.Errorf(err, "Command line", idl.CodeNoFiles, "Cannot glob files:\n%s\n", err)
+			return 5
+		}
+		if len(infiles) == 0 {
+			diags.Warnf(fmt.Errorf("No files match \"%s\"", infilePat), "Command line", idl.CodeNoFiles, "Warning: No files match \"%s\"\n", infilePat)
+		}
+		for _, infile := range infiles {
+			if processedFiles[infile] {
+				continue
+			}
+			processedFiles[infile] = true
+			bidl, err := parser.ParseIdl(infile, "test")
+			if err != nil {
+				diags.Errorf(err, "Parsing", idl.CodeSyntax, "Parsing error:\n%s\n", err)
+				return 6
+			}
+			files = append(files, bidl)
+		}
+	}
+
+	index := idl.NewIndex(files...)
+	syms := make([]*idl.Symbol, 0)
+	for _, s := range index.Lookup(oldName) {
+		if *defFile == "" || filepath.Clean(filepath.FromSlash(s.File.Filename)) == filepath.Clean(*defFile) {
+			syms = append(syms, s)
+		}
+	}
+	if len(syms) == 0 {
+		err := fmt.Errorf("\"%s\" is not defined in the given files", oldName)
+		diags.Errorf(err, "Command line", idl.CodeUsage, "%s\n", err)
+		return 7
+	} else if len(syms) > 1 {
+		text := fmt.Sprintf("\"%s\" is defined in more than one file, use -file to pick one:", oldName)
+		for _, s := range syms {
+			text += "\n\t" + filepath.FromSlash(s.File.Filename)
+		}
+		diags.Errorf(fmt.Errorf("%s", text), "Command line", idl.CodeUsage, "%s\n", text)
+		return 7
+	}
+	sym := syms[0]
+	if err := index.CheckRename(sym, newName); err != nil {
+		err = fmt.Errorf("Cannot rename %s \"%s\": %s", strings.ToLower(sym.Kind), sym.Name, err)
+		diags.Errorf(err, "Command line", idl.CodeUsage, "%s\n", err)
+		return 7
+	}
+
+	// a field keeps its wire name unless one was given explicitly
+	var wireAttr string
+	if *keepWire {
+		switch sym.Kind {
+		case "Field":
+			if !hasWireNameAttribute(sym.Field) {
+				wireAttr = fmt.Sprintf("@%s [%s(%q)]", idl.BabelScope, idl.WireNameAttribute, sym.Field.WireName())
+			}
+		case "Service":
+			err := fmt.Errorf("Service names are part of the URL and cannot keep their wire name")
+			diags.Errorf(err, "Command line", idl.CodeUsage, "%s\n", err)
+			return 7
+		default:
+			diags.Notef("Note: %s names are not part of the serialized data\n", sym.Kind)
+		}
+	}
+
+	byFile := make(map[string][]idl.Ref)
+	names := make([]string, 0)
+	for _, r := range sym.Refs {
+		if _, ok := byFile[r.Filename]; !ok {
+			names = append(names, r.Filename)
+		}
+		byFile[r.Filename] = append(byFile[r.Filename], r)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fn := filepath.FromSlash(name)
+		for _, r := range byFile[name] {
+			fmt.Printf("%s(%d,%d): %s -> %s\n", fn, r.Pos.Line, r.Pos.Column, r.Name, newName)
+		}
+		if *dryRun {
+			continue
+		}
+		info, err := os.Stat(fn)
+		if err != nil {
+			diags.Errorf(err, "Parsing", idl.CodeOpen, "Error reading %s: %s\n", fn, err)
+			return 8
+		}
+		data, err := ioutil.ReadFile(fn)
+		if err != nil {
+			diags.Errorf(err, "Parsing", idl.CodeOpen, "Error reading %s: %s\n", fn, err)
+			return 8
+		}
+		insert := idl.Pos{}
+		if wireAttr != "" && name == sym.File.Filename {
+			insert = sym.Refs[0].Pos
+		}
+		data, err = renameRefs(data, byFile[name], newName, insert, wireAttr)
+		if err != nil {
+			e := &idl.Error{Source: fn, Category: "Generation", Code: idl.CodeGenerate, Message: err}
+			diags.Errorf(e, "Generation", idl.CodeGenerate, "Error renaming in %s: %s\n", fn, err)
+			return 8
+		}
+		err = ioutil.WriteFile(fn, data, info.Mode())
+		if err != nil {
+			diags.Errorf(err, "Generation", idl.CodeOutput, "Error writing %s: %s\n", fn, err)
+			return 8
+		}
+	}
+	fmt.Printf("Renamed %s \"%s\" to \"%s\": %d references in %d files\n", strings.ToLower(sym.Kind), sym.Name, newName, len(sym.Refs), len(names))
+	return 0
+}
+
+// hasWireNameAttribute returns true if the field has a WireName attribute.
+func hasWireNameAttribute(f *idl.Field) bool {
+	for _, a := range f.Attributes {
+		if a.Scope == idl.BabelScope && a.Name == idl.WireNameAttribute {
+			return true
+		}
+	}
+	return false
+}
+
+// renameRefs replaces the names at the given references with newName. If attr
+// is set, it is written on its own line before the line at insert, with the
+// same indentation.
+func renameRefs(data []byte, refs []idl.Ref, newName string, insert idl.Pos, attr string) ([]byte, error) {
+	lines := strings.Split(string(data), "\n")
+	// work from the end so that earlier columns stay valid
+	sort.Slice(refs, func(a, b int) bool {
+		if refs[a].Pos.Line != refs[b].Pos.Line {
+			return refs[a].Pos.Line > refs[b].Pos.Line
+		}
+		return refs[a].Pos.Column > refs[b].Pos.Column
+	})
+	for _, r := range refs {
+		if r.Pos.Line < 1 || r.Pos.Line > len(lines) {
+			return nil, fmt.Errorf("line %d is out of range", r.Pos.Line)
+		}
+		line := lines[r.Pos.Line-1]
+		// columns count characters, not bytes
+		off := 0
+		for col := 1; col < r.Pos.Column && off < len(line); col++ {
+			_, size := utf8.DecodeRuneInString(line[off:])
+			off += size
+		}
+		if !strings.HasPrefix(line[off:], r.Name) {
+			return nil, fmt.Errorf("expected \"%s\" at line %d, column %d", r.Name, r.Pos.Line, r.Pos.Column)
+		}
+		lines[r.Pos.Line-1] = line[:off] + newName + line[off+len(r.Name):]
+	}
+	if attr != "" && insert.Line > 0 {
+		line := lines[insert.Line-1]
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if strings.HasSuffix(line, "\r") {
+			attr += "\r"
+		}
+		lines = append(lines[:insert.Line-1], append([]string{indent + attr}, lines[insert.Line-1:]...)...)
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
@@ -8,28 +8,43 @@ import (
 
 func allStructs(pidl *idl.Idl) []*idl.Struct {
 	s := make([]*idl.Struct, 0)
-	s = append(s, pidl.Structs...)
-	for _, i := range pidl.UniqueImports() {
-		s = append(s, i.Structs...)
-	}
+	idl.Inspect(pidl, func(n interface{}) bool {
+		switch x := n.(type) {
+		case *idl.Idl:
+			return true
+		case *idl.Struct:
+			s = append(s, x)
+		}
+		return false
+	})
 	return s
 }
 
 func allEnums(pidl *idl.Idl) []*idl.Enum {
 	s := make([]*idl.Enum, 0)
-	s = append(s, pidl.Enums...)
-	for _, i := range pidl.UniqueImports() {
-		s = append(s, i.Enums...)
-	}
+	idl.Inspect(pidl, func(n interface{}) bool {
+		switch x := n.(type) {
+		case *idl.Idl:
+			return true
+		case *idl.Enum:
+			s = append(s, x)
+		}
+		return false
+	})
 	return s
 }
 
 func allServices(pidl *idl.Idl) []*idl.Service {
 	s := make([]*idl.Service, 0)
-	s = append(s, pidl.Services...)
-	for _, i := range pidl.UniqueImports() {
-		s = append(s, i.Services...)
-	}
+	idl.Inspect(pidl, func(n interface{}) bool {
+		switch x := n.(type) {
+		case *idl.Idl:
+			return true
+		case *idl.Service:
+			s = append(s, x)
+		}
+		return false
+	})
 	return s
 }
 
@@ -63,9 +78,16 @@ func typeToItems(pidl *idl.Idl, t *idl.Type) *swagger2.ItemsDef {
 		} else if t.IsDatetime() {
 			it.Type = "string"
 			it.Format = "date-time"
+		} else if t.IsDate() || t.IsTime() || t.IsDuration() || t.IsUUID() {
+			// the formats are the type names: date, time, duration and uuid
+			it.Type = "string"
 		} else if t.IsDecimal() {
 			it.Type = "string"
 			it.Format = ""
+			if t.HasPrecision() {
+				// documents the limits, for example decimal(18,2)
+				it.Format = t.String()
+			}
 		} else if t.IsString() || t.IsChar() {
 			it.Type = "string"
 			it.Format = ""
@@ -100,10 +122,34 @@ func typeToItems(pidl *idl.Idl, t *idl.Type) *swagger2.ItemsDef {
 	return it
 }
 
+// keyDescription documents the allowed keys of a map with enum keys, since
+// swagger has no way to declare them.
+func keyDescription(pidl *idl.Idl, t *idl.Type) string {
+	if t.IsMap() {
+		e := pidl.FindEnum(t.KeyType.Name)
+		if e != nil {
+			keys := make([]string, 0)
+			for _, x := range e.Values {
+				keys = append(keys, x.Name)
+			}
+			return "Keys must be one of: " + strings.Join(keys, ", ")
+		}
+	}
+	return ""
+}
+
+// describe joins comments with the documentation of allowed map keys
+func describe(pidl *idl.Idl, comments []string, t *idl.Type) string {
+	if k := keyDescription(pidl, t); k != "" {
+		return strings.Join(append(append([]string{}, comments...), k), "\n")
+	}
+	return strings.Join(comments, "\n")
+}
+
 func fieldToSchema(pidl *idl.Idl, f *idl.Field) *swagger2.Schema {
 	sc := new(swagger2.Schema)
 	// sc.Title = f.Name
-	sc.Description = strings.Join(f.Comments, "\n")
+	sc.Description = describe(pidl, f.Comments, f.Type)
 	it := typeToItems(pidl, f.Type)
 	sc.Ref = it.Ref
 	sc.Type = it.Type
@@ -117,8 +163,8 @@ func fieldToSchema(pidl *idl.Idl, f *idl.Field) *swagger2.Schema {
 // used by rest
 func fieldToParm(pidl *idl.Idl, fld *idl.Field) *swagger2.Parameter {
 	p := new(swagger2.Parameter)
-	p.Name = fld.Name
-	p.Description = strings.Join(fld.Comments, "\n")
+	p.Name = fld.WireName()
+	p.Description = describe(pidl, fld.Comments, fld.Type)
 	it := typeToItems(pidl, fld.Type)
 	p.Ref = it.Ref
 	p.Type = it.Type
@@ -132,8 +178,8 @@ func fieldToParm(pidl *idl.Idl, fld *idl.Field) *swagger2.Parameter {
 // used by rest
 func fieldToBodyParm(pidl *idl.Idl, fld *idl.Field) *swagger2.Parameter {
 	p := new(swagger2.Parameter)
-	p.Name = fld.Name
-	p.Description = strings.Join(fld.Comments, "\n")
+	p.Name = fld.WireName()
+	p.Description = describe(pidl, fld.Comments, fld.Type)
 	p.Schema = fieldToSchema(pidl, fld)
 	p.Schema.Description = ""
 	return p
@@ -147,7 +193,7 @@ func parmsToSchema(pidl *idl.Idl, m *idl.Method) *swagger2.Schema {
 	sc.Type = "object"
 	if m.HasParameters() {
 		for _, p := range m.Parameters {
-			sc.Properties[p.Name] = *fieldToSchema(pidl, p)
+			sc.Properties[p.WireName()] = *fieldToSchema(pidl, p)
 		}
 	}
 	return sc
@@ -178,7 +224,7 @@ func structToSchema(pidl *idl.Idl, st *idl.Struct) *swagger2.Schema {
 	sc.Properties = make(map[string]swagger2.Schema)
 	sc.Type = "object"
 	for _, p := range st.Fields {
-		sc.Properties[p.Name] = *fieldToSchema(pidl, p)
+		sc.Properties[p.WireName()] = *fieldToSchema(pidl, p)
 	}
 	if st.Extends != "" {
 		sc.AllOf = make([]swagger2.Schema, 0)
@@ -204,7 +250,7 @@ func structToSchema(pidl *idl.Idl, st *idl.Struct) *swagger2.Schema {
 			// add base properties
 			for _, b := range bases {
 				for _, p := range b.Fields {
-					sc.Properties[p.Name] = *fieldToSchema(pidl, p)
+					sc.Properties[p.WireName()] = *fieldToSchema(pidl, p)
 				}
 			}
 		}
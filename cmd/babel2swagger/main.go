@@ -25,6 +25,9 @@ import (
 //go:embed error.json
 var errorModelJSON []byte
 
+// diags reports errors and warnings, as text until -diagnostics chooses a format.
+var diags = idl.TextDiagnostics("babel2swagger", os.Stderr)
+
 // Flags that are global
 var (
 	flatten bool
@@ -77,7 +80,19 @@ func main() {
 
 	flag.BoolVar(&genErr, "error", false, "When -rest is enabled, still include the Babel error definition")
 
+	var scopes string
+	flag.StringVar(&scopes, "scopes", "", "Comma-separated list of scopes to include definitions for")
+
+	var diagFormat string
+	flag.StringVar(&diagFormat, "diagnostics", "", idl.DiagnosticsUsage)
+
 	flag.Parse()
+	d, err := idl.StartDiagnostics("babel2swagger", diagFormat, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	diags = d
 
 	if format != "json" && format != "yaml" {
 		fmt.Printf("-format must be json or yaml.\n")
@@ -85,7 +100,7 @@ func main() {
 	}
 
 	if swagInt && !restful {
-		fmt.Fprintf(os.Stderr, "Warning: Ignoring -int64 because -rest is not set.\n")
+		diags.Warnf(fmt.Errorf("Ignoring -int64 because -rest is not set."), "Command line", idl.CodeUsage, "Warning: Ignoring -int64 because -rest is not set.\n")
 		swagInt = false
 	}
 
@@ -106,23 +121,21 @@ func main() {
 	for _, infilePat := range flag.Args() {
 		infiles, err := filepath.Glob(infilePat)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Cannot glob files: %s\n", err)
-			os.Exit(5)
+			diags.Fatalf(5, err, "Command line", idl.CodeNoFiles, "Cannot glob files: %s\n", err)
 		}
 		if len(infiles) == 0 {
-			fmt.Fprintf(os.Stderr, "Warning: No files match \"%s\"\n", infilePat)
+			diags.Warnf(fmt.Errorf("No files match \"%s\"", infilePat), "Command line", idl.CodeNoFiles, "Warning: No files match \"%s\"\n", infilePat)
 		}
 		for _, infile := range infiles {
 			_, ok := processedFiles[infile]
 			if ok {
-				fmt.Fprintf(os.Stderr, "Already processed %s\n", infile)
+				diags.Notef("Already processed %s\n", infile)
 			} else {
 				processedFiles[infile] = true
 				// fmt.Printf("%s:\n", infile)
 				bidl, err := parser.ParseIdl(infile, "test")
 				if err != nil {
-					fmt.Fprintf(os.Stderr, "Parsing error in %s: %s\n", infile, err)
-					os.Exit(6)
+					diags.Fatalf(6, err, "Parsing", idl.CodeSyntax, "Parsing error in %s: %s\n", infile, err)
 				}
 
 				midl.Imports = append(midl.Imports, bidl)
@@ -130,10 +143,16 @@ func main() {
 		}
 	}
 
+	// remove definitions for other scopes
+	err = midl.Prune(idl.ParseScopes(scopes))
+	if err != nil {
+		diags.Fatalf(6, err, "Validation", idl.CodeScopeMismatch, "Scope error: %s\n", err)
+	}
+
 	// validate combined babel
-	err := midl.Validate("test")
+	err = midl.Validate("test")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Combined IDL does not validate: %s\n", err)
+		diags.Warnf(err, "Validation", idl.ErrorCode(err), "Warning: Combined IDL does not validate: %s\n", err)
 	}
 
 	// convert to swagger
@@ -228,8 +247,7 @@ func main() {
 		if restful {
 			err := addRestService(&swag, &midl, svc)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Cannot generate REST service: %s\n", err)
-				os.Exit(11)
+				diags.Fatalf(11, err, "Generation", idl.CodeRest, "Error: Cannot generate REST service: %s\n", err)
 			}
 		} else {
 			svcComments := strings.Join(svc.Comments, "\n")
@@ -299,7 +317,13 @@ func main() {
 	// Validate swagger
 	errs := swag.Validate()
 	if len(errs) > 0 {
-		fmt.Fprintf(os.Stderr, "Warning: Swagger does not validate\n%s\n", swagger2.ErrorList(errs).Indent("\t"))
+		if diags.Format != "" {
+			for _, e := range errs {
+				diags.Warn(fmt.Errorf("%s", e), "Generation", idl.CodeSwagger)
+			}
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: Swagger does not validate\n%s\n", swagger2.ErrorList(errs).Indent("\t"))
+		}
 	}
 
 	// open output
@@ -308,8 +332,7 @@ func main() {
 	if output != "" {
 		outfile, err = os.Create(output)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Cannot open output file %s\n", output)
-			os.Exit(9)
+			diags.Fatalf(9, err, "Generation", idl.CodeOutput, "Error: Cannot open output file %s\n", output)
 		}
 		defer outfile.Close()
 	}
@@ -318,16 +341,17 @@ func main() {
 	if format == "json" {
 		s, err := swag.Json()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Cannot convert to JSON: %s\n", err)
-			os.Exit(7)
+			diags.Fatalf(7, err, "Generation", idl.CodeOutput, "Cannot convert to JSON: %s\n", err)
 		}
 		fmt.Fprintln(outfile, string(s))
 	} else {
 		s, err := swag.Yaml()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Cannot convert to YAML: %s\n", err)
-			os.Exit(8)
+			diags.Fatalf(8, err, "Generation", idl.CodeOutput, "Cannot convert to YAML: %s\n", err)
 		}
 		fmt.Fprintln(outfile, string(s))
 	}
+
+	// write any warnings
+	diags.Flush()
 }
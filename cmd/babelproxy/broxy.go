@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/ancientlore/flagcfg"
+	"github.com/babelrpc/babel/idl"
 	"github.com/facebookgo/flagenv"
 	"github.com/kardianos/service"
 )
@@ -24,6 +25,10 @@ var (
 	ver     bool
 	control string
 	help    bool
+
+	// diags reports the errors and warnings found while loading the IDL
+	// files, as text until -diagnostics chooses a format
+	diags = idl.TextDiagnostics("babelproxy", os.Stderr)
 )
 
 // init it called before main
@@ -49,6 +54,8 @@ func init() {
 	flag.StringVar(&conf.RestVersion, "restversion", conf.RestVersion, "Set the REST service version number")
 	flag.StringVar(&conf.BabelVersion, "babelversion", conf.BabelVersion, "Set the Babel service version number")
 	flag.StringVar(&conf.Title, "title", conf.Title, "Service name")
+	flag.StringVar(&conf.Scopes, "scopes", conf.Scopes, "Comma-separated list of scopes to include definitions for")
+	flag.StringVar(&conf.Diagnostics, "diagnostics", conf.Diagnostics, "Write IDL errors and warnings to standard error as "+strings.Join(idl.DiagnosticFormats, "|"))
 	flag.BoolVar(&ver, "version", false, "Display the version of babelproxy")
 	flag.BoolVar(&conf.Log, "log", conf.Log, "Log requests")
 	flag.StringVar(&control, "ctl", "", "Service control value - "+strings.Join(service.ControlAction[:], ", "))
@@ -47,6 +47,8 @@ type config struct {
 	BabelVersion string   `toml:"-"`    // service version
 	RestVersion  string   `toml:"-"`    // service version
 	Title        string   `toml:"-"`    // service title
+	Scopes       string   `toml:"-"`    // scopes to include definitions for
+	Diagnostics  string   `toml:"-"`    // format of IDL errors and warnings
 	Args         []string `toml:"args"` // list of file patterns to process
 }
 
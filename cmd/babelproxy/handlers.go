@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"path"
@@ -17,6 +18,7 @@ import (
 	"github.com/babelrpc/babel/idl"
 	"github.com/babelrpc/babel/parser"
 	"github.com/babelrpc/babel/rest"
+	"github.com/babelrpc/babel/types"
 	"github.com/julienschmidt/httprouter"
 )
 
@@ -36,7 +38,7 @@ func loadBabelFiles(args []string) (*idl.Idl, error) {
 			return nil, fmt.Errorf("cannot glob files: %w", err)
 		}
 		if len(infiles) == 0 {
-			log.Printf("Warning: No files match \"%s\"\n", infilePat)
+			diags.Warnf(fmt.Errorf("No files match \"%s\"", infilePat), "Command line", idl.CodeNoFiles, "Warning: No files match \"%s\"\n", infilePat)
 		}
 		for _, infile := range infiles {
 			_, ok := processedFiles[infile]
@@ -55,10 +57,16 @@ func loadBabelFiles(args []string) (*idl.Idl, error) {
 		}
 	}
 
+	// remove definitions for other scopes
+	err := midl.Prune(idl.ParseScopes(conf.Scopes))
+	if err != nil {
+		return nil, fmt.Errorf("scope error: %w", err)
+	}
+
 	// validate combined babel
-	err := midl.Validate("test")
+	err = midl.Validate("test")
 	if err != nil {
-		log.Printf("Warning: Combined IDL does not validate: %s\n", err)
+		diags.Warnf(err, "Validation", idl.ErrorCode(err), "Warning: Combined IDL does not validate: %s\n", err)
 	}
 
 	return &midl, nil
@@ -66,10 +74,15 @@ func loadBabelFiles(args []string) (*idl.Idl, error) {
 
 func allServices(pidl *idl.Idl) []*idl.Service {
 	s := make([]*idl.Service, 0)
-	s = append(s, pidl.Services...)
-	for _, i := range pidl.UniqueImports() {
-		s = append(s, i.Services...)
-	}
+	idl.Inspect(pidl, func(n interface{}) bool {
+		switch x := n.(type) {
+		case *idl.Idl:
+			return true
+		case *idl.Service:
+			s = append(s, x)
+		}
+		return false
+	})
 	return s
 }
 
@@ -81,7 +94,8 @@ func addHandlers(router *httprouter.Router, midl *idl.Idl) error {
 			count++
 			op, err := rest.ReadOp(mth)
 			if err != nil {
-				log.Fatalf("Cannot process %s.%s: %s", svc.Name, mth.Name, err)
+				err = fmt.Errorf("Cannot process %s.%s: %w", svc.Name, mth.Name, err)
+				diags.Fatalf(1, err, "Generation", idl.CodeRest, "%s\n", err)
 			}
 			if !op.Hide {
 				routePath := re.ReplaceAllString(path.Join(conf.RestPath, op.Path), ":$1")
@@ -128,7 +142,7 @@ func makeHandler(midl *idl.Idl, svc *idl.Service, mth *idl.Method) (httprouter.H
 				http.Error(w, err.Error(), 500)
 				return
 			}
-			nm := fld.Name
+			nm := fld.WireName()
 			if annotation.Name != "" {
 				nm = annotation.Name
 			}
@@ -138,30 +152,30 @@ func makeHandler(midl *idl.Idl, svc *idl.Service, mth *idl.Method) (httprouter.H
 				if len(val) > 0 || annotation.Required {
 					v, err := toType(val, midl, fld.Type, annotation.Format)
 					if err != nil {
-						http.Error(w, err.Error(), 500)
+						http.Error(w, err.Error(), statusOf(err))
 						return
 					}
-					req[fld.Name] = v
+					req[fld.WireName()] = v
 				}
 			case rest.HEADER:
 				val := r.Header[nm]
 				if len(val) > 0 || annotation.Required {
 					v, err := toType(val, midl, fld.Type, annotation.Format)
 					if err != nil {
-						http.Error(w, err.Error(), 500)
+						http.Error(w, err.Error(), statusOf(err))
 						return
 					}
-					req[fld.Name] = v
+					req[fld.WireName()] = v
 				}
 			case rest.PATH:
 				val := p.ByName(nm)
 				if val != "" || annotation.Required {
 					v, err := toType([]string{val}, midl, fld.Type, annotation.Format)
 					if err != nil {
-						http.Error(w, err.Error(), 500)
+						http.Error(w, err.Error(), statusOf(err))
 						return
 					}
-					req[fld.Name] = v
+					req[fld.WireName()] = v
 				}
 			case rest.FORMDATA:
 				// in theory form data is not legal
@@ -182,16 +196,22 @@ func makeHandler(midl *idl.Idl, svc *idl.Service, mth *idl.Method) (httprouter.H
 						vals[k] = v
 					}
 				}
-				req[fld.Name] = vals
+				req[fld.WireName()] = vals
 			case rest.BODY:
 				enc := json.NewDecoder(r.Body)
+				enc.UseNumber()
 				var m interface{}
 				err := enc.Decode(&m)
 				if err != nil {
-					http.Error(w, err.Error(), 500)
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				err = checkValue(m, midl, fld.Type)
+				if err != nil {
+					http.Error(w, err.Error(), statusOf(err))
 					return
 				}
-				req[fld.Name] = m
+				req[fld.WireName()] = m
 			}
 		}
 		// post to server
@@ -217,6 +237,39 @@ func makeHandler(midl *idl.Idl, svc *idl.Service, mth *idl.Method) (httprouter.H
 	return handle, nil
 }
 
+// badRequest is an error in the input of a client, which is answered with
+// status 400 rather than 500.
+type badRequest struct {
+	error
+}
+
+// statusOf returns the HTTP status to answer an error with.
+func statusOf(err error) int {
+	if errors.As(err, &badRequest{}) {
+		return http.StatusBadRequest
+	}
+	return 500
+}
+
+// parsed returns a value parsed from a parameter, or a badRequest if the
+// parameter is not valid.
+func parsed(v interface{}, err error) (interface{}, error) {
+	if err != nil {
+		return nil, badRequest{err}
+	}
+	return v, nil
+}
+
+// parsedString returns the standard form of a value parsed from a parameter,
+// which is how it is sent to the service, or a badRequest if the parameter is
+// not valid.
+func parsedString(v fmt.Stringer, err error) (interface{}, error) {
+	if err != nil {
+		return nil, badRequest{err}
+	}
+	return v.String(), nil
+}
+
 func one(val []string) string {
 	if len(val) == 0 {
 		return ""
@@ -227,19 +280,33 @@ func one(val []string) string {
 
 func toType(val []string, midl *idl.Idl, typ *idl.Type, fmt rest.ListFmt) (interface{}, error) {
 	if typ.IsBool() {
-		return strconv.ParseBool(one(val))
+		return parsed(strconv.ParseBool(one(val)))
 	} else if typ.IsInt() {
 		if typ.Name == "int64" {
 			// int64 is quoted
 			return one(val), nil
 		} else {
-			return strconv.ParseInt(one(val), 10, 32)
+			return parsed(strconv.ParseInt(one(val), 10, 32))
+		}
+	} else if typ.HasPrecision() {
+		// quoted, but limited in size
+		if err := types.CheckDecimal(one(val), typ.Precision, typ.Scale); err != nil {
+			return nil, badRequest{err}
 		}
+		return one(val), nil
 	} else if typ.IsString() || typ.IsChar() || typ.IsDatetime() || typ.IsBinary() || typ.IsDecimal() {
 		// treat as string - these are all quoted
 		return one(val), nil
 	} else if typ.IsFloat() {
-		return strconv.ParseFloat(one(val), 64)
+		return parsed(strconv.ParseFloat(one(val), 64))
+	} else if typ.IsDate() {
+		return parsedString(types.ParseDate(one(val)))
+	} else if typ.IsTime() {
+		return parsedString(types.ParseTime(one(val)))
+	} else if typ.IsDuration() {
+		return parsedString(types.ParseDuration(one(val)))
+	} else if typ.IsUUID() {
+		return parsedString(types.ParseUUID(one(val)))
 	} else if typ.IsList() && typ.ValueType.IsPrimitive() {
 		var sep string
 		switch fmt {
@@ -284,6 +351,55 @@ func toType(val []string, midl *idl.Idl, typ *idl.Type, fmt rest.ListFmt) (inter
 	return nil, errors.New("Unexpected type: " + typ.String())
 }
 
+// checkValue verifies that the decimals in a decoded JSON value fit the
+// precision and scale declared for them, and that the keys of maps with
+// enum keys are values of the enum. The value must be decoded with UseNumber
+// so that decimals sent as JSON numbers are checked without rounding.
+func checkValue(val interface{}, midl *idl.Idl, typ *idl.Type) error {
+	switch v := val.(type) {
+	case json.Number:
+		return checkValue(string(v), midl, typ)
+	case string:
+		if typ.HasPrecision() {
+			if err := types.CheckDecimal(v, typ.Precision, typ.Scale); err != nil {
+				return badRequest{err}
+			}
+		}
+	case []interface{}:
+		if typ.IsList() {
+			for _, x := range v {
+				if err := checkValue(x, midl, typ.ValueType); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]interface{}:
+		if typ.IsMap() {
+			e := midl.FindEnum(typ.KeyType.Name)
+			for k, x := range v {
+				if e != nil && e.FindValue(k) == nil {
+					return badRequest{fmt.Errorf("%s is not a valid %s key", k, e.Name)}
+				}
+				if err := checkValue(x, midl, typ.ValueType); err != nil {
+					return err
+				}
+			}
+		} else if typ.IsUserDefined() {
+			for s := midl.FindStruct(typ.Name); s != nil; s = midl.FindStruct(s.Extends) {
+				for _, fld := range s.Fields {
+					if err := checkValue(v[fld.WireName()], midl, fld.Type); err != nil {
+						return err
+					}
+				}
+				if s.Extends == "" {
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func makeBabelHandler(midl *idl.Idl, svc *idl.Service, mth *idl.Method) (httprouter.Handle, error) {
 
 	destPath := path.Join(conf.BabelPath, svc.Name, mth.Name)
@@ -293,8 +409,33 @@ func makeBabelHandler(midl *idl.Idl, svc *idl.Service, mth *idl.Method) (httprou
 	destUrl := conf.BabelProto + "://" + conf.BabelAddr + destPath
 	handle := func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 		kubismus.Metric("Requests", 1, 0)
+		// check the parameters before passing them on
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		// an empty body is a request without parameters
+		if len(bytes.TrimSpace(b)) == 0 {
+			b = []byte("{}")
+		}
+		var req map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		err = dec.Decode(&req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, fld := range mth.Parameters {
+			err = checkValue(req[fld.WireName()], midl, fld.Type)
+			if err != nil {
+				http.Error(w, err.Error(), statusOf(err))
+				return
+			}
+		}
 		// post to server
-		httpreq, err := http.NewRequest("POST", destUrl, r.Body)
+		httpreq, err := http.NewRequest("POST", destUrl, bytes.NewReader(b))
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -305,7 +446,7 @@ func makeBabelHandler(midl *idl.Idl, svc *idl.Service, mth *idl.Method) (httprou
 		}
 		httpreq.Header.Set("Content-Type", "application/json")
 		//httpreq.Header.Set("Accept", "application/json")
-		// httpreq.ContentLength = int64(len(b))
+		httpreq.ContentLength = int64(len(b))
 		httpreq.Close = false
 		doHttp(httpreq, w)
 	}
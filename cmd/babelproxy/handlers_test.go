@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/babelrpc/babel/parser"
+)
+
+func TestBadParameters(t *testing.T) {
+	midl, err := parser.ParseIdl(filepath.Join("..", "..", "parser", "test", "new_types.babel"), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := midl.FindService("Calendar")
+	handle, err := makeHandler(midl, svc, svc.Methods[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the parameters of Find are in the query string
+	for _, query := range []string{"first=2024-13-45", "last=noon", "owner=not-a-uuid"} {
+		w := httptest.NewRecorder()
+		handle(w, httptest.NewRequest("GET", "/Calendar/Find?"+query, nil), nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s was answered with %d instead of %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestDecimalNumbers(t *testing.T) {
+	midl, err := parser.ParseIdl(filepath.Join("..", "..", "parser", "test", "decimal_precision.babel"), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := midl.FindService("Payments")
+	handle, err := makeBabelHandler(midl, svc, svc.Methods[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// decimals are checked whether they are sent as strings or as numbers
+	for _, body := range []string{`{"amount": "1.234"}`, `{"amount": 1.234}`, `{"amount": 1e18}`, `{"rate": 0.00001}`} {
+		w := httptest.NewRecorder()
+		handle(w, httptest.NewRequest("POST", "/Payments/Convert", strings.NewReader(body)), nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s was answered with %d instead of %d", body, w.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"runtime"
 	"strings"
 	"time"
 
 	"github.com/ancientlore/kubismus"
+	"github.com/babelrpc/babel/idl"
 	"github.com/julienschmidt/httprouter"
 	"github.com/kardianos/service"
 	"golang.org/x/net/context"
@@ -90,10 +92,15 @@ func (i *svcImpl) doWork() {
 	go http.ListenAndServe(conf.StatusAddr, mux)
 
 	// read files
-	midl, err := loadBabelFiles(conf.Args)
+	d, err := idl.StartDiagnostics("babelproxy", conf.Diagnostics, os.Stderr)
 	if err != nil {
 		log.Fatal(err)
 	}
+	diags = d
+	midl, err := loadBabelFiles(conf.Args)
+	if err != nil {
+		diags.Fatalf(1, err, "Parsing", idl.CodeSyntax, "%s\n", err)
+	}
 
 	// handle swagger UI and JSON generator
 	router := httprouter.New()
@@ -112,6 +119,7 @@ func (i *svcImpl) doWork() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	diags.Flush()
 
 	// listen up!
 	log.Fatal(http.ListenAndServe(conf.RestAddr, router))
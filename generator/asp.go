@@ -2,7 +2,6 @@ package generator
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 	"text/template"
@@ -34,9 +33,17 @@ func (gen *aspGenerator) internalType(t *idl.Type) string {
 	if t.Name == "list" {
 		s = fmt.Sprintf("list<%s>", gen.internalType(t.ValueType))
 	} else if t.Name == "map" {
-		s = fmt.Sprintf("map<%s,%s>", t.KeyType, gen.internalType(t.ValueType))
+		key := gen.internalType(t.KeyType)
+		if t.KeyType.IsEnum(gen.tplRootIdl) {
+			// enum keys are passed as their names
+			key = "string"
+		}
+		s = fmt.Sprintf("map<%s,%s>", key, gen.internalType(t.ValueType))
 	} else if t.IsEnum(gen.tplRootIdl) {
 		s = "enum"
+	} else if t.IsDate() || t.IsTime() || t.IsDuration() || t.IsUUID() {
+		// the ASP runtime has no equivalents, so these are passed as their wire format
+		s = "string"
 	} else if !t.IsUserDefined() {
 		s = t.Name
 	} else {
@@ -203,14 +210,9 @@ func (gen *aspGenerator) GenCodeInternal(pidl *idl.Idl, outFname string, templat
 	gen.resetTemplate(pidl)
 
 	if len(pidl.Consts) > 0 || len(pidl.Enums) > 0 || len(pidl.Structs) > 0 || len(pidl.Services) > 0 {
-		outFile, err := os.Create(outFname)
-		if err != nil {
-			return nil, fmt.Errorf("can't create output file: %w", err)
-		}
-		defer outFile.Close()
-		err = gen.templates.ExecuteTemplate(outFile, template, pidl)
+		err := gen.writeTemplate(outFname, template, pidl)
 		if err != nil {
-			return nil, fmt.Errorf("error executing template: %w", err)
+			return nil, err
 		}
 		return []string{outFname}, nil
 	} else {
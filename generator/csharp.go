@@ -23,6 +23,12 @@ var (
 		"float64":  "double",
 		"string":   "string",
 		"datetime": "DateTime",
+		// the C# serializer does not write DateTime, TimeSpan and Guid in the
+		// ISO formats of these types, so they are passed as their wire format
+		"date":     "string",
+		"time":     "string",
+		"duration": "string",
+		"uuid":     "string",
 		"decimal":  "decimal",
 		"char":     "char",
 		"binary":   "byte[]",
@@ -57,8 +63,8 @@ func (gen *csharpGenerator) formatType(t *idl.Type) string {
 	if t.Name == "list" {
 		s = fmt.Sprintf(ms, gen.formatType(t.ValueType))
 	} else if t.Name == "map" {
-		s = fmt.Sprintf(ms, csharpTypes[t.KeyType.Name], gen.formatType(t.ValueType))
-	} else if t.IsPrimitive() && t.Name != "string" {
+		s = fmt.Sprintf(ms, gen.formatKey(csharpTypes, t.KeyType), gen.formatType(t.ValueType))
+	} else if t.IsPrimitive() && ms != "string" {
 		s = ms + "?"
 	} else if t.IsEnum(gen.tplRootIdl) {
 		s = ms + "?"
@@ -79,7 +85,7 @@ func (gen *csharpGenerator) fullTypeName(t *idl.Type) string {
 	if t.Name == "list" {
 		s = fmt.Sprintf(ms, gen.fullTypeName(t.ValueType))
 	} else if t.Name == "map" {
-		s = fmt.Sprintf(ms, csharpTypes[t.KeyType.Name], gen.fullTypeName(t.ValueType))
+		s = fmt.Sprintf(ms, gen.formatKey(csharpTypes, t.KeyType), gen.fullTypeName(t.ValueType))
 	} else {
 		ns := gen.tplRootIdl.NamespaceOf(ms, "csharp")
 		if ns != "" {
@@ -107,7 +113,7 @@ func (gen *csharpGenerator) fullNameOf(name string) string {
 // formatLiteral formats a literal value for the C# parser.
 func (gen *csharpGenerator) formatLiteral(value interface{}, typeName string) string {
 	if typeName == "#ref" {
-		return gen.fullNameOf(value.(string))
+		return escapeRef("csharp", gen.fullNameOf(value.(string)))
 	} else if typeName == "char" {
 		return fmt.Sprintf("%q", value)
 	} else {
@@ -137,6 +143,103 @@ func (gen *csharpGenerator) isTrivialProperty(t *idl.Type) bool {
 	return t.IsPrimitive() || t.Name == "binary" || t.IsEnum(gen.tplRootIdl)
 }
 
+// immutable returns true if values of the type can be shared by copies.
+func (gen *csharpGenerator) immutable(t *idl.Type) bool {
+	return t.IsPrimitive() || t.IsEnum(gen.tplRootIdl)
+}
+
+// copyOf returns an expression that makes a deep copy of expr, which is
+// evaluated more than once. depth numbers the parameters of nested lambdas.
+func (gen *csharpGenerator) copyOf(t *idl.Type, expr string, depth int) string {
+	switch {
+	case gen.immutable(t):
+		return expr
+	case t.IsCollection() && gen.immutable(t.ValueType):
+		return fmt.Sprintf("%s == null ? null : new %s(%s)", expr, gen.formatType(t), expr)
+	case t.IsList():
+		v := fmt.Sprintf("v%d", depth)
+		return fmt.Sprintf("%s == null ? null : %s.ConvertAll(%s => %s)", expr, expr, v, gen.copyOf(t.ValueType, v, depth+1))
+	case t.IsMap():
+		e := fmt.Sprintf("e%d", depth)
+		return fmt.Sprintf("%s == null ? null : %s.ToDictionary(%s => %s.Key, %s => %s)", expr, expr, e, e, e, gen.copyOf(t.ValueType, e+".Value", depth+1))
+	case t.IsBinary():
+		return fmt.Sprintf("%s == null ? null : (byte[])%s.Clone()", expr, expr)
+	default:
+		return fmt.Sprintf("%s == null ? null : %s.Clone()", expr, expr)
+	}
+}
+
+// equalsOf returns an expression that is true if a and b, which are evaluated
+// more than once, hold the same values. depth numbers the parameters of nested
+// lambdas.
+func (gen *csharpGenerator) equalsOf(t *idl.Type, a, b string, depth int) string {
+	switch {
+	case gen.immutable(t):
+		return fmt.Sprintf("%s == %s", a, b)
+	case t.IsBinary() || (t.IsList() && gen.immutable(t.ValueType)):
+		return fmt.Sprintf("(%s == null ? %s == null : %s != null && %s.SequenceEqual(%s))", a, b, b, a, b)
+	case t.IsList():
+		i := fmt.Sprintf("i%d", depth)
+		return fmt.Sprintf("(%s == null ? %s == null : %s != null && %s.Count == %s.Count && Enumerable.Range(0, %s.Count).All(%s => %s))", a, b, b, a, b, a, i,
+			gen.equalsOf(t.ValueType, a+"["+i+"]", b+"["+i+"]", depth+1))
+	case t.IsMap():
+		e := fmt.Sprintf("e%d", depth)
+		return fmt.Sprintf("(%s == null ? %s == null : %s != null && %s.Count == %s.Count && %s.All(%s => %s.ContainsKey(%s.Key) && %s))", a, b, b, a, b, a, e, b, e,
+			gen.equalsOf(t.ValueType, e+".Value", b+"["+e+".Key]", depth+1))
+	default:
+		return fmt.Sprintf("object.Equals(%s, %s)", a, b)
+	}
+}
+
+// roundOf returns an expression that makes a copy of expr, which is evaluated
+// more than once, with its decimals rounded to their declared scale. The type
+// must hold decimals with a precision, directly or in lists and maps. depth
+// numbers the parameters of nested lambdas.
+func (gen *csharpGenerator) roundOf(t *idl.Type, expr string, depth int) string {
+	switch {
+	case t.IsList():
+		v := fmt.Sprintf("v%d", depth)
+		return fmt.Sprintf("%s == null ? null : %s.ConvertAll(%s => %s)", expr, expr, v, gen.roundOf(t.ValueType, v, depth+1))
+	case t.IsMap():
+		e := fmt.Sprintf("e%d", depth)
+		return fmt.Sprintf("%s == null ? null : %s.ToDictionary(%s => %s.Key, %s => %s)", expr, expr, e, e, e, gen.roundOf(t.ValueType, e+".Value", depth+1))
+	default:
+		return fmt.Sprintf("%s.HasValue ? (decimal?)Math.Round(%s.Value, %d, MidpointRounding.AwayFromZero) : null", expr, expr, t.Scale)
+	}
+}
+
+// roundDecimal returns the statement of RoundDecimals that rounds the decimals
+// of field f, which is the property prop.
+func (gen *csharpGenerator) roundDecimal(f *idl.Field, prop string) string {
+	if f.Type.HasPrecision() {
+		return fmt.Sprintf("if(%s.HasValue) %s = Math.Round(%s.Value, %d, MidpointRounding.AwayFromZero);", prop, prop, prop, f.Type.Scale)
+	}
+	return fmt.Sprintf("%s = %s;", prop, gen.roundOf(f.Type, prop, 0))
+}
+
+// checkDecimal returns the lines of the statement of CheckDecimals that throws
+// an exception if a decimal of field f, the property prop, does not fit its
+// declared precision and scale. The values of lists and maps are checked in
+// loops.
+func (gen *csharpGenerator) checkDecimal(f *idl.Field, prop string) []string {
+	var lines []string
+	t, expr, depth := f.Type, prop, 0
+	for ; t.IsList() || t.IsMap(); t, depth = t.ValueType, depth+1 {
+		v := fmt.Sprintf("v%d", depth)
+		values := expr
+		if t.IsMap() {
+			values += ".Values"
+		}
+		lines = append(lines, fmt.Sprintf("%sif(%s != null) foreach(var %s in %s)", strings.Repeat("\t", depth), expr, v, values))
+		expr = v
+	}
+	indent := strings.Repeat("\t", depth)
+	return append(lines,
+		fmt.Sprintf("%sif(%s.HasValue && (%s.Value != Math.Round(%s.Value, %d) || Math.Abs(Math.Truncate(%s.Value)) >= 1E%dM))",
+			indent, expr, expr, expr, t.Scale, expr, t.IntegerDigits()),
+		fmt.Sprintf("%s\tthrow new ArgumentOutOfRangeException(%q, %s.Value, %q);", indent, prop, expr, "Value does not fit "+t.String()))
+}
+
 // init sets up the generator for use and loads the templates.
 func (gen *csharpGenerator) init(args *Arguments) error {
 	if !args.GenClient && !args.GenModel && !args.GenServer {
@@ -155,6 +258,10 @@ func (gen *csharpGenerator) init(args *Arguments) error {
 				} else if v != "ns-flat" && v != "ns-nested" {
 					return fmt.Errorf("invalid output option: %s.  Valid options are 'ns-flat' and 'ns-nested'", v)
 				}
+			case "builders":
+				if err := gen.setBuilders(v); err != nil {
+					return err
+				}
 			default:
 				return fmt.Errorf("the %s option is not applicable to language csharp", k)
 			}
@@ -168,6 +275,11 @@ func (gen *csharpGenerator) init(args *Arguments) error {
 		"filterAttrs":       func(attrs []*idl.Attribute) []*idl.Attribute { return gen.filterAttributes(attrs) },
 		"isVoid":            func(t *idl.Type) bool { return gen.isVoid(t) },
 		"isTrivialProperty": func(t *idl.Type) bool { return gen.isTrivialProperty(t) },
+		"isImmutable":       func(t *idl.Type) bool { return gen.immutable(t) },
+		"copyOf":            func(t *idl.Type, expr string) string { return gen.copyOf(t, expr, 0) },
+		"equalsOf":          func(t *idl.Type, a, b string) string { return gen.equalsOf(t, a, b, 0) },
+		"roundDecimal":      func(f *idl.Field, prop string) string { return gen.roundDecimal(f, prop) },
+		"checkDecimal":      func(f *idl.Field, prop string) []string { return gen.checkDecimal(f, prop) },
 		"usings": func() []string {
 			pkg := gen.tplRootIdl.Namespaces["csharp"]
 			imports := make([]string, 0)
@@ -286,14 +398,9 @@ func (gen *csharpGenerator) GenCodeInternal(pidl *idl.Idl, outFname string, temp
 		if err != nil {
 			return nil, fmt.Errorf("can't create output dir: %w", err)
 		}
-		outFile, err := os.Create(outFname)
-		if err != nil {
-			return nil, fmt.Errorf("can't create output file: %w", err)
-		}
-		defer outFile.Close()
-		err = gen.templates.ExecuteTemplate(outFile, template, pidl)
+		err = gen.writeTemplate(outFname, template, pidl)
 		if err != nil {
-			return nil, fmt.Errorf("error executing template: %w", err)
+			return nil, err
 		}
 		return []string{outFname}, nil
 	} else {
@@ -0,0 +1,51 @@
+package generator
+
+import (
+	"regexp"
+	"testing"
+)
+
+var csharpProperty = regexp.MustCompile(`^\s*public [^(]+ \{ get; set; \}\s*$`)
+
+func TestCSharpTypes(t *testing.T) {
+	// the types without an ISO format in the C# serializer are passed as strings
+	g := generate(t, "csharp", Arguments{GenModel: true}, "new_types.babel")
+	props := declarations(g.file(t, "new_typesModel.cs"), csharpProperty)
+	for _, decl := range []string{
+		"public string ID { get; set; }",
+		"public string Day { get; set; }",
+		"public string Start { get; set; }",
+		"public string Length { get; set; }",
+		"public List<string> Holidays { get; set; }",
+		"public Dictionary<string,string> Breaks { get; set; }",
+	} {
+		if !props[decl] {
+			t.Errorf("Appointment does not declare %q: %v", decl, props)
+		}
+	}
+	methods := declarations(g.file(t, "new_typesInterface.cs"), regexp.MustCompile(`^\s*List<Appointment> Find\(|^\s*string Total\(`))
+	for _, decl := range []string{
+		"List<Appointment> Find(string first, string last, string owner);",
+		"string Total(string owner);",
+	} {
+		if !methods[decl] {
+			t.Errorf("ICalendar does not declare %q: %v", decl, methods)
+		}
+	}
+}
+
+func TestCSharpDecimals(t *testing.T) {
+	// decimals in lists and maps are rounded and checked too
+	g := generate(t, "csharp", Arguments{GenModel: true}, "decimal_precision.babel")
+	lines := declarations(g.file(t, "decimal_precisionModel.cs"), regexp.MustCompile(`\bforeach\(|\.ConvertAll\(`))
+	for _, line := range []string{
+		"if(Rates != null) foreach(var v0 in Rates)",
+		"if(Fees != null) foreach(var v0 in Fees.Values)",
+		"if(v0 != null) foreach(var v1 in v0)",
+		"Rates = Rates == null ? null : Rates.ConvertAll(v0 => v0.HasValue ? (decimal?)Math.Round(v0.Value, 4, MidpointRounding.AwayFromZero) : null);",
+	} {
+		if !lines[line] {
+			t.Errorf("Quote does not have %q: %v", line, lines)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package generator
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	babel "github.com/babelrpc/babel/parser"
+)
+
+// generated holds the output of a generator.
+type generated struct {
+	Dir   string            // the output folder
+	Files []string          // the generated files, relative to Dir and in the order they were written
+	Code  map[string]string // the content of each file, with LF line endings
+}
+
+// file returns the content of the generated file with the given base name.
+func (g *generated) file(t *testing.T, name string) string {
+	t.Helper()
+	for _, fn := range g.Files {
+		if path.Base(fn) == name {
+			return g.Code[fn]
+		}
+	}
+	t.Fatalf("%s was not generated: %v", name, g.Files)
+	return ""
+}
+
+// generate parses the given files from the parser's test folder and generates
+// code for them with one generator. The templates and a new output folder are
+// used unless args gives others.
+func generate(t *testing.T, lang string, args Arguments, files ...string) *generated {
+	t.Helper()
+	if args.TemplateDir == "" {
+		args.TemplateDir = filepath.Join("..", "babeltemplates")
+	}
+	if args.OutputDir == "" {
+		args.OutputDir = t.TempDir()
+	}
+	gen, err := New(lang, &args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &generated{Dir: args.OutputDir, Code: make(map[string]string)}
+	for _, file := range files {
+		pidl, err := babel.ParseIdl(filepath.Join("..", "parser", "test", file), lang)
+		if err != nil {
+			t.Fatalf("The parser failed file %q which should have succeeded: %s", file, err)
+		}
+		fnames, err := gen.GenerateCode(pidl)
+		if err != nil {
+			t.Fatalf("Generating %s for %q with %v failed: %s", lang, file, args.Options, err)
+		}
+		for _, fn := range fnames {
+			data, err := ioutil.ReadFile(fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rel, err := filepath.Rel(args.OutputDir, fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rel = filepath.ToSlash(rel)
+			g.Files = append(g.Files, rel)
+			g.Code[rel] = strings.Replace(string(data), "\r\n", "\n", -1)
+		}
+	}
+	return g
+}
+
+// checkedPackage is a type-checked Go package.
+type checkedPackage struct {
+	*types.Package
+	info  *types.Info
+	files []*ast.File
+}
+
+// sourceImporter type-checks the other packages that generated code imports
+// from their source. It is shared because the standard library takes a while.
+var sourceImporter = importer.ForCompiler(token.NewFileSet(), "source", nil).(types.ImporterFrom)
+
+// goChecker type-checks generated packages, which import each other by their
+// folder under a module path, and stub packages given as source.
+type goChecker struct {
+	fset   *token.FileSet
+	srcs   map[string][]string // the source files of each package by import path
+	pkgs   map[string]*checkedPackage
+	srcDir string
+}
+
+// checkGo type-checks the Go code of g and returns its packages by import
+// path, which is the folder of a package under module. stubs gives the source
+// of other packages it imports, such as a wrapper for optional values. Imports
+// of the babel packages are found from the module of the tests.
+func checkGo(t *testing.T, g *generated, module string, stubs map[string]string) map[string]*checkedPackage {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &goChecker{fset: token.NewFileSet(), srcs: make(map[string][]string), pkgs: make(map[string]*checkedPackage), srcDir: wd}
+	for p, src := range stubs {
+		c.srcs[p] = []string{src}
+	}
+	generated := make([]string, 0)
+	for _, fn := range g.Files {
+		if strings.HasSuffix(fn, ".go") {
+			p := path.Join(module, path.Dir(fn))
+			if _, ok := c.srcs[p]; !ok {
+				generated = append(generated, p)
+			}
+			c.srcs[p] = append(c.srcs[p], g.Code[fn])
+		}
+	}
+	result := make(map[string]*checkedPackage)
+	for _, p := range generated {
+		pkg, err := c.check(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result[p] = pkg
+	}
+	return result
+}
+
+// check type-checks a package once.
+func (c *goChecker) check(p string) (*checkedPackage, error) {
+	if pkg, ok := c.pkgs[p]; ok {
+		return pkg, nil
+	}
+	pkg := &checkedPackage{info: &types.Info{Defs: make(map[*ast.Ident]types.Object), Uses: make(map[*ast.Ident]types.Object)}}
+	for i, src := range c.srcs[p] {
+		f, err := parser.ParseFile(c.fset, path.Join(p, string(rune('a'+i))+".go"), src, 0)
+		if err != nil {
+			return nil, err
+		}
+		pkg.files = append(pkg.files, f)
+	}
+	conf := types.Config{Importer: c}
+	var err error
+	if pkg.Package, err = conf.Check(p, c.fset, pkg.files, pkg.info); err != nil {
+		return nil, err
+	}
+	c.pkgs[p] = pkg
+	return pkg, nil
+}
+
+// Import implements types.Importer.
+func (c *goChecker) Import(p string) (*types.Package, error) {
+	return c.ImportFrom(p, c.srcDir, 0)
+}
+
+// ImportFrom implements types.ImporterFrom.
+func (c *goChecker) ImportFrom(p, dir string, mode types.ImportMode) (*types.Package, error) {
+	if _, ok := c.srcs[p]; ok {
+		pkg, err := c.check(p)
+		if err != nil {
+			return nil, err
+		}
+		return pkg.Package, nil
+	}
+	return sourceImporter.ImportFrom(p, c.srcDir, mode)
+}
+
+// field returns the type and struct tag of a field of a struct type.
+func (pkg *checkedPackage) field(t *testing.T, typeName, fieldName string) (string, string) {
+	t.Helper()
+	obj := pkg.Scope().Lookup(typeName)
+	if obj == nil {
+		t.Fatalf("%s does not declare %s", pkg.Path(), typeName)
+	}
+	s, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		t.Fatalf("%s is not a struct", typeName)
+	}
+	for i := 0; i < s.NumFields(); i++ {
+		if s.Field(i).Name() == fieldName {
+			return types.TypeString(s.Field(i).Type(), nil), s.Tag(i)
+		}
+	}
+	t.Fatalf("%s does not have a field %s", typeName, fieldName)
+	return "", ""
+}
+
+// method returns the signature of a method or function, written without the
+// package paths of its types, or "" if it isn't declared. Methods are named
+// Type.Method.
+func (pkg *checkedPackage) method(name string) string {
+	var obj types.Object
+	if arr := strings.SplitN(name, ".", 2); len(arr) == 2 {
+		tn := pkg.Scope().Lookup(arr[0])
+		if tn == nil {
+			return ""
+		}
+		obj, _, _ = types.LookupFieldOrMethod(tn.Type(), true, pkg.Package, arr[1])
+	} else {
+		obj = pkg.Scope().Lookup(name)
+	}
+	if obj == nil {
+		return ""
+	}
+	if _, ok := obj.(*types.Func); !ok {
+		return ""
+	}
+	return types.TypeString(obj.Type(), func(*types.Package) string { return "" })
+}
+
+// calls returns true if the body of a function or method, named Type.Method,
+// calls the given function, which is named with its package path, for example
+// "github.com/babelrpc/babel/types.RoundDecimal", or as Type.Method for
+// methods.
+func (pkg *checkedPackage) calls(name, callee string) bool {
+	recv, fn := "", name
+	if arr := strings.SplitN(name, ".", 2); len(arr) == 2 {
+		recv, fn = arr[0], arr[1]
+	}
+	found := false
+	for _, f := range pkg.files {
+		for _, decl := range f.Decls {
+			d, ok := decl.(*ast.FuncDecl)
+			if !ok || d.Name.Name != fn || d.Body == nil || receiverName(d) != recv {
+				continue
+			}
+			ast.Inspect(d.Body, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok && calleeName(pkg.info, call) == callee {
+					found = true
+				}
+				return !found
+			})
+		}
+	}
+	return found
+}
+
+// receiverName returns the name of the receiver type of a method, or "" for a function.
+func receiverName(d *ast.FuncDecl) string {
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		return ""
+	}
+	t := d.Recv.List[0].Type
+	if s, ok := t.(*ast.StarExpr); ok {
+		t = s.X
+	}
+	if id, ok := t.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+// calleeName returns the name of the function or method that a call calls, in
+// the form used by calls.
+func calleeName(info *types.Info, call *ast.CallExpr) string {
+	var id *ast.Ident
+	switch fn := call.Fun.(type) {
+	case *ast.Ident:
+		id = fn
+	case *ast.SelectorExpr:
+		id = fn.Sel
+	default:
+		return ""
+	}
+	f, ok := info.Uses[id].(*types.Func)
+	if !ok {
+		return ""
+	}
+	if sig, ok := f.Type().(*types.Signature); ok && sig.Recv() != nil {
+		t := sig.Recv().Type()
+		if p, ok := t.(*types.Pointer); ok {
+			t = p.Elem()
+		}
+		if n, ok := t.(*types.Named); ok {
+			return n.Obj().Name() + "." + f.Name()
+		}
+		return f.Name()
+	}
+	if f.Pkg() == nil {
+		return f.Name()
+	}
+	return f.Pkg().Path() + "." + f.Name()
+}
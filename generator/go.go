@@ -2,9 +2,10 @@ package generator
 
 import (
 	"fmt"
+	"go/format"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -24,6 +25,10 @@ var (
 		"float64":  "float64",
 		"string":   "string",
 		"datetime": "time.Time",
+		"date":     "types.Date",
+		"time":     "types.Time",
+		"duration": "types.Duration",
+		"uuid":     "types.UUID",
 		"decimal":  "big.Rat",
 		"char":     "rune",
 		"binary":   "[]byte",
@@ -35,32 +40,22 @@ var (
 // goGenerator is the code generator for C#.
 type goGenerator struct {
 	templateManager
-	args *Arguments
+	args       *Arguments
+	module     *goModule             // module the code is written into, if any
+	goMod      bool                  // write a go.mod file for the module
+	context    bool                  // pass a context.Context to service methods
+	shape      goShape               // Go types used for fields, parameters and return values
+	wroteGoMod bool                  // the go.mod file has been handled
+	packages   map[string]*goPackage // packages by Go namespace
+	imports    []goImport            // packages imported by the current file
+	qualifiers map[string]string     // package names used by the current file, by Go namespace
+
+	optionalQualifier string // package prefix of the optional wrapper in the current file
 }
 
-// formatType returns the Type as a string in format suitable for C#.
+// formatType returns the Go type of a field, parameter or return value.
 func (gen *goGenerator) formatType(t *idl.Type) string {
-	var s string
-	ms, ok := goTypes[t.Name]
-	if !ok {
-		ms = t.Name
-	}
-	if t.IsList() {
-		s = fmt.Sprintf(ms, gen.formatType(t.ValueType))
-	} else if t.IsMap() {
-		s = fmt.Sprintf(ms, goTypes[t.KeyType.Name], gen.formatType(t.ValueType))
-	} else if t.IsBinary() {
-		s = ms
-	} else if t.IsPrimitive() {
-		s = "*" + ms
-	} else if t.IsEnum(gen.tplRootIdl) {
-		s = "*" + ms
-	} else if t.IsVoid() {
-		s = ""
-	} else {
-		s = "*" + ms
-	}
-	return s
+	return gen.goType(t, false)
 }
 
 // fullTypeName returns the fully qualified type name using namespace syntax for go.
@@ -74,7 +69,7 @@ func (gen *goGenerator) fullTypeName(t *idl.Type) string {
 	if t.Name == "list" {
 		s = fmt.Sprintf(ms, gen.fullTypeName(t.ValueType))
 	} else if t.Name == "map" {
-		s = fmt.Sprintf(ms, goTypes[t.KeyType.Name], gen.fullTypeName(t.ValueType))
+		s = fmt.Sprintf(ms, gen.formatKey(goTypes, t.KeyType), gen.fullTypeName(t.ValueType))
 	} else if t.IsPrimitive() {
 		s = "*" + ms
 	} else {
@@ -88,14 +83,10 @@ func (gen *goGenerator) fullTypeName(t *idl.Type) string {
 	return s
 }
 
-// fullNameOf returns the fully qualified name of the object with its
-// namespace formatted for go.
+// fullNameOf returns the name of the object qualified by its package when it
+// is defined in another Go package.
 func (gen *goGenerator) fullNameOf(name string) string {
-	ns := gen.tplRootIdl.NamespaceOf(name, "go")
-	if ns != "" {
-		return fmt.Sprintf("%s.%s", ns, name)
-	}
-	return name
+	return gen.qualify(name)
 }
 
 // formatLiteral formats a literal value for the Go parser.
@@ -103,8 +94,7 @@ func (gen *goGenerator) formatLiteral(value interface{}, typeName string) string
 	if typeName == "#ref" {
 		s, ok := value.(string)
 		if ok {
-			s = strings.Replace(s, ".", "", -1)
-			return s
+			return gen.qualifierOf(strings.Split(s, ".")[0]) + strings.Replace(s, ".", "", -1)
 		} else {
 			return fmt.Sprintf("%s", value)
 		}
@@ -125,51 +115,96 @@ func (gen *goGenerator) init(args *Arguments) error {
 		return fmt.Errorf("nothing to do")
 	} else if args.ServerType != "" {
 		return fmt.Errorf("-servertype does not apply to Go")
-	} else if len(args.Options) > 0 {
-		for k := range args.Options {
+	}
+	gen.shape = newGoShape()
+	if len(args.Options) > 0 {
+		for k, v := range args.Options {
 			switch k {
+			case "module":
+				if v == "auto" {
+					m, err := findGoModule(args.OutputDir)
+					if err != nil {
+						return err
+					}
+					gen.module = m
+				} else if isModulePath(v) {
+					dir, err := filepath.Abs(args.OutputDir)
+					if err != nil {
+						return err
+					}
+					gen.module = &goModule{Path: v, Dir: dir}
+				} else {
+					return fmt.Errorf("invalid module option: %s.  Valid options are 'auto' or a module path", v)
+				}
+			case "gomod":
+				b, err := strconv.ParseBool(v)
+				if err != nil {
+					return fmt.Errorf("invalid gomod option: %s", v)
+				}
+				gen.goMod = b
+			case "ctx":
+				b, err := strconv.ParseBool(v)
+				if err != nil {
+					return fmt.Errorf("invalid ctx option: %s", v)
+				}
+				gen.context = b
+			case "builders":
+				if err := gen.setBuilders(v); err != nil {
+					return err
+				}
 			default:
-				return fmt.Errorf("the %s option is not applicable to language go", k)
+				if ok, err := gen.shape.set(k, v); err != nil {
+					return err
+				} else if !ok {
+					return fmt.Errorf("the %s option is not applicable to language go", k)
+				}
 			}
 		}
+		if err := gen.shape.check(); err != nil {
+			return err
+		}
+		if gen.shape.omitsZero() && gen.module != nil && goVersionBefore(gen.module.Go, omitZeroGo) {
+			return fmt.Errorf("the optional option tags fields with omitzero, which needs go %s or later, but %s declares go %s.  Use omitempty=none or a newer go version", omitZeroGo, filepath.Join(gen.module.Dir, "go.mod"), gen.module.Go)
+		}
+		if gen.goMod && (gen.module == nil || args.Options["module"] == "auto") {
+			return fmt.Errorf("the gomod option needs a module path, for example module=company.com/client")
+		}
 	}
 	gen.args = args
+	gen.packages = make(map[string]*goPackage)
+	gen.postProcess = format.Source
 	return gen.loadTempates(args.TemplateDir, "go", template.FuncMap{
 		"formatType":  func(t *idl.Type) string { return gen.formatType(t) },
 		"fullNameOf":  func(name string) string { return gen.fullNameOf(name) },
 		"formatValue": func(p *idl.Pair) string { return gen.formatLiteral(p.Value, p.DataType) },
 		"isVoid":      func(t *idl.Type) bool { return gen.isVoid(t) },
 		"imports": func() []string {
-			pkg := gen.tplRootIdl.Namespaces["go"]
-			imports := make([]string, 0)
-			for _, i := range gen.tplRootIdl.UniqueNamespaces("go") {
-				if i != pkg {
-					imports = append(imports, i)
-				}
+			imports := make([]string, len(gen.imports))
+			for i, imp := range gen.imports {
+				imports[i] = imp.String()
 			}
 			return imports
 		},
+		"useContext":   func() bool { return gen.context },
+		"unknownEnums": func() string { return gen.shape.unknownEnums },
+		"unusedName":   func(m *idl.Method, name string) string { return gen.unusedName(m, name) },
 		"isNotPascalCase": func(name string) bool {
 			if len(name) > 1 {
 				return strings.ToUpper(name[0:1]) != name[0:1]
 			}
 			return false
 		},
-		"serializerOptions": func(t *idl.Type) string {
-			if t.IsList() || t.IsMap() || t.IsBinary() {
-				return ",omitempty"
-			} else if t.IsEnum(gen.tplRootIdl) {
-				return ",omitempty"
-			} else if t.Name == "int64" || t.Name == "decimal" {
-				return ",string,omitempty"
-			} else {
-				return ",omitempty"
-			}
-		},
+		"serializerOptions": func(t *idl.Type) string { return gen.serializerOptions(t) },
+		"setDefault":        func(f *idl.Field) string { return gen.setDefault(f) },
+		"roundDecimal":      func(f *idl.Field) string { return gen.roundDecimal(f) },
+		"checkDecimal":      func(f *idl.Field) string { return gen.checkDecimal(f) },
+		"cloneField":        func(f *idl.Field) string { return gen.cloneField(f) },
+		"equalField":        func(f *idl.Field) string { return gen.equalField(f) },
+		"builderType":       func(t *idl.Type) string { return gen.builderType(t) },
+		"builderSet":        func(f *idl.Field) string { return gen.builderSet(f) },
 		"package": func() string {
-			ns := gen.tplRootIdl.Namespaces["go"]
-			arr := strings.Split(ns, "/")
-			return arr[len(arr)-1]
+			p, _ := gen.packageOf(gen.tplRootIdl.Namespaces["go"])
+			return p.Name
 		},
 		"notptr": func(s string) string {
 			if s[0] == '*' {
@@ -178,47 +213,95 @@ func (gen *goGenerator) init(args *Arguments) error {
 				return s
 			}
 		},
-		"modelUsesType": func(s string) bool {
-			l, _ := gen.tplRootIdl.UniqueTypes()
-			for _, i := range l {
-				if i == s {
-					return true
+		"modelUsesType": func(names ...string) bool {
+			for _, s := range gen.tplRootIdl.Structs {
+				for _, f := range s.Fields {
+					if typeUses(f.Type, names) {
+						return true
+					}
 				}
 			}
 			return false
 		},
-		"serviceUsesType": func(s string) bool {
-			_, l := gen.tplRootIdl.UniqueTypes()
-			for _, i := range l {
-				if i == s {
+		"modelHasDecimals": func() bool {
+			for _, s := range gen.tplRootIdl.Structs {
+				if len(s.DecimalFields()) > 0 {
 					return true
 				}
 			}
 			return false
 		},
+		"serviceUsesType": func(names ...string) bool {
+			for _, s := range gen.tplRootIdl.Services {
+				for _, m := range s.Methods {
+					if typeUses(m.Returns, names) {
+						return true
+					}
+					for _, p := range m.Parameters {
+						if typeUses(p.Type, names) {
+							return true
+						}
+					}
+				}
+			}
+			return false
+		},
 	})
 }
 
+// unusedName returns name, followed by underscores if needed so that it differs
+// from the names of the method's parameters. Generated methods use it to name
+// their receivers and local variables.
+func (gen *goGenerator) unusedName(m *idl.Method, name string) string {
+	for i := 0; i < len(m.Parameters); i++ {
+		if escapeIdent("go", m.Parameters[i].Name) == name {
+			name += "_"
+			i = -1
+		}
+	}
+	return name
+}
+
+// typeUses returns true if the type, or the key or value type of a list or
+// map, is one of the given type names.
+func typeUses(t *idl.Type, names []string) bool {
+	if t == nil {
+		return false
+	}
+	for _, n := range names {
+		if t.Name == n {
+			return true
+		}
+	}
+	return typeUses(t.KeyType, names) || typeUses(t.ValueType, names)
+}
+
 // replaceSuffix generates an output filename from the babel file path after ensuring the path exists.
 func (gen *goGenerator) replaceSuffix(pidl *idl.Idl, repl string) (string, error) {
 	s := strings.TrimSuffix(filepath.Base(pidl.Filename), ".babel")
 	if !strings.HasSuffix(strings.ToLower(s), strings.ToLower(repl)) {
 		s += repl
 	}
-	d := filepath.Join(gen.args.OutputDir, filepath.FromSlash(pidl.Namespaces["go"]))
+	p, err := gen.packageOf(pidl.Namespaces["go"])
+	if err != nil {
+		return "", err
+	}
 	// check error
-	err := os.MkdirAll(d, os.ModePerm)
+	err = os.MkdirAll(p.Dir, os.ModePerm)
 	if err != nil {
 		return "", err
 	} else {
-		return filepath.Join(d, s+".go"), nil
+		return filepath.Join(p.Dir, s+".go"), nil
 	}
 }
 
 // GenerateCode generates the source code for the given IDL. It returns an array
 // of the generated file names and an error indicator.
 func (gen *goGenerator) GenerateCode(pidl *idl.Idl) ([]string, error) {
-	outFnames := make([]string, 0)
+	outFnames, err := gen.writeGoMod()
+	if err != nil {
+		return nil, err
+	}
 
 	if len(pidl.Consts) > 0 || len(pidl.Enums) > 0 || len(pidl.Structs) > 0 {
 		if gen.args.GenModel {
@@ -248,19 +331,17 @@ func (gen *goGenerator) GenerateCode(pidl *idl.Idl) ([]string, error) {
 			outFnames = append(outFnames, serviceFnames...)
 		}
 
-		/*
-			if gen.args.GenClient {
-				fn, err := gen.replaceSuffix(pidl, "Client")
-				if err != nil {
-					return nil, err
-				}
-				proxyFnames, err := gen.GenCodeInternal(pidl, fn, "client.go")
-				if err != nil {
-					return nil, err
-				}
-				outFnames = append(outFnames, proxyFnames...)
+		if gen.args.GenClient {
+			fn, err := gen.replaceSuffix(pidl, "Client")
+			if err != nil {
+				return nil, err
+			}
+			proxyFnames, err := gen.GenCodeInternal(pidl, fn, "client.go")
+			if err != nil {
+				return nil, err
 			}
-		*/
+			outFnames = append(outFnames, proxyFnames...)
+		}
 
 		if gen.args.GenServer {
 			fn, err := gen.replaceSuffix(pidl, "Invoker")
@@ -279,27 +360,18 @@ func (gen *goGenerator) GenerateCode(pidl *idl.Idl) ([]string, error) {
 
 func (gen *goGenerator) GenCodeInternal(pidl *idl.Idl, outFname string, template string) ([]string, error) {
 	gen.resetTemplate(pidl)
+	err := gen.resolveImports(template)
+	if err != nil {
+		return nil, err
+	}
 
 	if len(pidl.Consts) > 0 || len(pidl.Enums) > 0 || len(pidl.Structs) > 0 || len(pidl.Services) > 0 {
-		outFile, err := os.Create(outFname)
+		err = gen.writeTemplate(outFname, template, pidl)
 		if err != nil {
-			return nil, fmt.Errorf("can't create output file: %w", err)
+			return nil, err
 		}
-		defer outFile.Close()
-		err = gen.templates.ExecuteTemplate(outFile, template, pidl)
-		if err != nil {
-			return nil, fmt.Errorf("error executing template: %w", err)
-		}
-		//err = gen.RunGoFmt(outFname)
-		//if err != nil {
-		//	return nil, fmt.Errorf("Error running go fmt: %s", err)
-		//}
 		return []string{outFname}, nil
 	} else {
 		return []string{}, nil
 	}
 }
-
-func (gen *goGenerator) RunGoFmt(outFname string) error {
-	return exec.Command("go", "fmt", outFname).Run()
-}
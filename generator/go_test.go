@@ -0,0 +1,338 @@
+package generator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/constant"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	babel "github.com/babelrpc/babel/parser"
+)
+
+// optStub is a wrapper for optional values with the API that the optional
+// option requires.
+const optStub = `package opt
+
+type Value[T comparable] struct {
+	V     T
+	Valid bool
+}
+
+func (o *Value[T]) Set(v T)     { o.V, o.Valid = v, true }
+func (o Value[T]) IsZero() bool { return !o.Valid }
+`
+
+const typesPkg = "github.com/babelrpc/babel/types"
+
+// onlyPackage returns the package of code that was generated for one namespace.
+func onlyPackage(t *testing.T, pkgs map[string]*checkedPackage) *checkedPackage {
+	t.Helper()
+	if len(pkgs) != 1 {
+		t.Fatalf("Expected one package, got %d", len(pkgs))
+	}
+	for _, pkg := range pkgs {
+		return pkg
+	}
+	return nil
+}
+
+// expectField checks the type and struct tag of a field.
+func expectField(t *testing.T, pkg *checkedPackage, typeName, fieldName, typ, tag string) {
+	t.Helper()
+	if ft, ftag := pkg.field(t, typeName, fieldName); ft != typ || ftag != tag {
+		t.Errorf("%s.%s is %s `%s` instead of %s `%s`", typeName, fieldName, ft, ftag, typ, tag)
+	}
+}
+
+// optString has the API of the optional wrapper, for marshalling the fields
+// that are declared with it.
+type optString struct {
+	V     string
+	Valid bool
+}
+
+func (o optString) IsZero() bool { return !o.Valid }
+
+// expectJSON marshals value as the only field of a struct, with the struct tag
+// of a generated field, and checks the result. The type of value must be the
+// type of the field, unless it is declared with the optional wrapper.
+func expectJSON(t *testing.T, pkg *checkedPackage, typeName, fieldName string, value interface{}, result string) {
+	t.Helper()
+	ft, tag := pkg.field(t, typeName, fieldName)
+	rt := reflect.TypeOf(value)
+	if ft != rt.String() && !strings.HasPrefix(ft, "example.com/opt.") {
+		t.Fatalf("%s.%s is %s instead of %s", typeName, fieldName, ft, rt)
+	}
+	v := reflect.New(reflect.StructOf([]reflect.StructField{{Name: fieldName, Type: rt, Tag: reflect.StructTag(tag)}}))
+	v.Elem().Field(0).Set(reflect.ValueOf(value))
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != result {
+		t.Errorf("%s.%s marshals as %s instead of %s", typeName, fieldName, b, result)
+	}
+}
+
+// expectCalls checks whether a function calls another.
+func expectCalls(t *testing.T, pkg *checkedPackage, name, callee string, calls bool) {
+	t.Helper()
+	if pkg.calls(name, callee) != calls {
+		t.Errorf("%s calls %s: %v, expected %v", name, callee, !calls, calls)
+	}
+}
+
+// expectMethod checks the signature of a function or method.
+func expectMethod(t *testing.T, pkg *checkedPackage, name, sig string) {
+	t.Helper()
+	if s := pkg.method(name); s != sig {
+		t.Errorf("%s is %q instead of %q", name, s, sig)
+	}
+}
+
+func TestGoOptions(t *testing.T) {
+	tests := []struct {
+		file    string
+		options map[string]string
+		check   func(t *testing.T, pkg *checkedPackage)
+	}{
+		{"full_file.babel", map[string]string{}, func(t *testing.T, pkg *checkedPackage) {
+			expectField(t, pkg, "SearchCriteria", "UID", "*int64", `json:"UID,string,omitempty"`)
+			expectField(t, pkg, "User", "LoginID", "*string", `json:"LoginID,omitempty"`)
+		}},
+		{"full_file.babel", map[string]string{"primitives": "value", "omitempty": "nil"}, func(t *testing.T, pkg *checkedPackage) {
+			expectField(t, pkg, "SearchCriteria", "UID", "int64", `json:"UID,string"`)
+			expectField(t, pkg, "User", "LoginID", "string", `json:"LoginID"`)
+		}},
+		{"full_file.babel", map[string]string{"optional": "example.com/opt.Value"}, func(t *testing.T, pkg *checkedPackage) {
+			uid := int64(9007199254740993)
+			expectJSON(t, pkg, "SearchCriteria", "UID", &uid, `{"UID":"9007199254740993"}`)
+			expectField(t, pkg, "User", "LoginID", "example.com/opt.Value[string]", `json:"LoginID,omitzero"`)
+			expectJSON(t, pkg, "User", "LoginID", optString{}, `{}`)
+			expectJSON(t, pkg, "User", "LoginID", optString{V: "", Valid: true}, `{"LoginID":{"V":"","Valid":true}}`)
+			expectCalls(t, pkg, "User.Init", "Value.Set", true)
+		}},
+		{"decimal_precision.babel", map[string]string{"decimal": "float", "omitempty": "none"}, func(t *testing.T, pkg *checkedPackage) {
+			expectField(t, pkg, "Money", "Amount", "*math/big.Float", `json:"Amount,string"`)
+			expectCalls(t, pkg, "Money.RoundDecimals", typesPkg+".RoundFloat", true)
+			expectCalls(t, pkg, "Money.CheckDecimals", typesPkg+".CheckFloat", true)
+			expectCalls(t, pkg, "Payment.RoundDecimals", "Money.RoundDecimals", true)
+			expectCalls(t, pkg, "Quote.RoundDecimals", typesPkg+".RoundFloat", true)
+			expectCalls(t, pkg, "Quote.CheckDecimals", typesPkg+".CheckFloat", true)
+		}},
+		{"decimal_precision.babel", map[string]string{"decimal": "string"}, func(t *testing.T, pkg *checkedPackage) {
+			expectField(t, pkg, "Quote", "Fees", "map[string][]*string", `json:"Fees,omitempty"`)
+			expectCalls(t, pkg, "Quote.RoundDecimals", typesPkg+".RoundDecimalString", true)
+			expectCalls(t, pkg, "Quote.CheckDecimals", typesPkg+".CheckDecimal", true)
+		}},
+		{"decimal_precision.babel", map[string]string{"decimal": "string", "primitives": "value"}, func(t *testing.T, pkg *checkedPackage) {
+			expectField(t, pkg, "Money", "Amount", "string", `json:"Amount,omitempty"`)
+			expectField(t, pkg, "Money", "Rates", "[]string", `json:"Rates,omitempty"`)
+			expectCalls(t, pkg, "Money.RoundDecimals", typesPkg+".RoundDecimalString", true)
+			expectCalls(t, pkg, "Quote.CheckDecimals", typesPkg+".CheckDecimal", true)
+		}},
+		{"gomod/common.babel", map[string]string{"datetime": "string"}, func(t *testing.T, pkg *checkedPackage) {
+			expectField(t, pkg, "Audit", "CreatedAt", "*string", `json:"CreatedAt,omitempty"`)
+			expectField(t, pkg, "Audit", "Day", "*"+typesPkg+".Date", `json:"Day,omitempty"`)
+		}},
+		{"enum_map_keys.babel", map[string]string{}, func(t *testing.T, pkg *checkedPackage) {
+			if c, ok := pkg.Scope().Lookup("CurrencyUSD").(interface{ Val() constant.Value }); !ok || constant.StringVal(c.Val()) != "USD" {
+				t.Errorf("CurrencyUSD is not the name of the value")
+			}
+			expectMethod(t, pkg, "ParseCurrency", "func(s string) (Currency, error)")
+			expectMethod(t, pkg, "CurrencyValues", "func() []Currency")
+			expectMethod(t, pkg, "Currency.IsValid", "func() bool")
+			expectMethod(t, pkg, "Currency.MarshalText", "func() ([]byte, error)")
+			expectMethod(t, pkg, "Currency.UnmarshalText", "func(b []byte) error")
+			expectCalls(t, pkg, "Currency.UnmarshalText", "Currency.IsValid", false)
+		}},
+		{"enum_map_keys.babel", map[string]string{"unknownenums": "error"}, func(t *testing.T, pkg *checkedPackage) {
+			expectCalls(t, pkg, "Currency.UnmarshalText", "Currency.IsValid", true)
+			expectCalls(t, pkg, "Currency.UnmarshalText", "fmt.Errorf", true)
+		}},
+		{"enum_map_keys.babel", map[string]string{"unknownenums": "empty"}, func(t *testing.T, pkg *checkedPackage) {
+			expectCalls(t, pkg, "Currency.UnmarshalText", "Currency.IsValid", true)
+			expectCalls(t, pkg, "Currency.UnmarshalText", "fmt.Errorf", false)
+		}},
+	}
+	for _, tc := range tests {
+		g := generate(t, "go", Arguments{GenModel: true, Options: tc.options}, tc.file)
+		pkgs := checkGo(t, g, "example.com/gen", map[string]string{"example.com/opt": optStub})
+		tc.check(t, onlyPackage(t, pkgs))
+	}
+
+	bad := []map[string]string{
+		{"primitives": "maybe"},
+		{"decimal": "int"},
+		{"optional": "Value"},
+		{"optional": "example.com/opt.value"},
+		{"optional": "example.com/opt.Value", "primitives": "value"},
+	}
+	for _, options := range bad {
+		if _, err := New("go", &Arguments{OutputDir: t.TempDir(), GenModel: true, Options: options}); err == nil {
+			t.Errorf("The options %v should have failed", options)
+		}
+	}
+}
+
+func TestGoFormat(t *testing.T) {
+	// the generated code is formatted and compiles
+	g := generate(t, "go", Arguments{GenModel: true, GenClient: true, GenServer: true}, "full_file.babel")
+	for _, fn := range g.Files {
+		if formatted, err := format.Source([]byte(g.Code[fn])); err != nil || string(formatted) != g.Code[fn] {
+			t.Errorf("%s is not formatted", fn)
+		}
+	}
+	checkGo(t, g, "example.com/gen", nil)
+
+	// a copy of the Go templates with a syntax error in the model
+	tpl := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tpl, "go"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join("..", "babeltemplates", "go", "*.go"))
+	for _, fn := range files {
+		data, err := ioutil.ReadFile(fn)
+		if err == nil && filepath.Base(fn) == "model.go" {
+			data = []byte(strings.Replace(string(data), "type {{.Name}} struct", "type {{.Name}} struct struct", 1))
+		}
+		if err == nil {
+			err = ioutil.WriteFile(filepath.Join(tpl, "go", filepath.Base(fn)), data, 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := t.TempDir()
+	gen, err := New("go", &Arguments{TemplateDir: tpl, OutputDir: out, GenModel: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pidl, err := babel.ParseIdl(filepath.Join("..", "parser", "test", "full_file.babel"), "go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = gen.GenerateCode(pidl)
+	var ce *CodeError
+	if !errors.As(err, &ce) {
+		t.Fatalf("Expected a CodeError, got %v", err)
+	}
+	if ce.Template != "model.go" || ce.Source != pidl.Filename || ce.Line == 0 || !strings.HasPrefix(ce.Text, "type SearchCriteria struct struct") {
+		t.Errorf("Wrong CodeError: %+v", ce)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(out, "*", "*", "*Model.go")); len(matches) > 0 {
+		t.Errorf("The invalid model was written to %v", matches)
+	}
+}
+
+func TestGoModules(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	args := Arguments{GenModel: true, GenClient: true, GenServer: true}
+
+	// common is inside the module, users is written under the output folder
+	args.OutputDir = filepath.Join(dir, "gen")
+	args.Options = map[string]string{"module": "auto"}
+	g := generate(t, "go", args, "gomod/common.babel", "gomod/users.babel")
+	expected := []string{
+		"../api/common/commonModel.go",
+		"company.com/users/common/usersModel.go",
+		"company.com/users/common/usersInterface.go",
+		"company.com/users/common/usersClient.go",
+		"company.com/users/common/usersInvoker.go",
+	}
+	if fmt.Sprint(g.Files) != fmt.Sprint(expected) {
+		t.Errorf("Generated %v instead of %v", g.Files, expected)
+	}
+	// the packages are type-checked by their folder in the module
+	inModule := &generated{Dir: dir, Code: make(map[string]string)}
+	for _, fn := range g.Files {
+		rel := path.Join("gen", fn)
+		inModule.Files = append(inModule.Files, rel)
+		inModule.Code[rel] = g.Code[fn]
+	}
+	pkgs := checkGo(t, inModule, "example.com/app", nil)
+	if pkg := pkgs["example.com/app/gen/company.com/users/common"]; pkg == nil || pkg.Name() != "common" {
+		t.Errorf("The users package was not generated: %v", pkgs)
+	} else if _, ok := pkgs["example.com/app/api/common"]; !ok {
+		t.Errorf("The common package was not generated in the module: %v", pkgs)
+	} else {
+		expectField(t, pkg, "User", "Audit", "example.com/app/api/common.Audit", "")
+	}
+
+	// a standalone client module gets its own go.mod
+	args.OutputDir = ""
+	args.Options = map[string]string{"module": "example.com/client", "gomod": "true"}
+	g = generate(t, "go", args, "gomod/common.babel", "gomod/users.babel")
+	if len(g.Files) != 6 || g.Files[0] != "go.mod" || !strings.Contains(g.Code["go.mod"], "\nmodule example.com/client\n") {
+		t.Errorf("Generated %v instead of go.mod and the code", g.Files)
+	}
+	pkgs = checkGo(t, g, "example.com/client", nil)
+	if pkg := pkgs["example.com/client/company.com/users/common"]; pkg == nil {
+		t.Errorf("The users package was not generated: %v", pkgs)
+	} else {
+		expectField(t, pkg, "User", "Audit", "example.com/client/example.com/app/api/common.Audit", "")
+	}
+
+	if _, err := New("go", &Arguments{OutputDir: dir, GenModel: true, Options: map[string]string{"module": "auto", "gomod": "true"}}); err == nil {
+		t.Errorf("gomod without a module path should have failed")
+	}
+
+	// omitzero needs go 1.24, which a go.mod written for the optional option declares
+	args.Options = map[string]string{"module": "example.com/client", "gomod": "true", "optional": "example.com/opt.Value"}
+	g = generate(t, "go", args, "gomod/common.babel")
+	if !strings.Contains(g.Code["go.mod"], "\ngo 1.24\n") {
+		t.Errorf("The go.mod for the optional option does not declare go 1.24:\n%s", g.Code["go.mod"])
+	}
+	old := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(old, "go.mod"), []byte("module example.com/old\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	options := map[string]string{"module": "auto", "optional": "example.com/opt.Value"}
+	if _, err := New("go", &Arguments{OutputDir: old, GenModel: true, Options: options}); err == nil {
+		t.Errorf("The optional option should have failed for a module with go 1.21")
+	}
+	options["omitempty"] = "none"
+	if _, err := New("go", &Arguments{OutputDir: old, TemplateDir: filepath.Join("..", "babeltemplates"), GenModel: true, Options: options}); err != nil {
+		t.Errorf("The optional option without omitempty failed for a module with go 1.21: %s", err)
+	}
+
+	// ctx adds a context to service methods
+	args.Options = map[string]string{"ctx": "true"}
+	g = generate(t, "go", args, "gomod/common.babel", "gomod/users.babel")
+	pkg := checkGo(t, g, "", nil)["company.com/users/common"]
+	if pkg == nil {
+		t.Fatalf("The users package was not generated: %v", g.Files)
+	}
+	expectMethod(t, pkg, "IUsers.Lookup", "func(ctx Context, name *string, state *Status) (*User, error)")
+	expectMethod(t, pkg, "UsersClient.Lookup", "func(ctx Context, name *string, state *Status) (*User, error)")
+	expectCalls(t, pkg, "UsersClient.Lookup", "Client.CallContext", true)
+	expectCalls(t, pkg, "Users.Lookup", "IUsers.Lookup", true)
+	if _, err := New("go", &Arguments{OutputDir: dir, GenModel: true, Options: map[string]string{"ctx": "maybe"}}); err == nil {
+		t.Errorf("An invalid ctx option should have failed")
+	}
+}
+
+func TestGoLocalNames(t *testing.T) {
+	// the locals of the client don't clash with parameters of the same name
+	for _, ctx := range []string{"false", "true"} {
+		g := generate(t, "go", Arguments{GenModel: true, GenClient: true, GenServer: true, Options: map[string]string{"ctx": ctx}}, "local_names.babel")
+		pkg := onlyPackage(t, checkGo(t, g, "example.com/gen", nil))
+		sig, call := "func(c *string, req *string, rsp *string, err *string) (*string, error)", "Client.Call"
+		if ctx == "true" {
+			sig, call = "func(ctx Context, c *string, req *string, rsp *string, err *string) (*string, error)", "Client.CallContext"
+		}
+		expectMethod(t, pkg, "LocalsClient.Get", sig)
+		expectCalls(t, pkg, "LocalsClient.Get", call, true)
+	}
+}
@@ -0,0 +1,139 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/babelrpc/babel/idl"
+)
+
+// deep returns true if copying a value of the type with an assignment would
+// share memory with the original, so Clone must copy it explicitly.
+func (gen *goGenerator) deep(t *idl.Type, elem bool) bool {
+	if t.IsList() || t.IsMap() || t.IsBinary() {
+		return true
+	}
+	if !elem && gen.wrapped(t) {
+		return false
+	}
+	return strings.HasPrefix(gen.goType(t, elem), "*")
+}
+
+// isStruct returns true if the type is a struct.
+func (gen *goGenerator) isStruct(t *idl.Type) bool {
+	return !t.IsList() && !t.IsMap() && !t.IsBinary() && !t.IsPrimitive() && !t.IsEnum(gen.tplRootIdl)
+}
+
+// cloneField returns the statements that make field f of c, a shallow copy of
+// obj, a deep copy.
+func (gen *goGenerator) cloneField(f *idl.Field) string {
+	if !gen.deep(f.Type, false) {
+		return ""
+	}
+	name := escapeIdent("go", gen.toPascalCase(f.Name))
+	return "\n\t" + gen.cloneValue("c."+name, "obj."+name, f.Type, false, 0)
+}
+
+// cloneValue returns the statements that assign a deep copy of src to dst.
+// depth numbers the variables of nested loops.
+func (gen *goGenerator) cloneValue(dst, src string, t *idl.Type, elem bool, depth int) string {
+	goType := gen.goType(t, elem)
+	switch {
+	case t.IsList() && !gen.deep(t.ValueType, true):
+		return fmt.Sprintf("if %s != nil {\n%s = make(%s, len(%s))\ncopy(%s, %s)\n}", src, dst, goType, src, dst, src)
+	case t.IsList():
+		i, v := fmt.Sprintf("idx%d", depth), fmt.Sprintf("val%d", depth)
+		return fmt.Sprintf("if %s != nil {\n%s = make(%s, len(%s))\nfor %s, %s := range %s {\n%s\n}\n}", src, dst, goType, src, i, v, src,
+			gen.cloneValue(dst+"["+i+"]", v, t.ValueType, true, depth+1))
+	case t.IsMap() && (!gen.deep(t.ValueType, true) || gen.isStruct(t.ValueType)):
+		// a nil value is copied as is, keeping its key
+		k, v := fmt.Sprintf("key%d", depth), fmt.Sprintf("val%d", depth)
+		return fmt.Sprintf("if %s != nil {\n%s = make(%s, len(%s))\nfor %s, %s := range %s {\n%s\n}\n}", src, dst, goType, src, k, v, src,
+			gen.cloneValue(dst+"["+k+"]", v, t.ValueType, true, depth+1))
+	case t.IsMap():
+		k, v, cp := fmt.Sprintf("key%d", depth), fmt.Sprintf("val%d", depth), fmt.Sprintf("cp%d", depth)
+		return fmt.Sprintf("if %s != nil {\n%s = make(%s, len(%s))\nfor %s, %s := range %s {\nvar %s %s\n%s\n%s[%s] = %s\n}\n}", src, dst, goType, src, k, v, src,
+			cp, gen.goType(t.ValueType, true), gen.cloneValue(cp, v, t.ValueType, true, depth+1), dst, k, cp)
+	case t.IsBinary():
+		return fmt.Sprintf("if %s != nil {\n%s = make([]byte, len(%s))\ncopy(%s, %s)\n}", src, dst, src, dst, src)
+	case gen.isStruct(t):
+		return fmt.Sprintf("%s = %s.Clone()", dst, src)
+	case goType == "*big.Rat":
+		return fmt.Sprintf("if %s != nil {\n%s = new(big.Rat).Set(%s)\n}", src, dst, src)
+	case goType == "*big.Float":
+		return fmt.Sprintf("if %s != nil {\n%s = new(big.Float).Copy(%s)\n}", src, dst, src)
+	case strings.HasPrefix(goType, "*"):
+		return fmt.Sprintf("if %s != nil {\n%s = new(%s)\n*%s = *%s\n}", src, dst, goType[1:], dst, src)
+	default:
+		return fmt.Sprintf("%s = %s", dst, src)
+	}
+}
+
+// equalField returns the statements that return false if field f of obj and
+// other differ.
+func (gen *goGenerator) equalField(f *idl.Field) string {
+	name := escapeIdent("go", gen.toPascalCase(f.Name))
+	return "\n\t" + gen.equalValue("obj."+name, "other."+name, f.Type, false, 0)
+}
+
+// equalValue returns the statements that return false if a and b differ. Nil
+// and empty lists, maps and binary values are equal. depth numbers the
+// variables of nested loops.
+func (gen *goGenerator) equalValue(a, b string, t *idl.Type, elem bool, depth int) string {
+	goType := gen.goType(t, elem)
+	unequal := func(cond string) string {
+		return fmt.Sprintf("if %s {\nreturn false\n}", cond)
+	}
+	switch {
+	case t.IsList():
+		i, v := fmt.Sprintf("idx%d", depth), fmt.Sprintf("val%d", depth)
+		return fmt.Sprintf("%s\nfor %s, %s := range %s {\n%s\n}", unequal(fmt.Sprintf("len(%s) != len(%s)", a, b)), i, v, a,
+			gen.equalValue(v, b+"["+i+"]", t.ValueType, true, depth+1))
+	case t.IsMap():
+		k, v, w, ok := fmt.Sprintf("key%d", depth), fmt.Sprintf("val%d", depth), fmt.Sprintf("other%d", depth), fmt.Sprintf("ok%d", depth)
+		return fmt.Sprintf("%s\nfor %s, %s := range %s {\n%s, %s := %s[%s]\n%s\n%s\n}", unequal(fmt.Sprintf("len(%s) != len(%s)", a, b)), k, v, a, w, ok, b, k,
+			unequal("!"+ok), gen.equalValue(v, w, t.ValueType, true, depth+1))
+	case t.IsBinary():
+		return unequal(fmt.Sprintf("!bytes.Equal(%s, %s)", a, b))
+	case gen.isStruct(t):
+		return unequal(fmt.Sprintf("!%s.Equal(%s)", a, b))
+	case goType == "*big.Rat" || goType == "*big.Float":
+		return unequal(fmt.Sprintf("(%s == nil) != (%s == nil) || %s != nil && %s.Cmp(%s) != 0", a, b, a, a, b))
+	case goType == "*time.Time":
+		return unequal(fmt.Sprintf("(%s == nil) != (%s == nil) || %s != nil && !%s.Equal(*%s)", a, b, a, a, b))
+	case strings.HasPrefix(goType, "*"):
+		return unequal(fmt.Sprintf("(%s == nil) != (%s == nil) || %s != nil && *%s != *%s", a, b, a, a, b))
+	case goType == "time.Time":
+		return unequal(fmt.Sprintf("!%s.Equal(%s)", a, b))
+	default:
+		return unequal(fmt.Sprintf("%s != %s", a, b))
+	}
+}
+
+// builderType returns the type of the parameter of the With method of a field.
+// Optional and pointer primitive fields take the value they point to.
+func (gen *goGenerator) builderType(t *idl.Type) string {
+	goType := gen.goType(t, false)
+	switch {
+	case gen.wrapped(t):
+		return gen.goType(t, true)
+	case strings.HasPrefix(goType, "*") && (t.IsPrimitive() || t.IsEnum(gen.tplRootIdl)) && !strings.HasPrefix(goType, "*big."):
+		return goType[1:]
+	default:
+		return goType
+	}
+}
+
+// builderSet returns the statement of the With method of a field that sets it
+// to value.
+func (gen *goGenerator) builderSet(f *idl.Field) string {
+	name := "obj." + escapeIdent("go", gen.toPascalCase(f.Name))
+	switch {
+	case gen.wrapped(f.Type):
+		return name + ".Set(value)"
+	case gen.builderType(f.Type) != gen.goType(f.Type, false):
+		return name + " = &value"
+	default:
+		return name + " = value"
+	}
+}
@@ -0,0 +1,354 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/babelrpc/babel/idl"
+)
+
+// goModule is the Go module that generated code is written into.
+type goModule struct {
+	Path string // module path declared in go.mod
+	Dir  string // absolute path of the folder holding go.mod
+	Go   string // go version declared in go.mod, or "" if it is not known
+}
+
+// omitZeroGo is the first Go version whose encoding/json honours omitzero.
+const omitZeroGo = "1.24"
+
+// goPackage describes where the code for a Go namespace is written and how
+// other packages import it.
+type goPackage struct {
+	ImportPath string
+	Dir        string
+	Name       string
+}
+
+// findGoModule reads the go.mod file in dir or the closest folder above it.
+func findGoModule(dir string) (*goModule, error) {
+	d, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		data, err := ioutil.ReadFile(filepath.Join(d, "go.mod"))
+		if err == nil {
+			p := readModulePath(data)
+			if p == "" {
+				return nil, fmt.Errorf("no module path in %s", filepath.Join(d, "go.mod"))
+			}
+			return &goModule{Path: p, Dir: d, Go: readGoVersion(data)}, nil
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return nil, fmt.Errorf("no go.mod found in %s or the folders above it", dir)
+		}
+		d = parent
+	}
+}
+
+// readModulePath returns the module path declared in the contents of a go.mod
+// file, or "" if there isn't one.
+func readModulePath(data []byte) string {
+	p := readDirective(data, "module")
+	if unq, err := strconv.Unquote(p); err == nil {
+		p = unq
+	}
+	return p
+}
+
+// readGoVersion returns the go version declared in the contents of a go.mod
+// file, or "" if there isn't one.
+func readGoVersion(data []byte) string {
+	return readDirective(data, "go")
+}
+
+// readDirective returns the argument of the first directive with the given
+// name in the contents of a go.mod file, or "" if there isn't one.
+func readDirective(data []byte, name string) string {
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if f := strings.Fields(line); len(f) == 2 && f[0] == name {
+			return f[1]
+		}
+	}
+	return ""
+}
+
+// goVersionBefore returns true if the Go version v, such as "1.21" or
+// "1.21.3", is older than min, which is given as "1.N".
+func goVersionBefore(v, min string) bool {
+	minor := func(v string) int {
+		parts := strings.SplitN(strings.TrimPrefix(v, "1."), ".", 2)
+		n, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return -1
+		}
+		return n
+	}
+	return strings.HasPrefix(v, "1.") && minor(v) < minor(min)
+}
+
+// isModulePath returns true if s looks like a module path.
+func isModulePath(s string) bool {
+	if s == "" || strings.HasPrefix(s, "/") || strings.HasSuffix(s, "/") || strings.Contains(s, "//") {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-._~/", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// goPackageName derives a package name from an import path. It is the last
+// element of the path, or the one before a major version suffix such as v2,
+// reduced to lower-case letters, digits and underscores.
+func goPackageName(importPath string) string {
+	elems := strings.Split(importPath, "/")
+	last := elems[len(elems)-1]
+	if len(elems) > 1 && len(last) > 1 && last[0] == 'v' && strings.Trim(last[1:], "0123456789") == "" {
+		last = elems[len(elems)-2]
+	}
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, last)
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "pkg" + name
+	}
+	if token.IsKeyword(name) {
+		name += "pkg"
+	}
+	return name
+}
+
+// packageOf returns the package for a Go namespace. Without a module, the
+// namespace is the import path and the code is written to the matching folder
+// under the output folder. With a module, namespaces inside the module are
+// written to their folder in the module, and other namespaces are written
+// under the output folder and imported relative to the module.
+func (gen *goGenerator) packageOf(ns string) (*goPackage, error) {
+	if p, ok := gen.packages[ns]; ok {
+		return p, nil
+	}
+	p := &goPackage{ImportPath: ns, Dir: filepath.Join(gen.args.OutputDir, filepath.FromSlash(ns))}
+	if m := gen.module; m != nil {
+		if ns == m.Path || strings.HasPrefix(ns, m.Path+"/") {
+			p.Dir = filepath.Join(m.Dir, filepath.FromSlash(strings.TrimPrefix(ns, m.Path)))
+		} else {
+			out, err := filepath.Abs(gen.args.OutputDir)
+			if err != nil {
+				return nil, err
+			}
+			rel, err := filepath.Rel(m.Dir, out)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return nil, fmt.Errorf("output folder %s is not inside module %s in %s", gen.args.OutputDir, m.Path, m.Dir)
+			}
+			p.ImportPath = path.Join(m.Path, filepath.ToSlash(rel), ns)
+		}
+	}
+	// keep the folder relative when the output folder is
+	if !filepath.IsAbs(gen.args.OutputDir) && filepath.IsAbs(p.Dir) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, p.Dir); err == nil {
+				p.Dir = rel
+			}
+		}
+	}
+	p.Name = goPackageName(p.ImportPath)
+	gen.packages[ns] = p
+	return p, nil
+}
+
+// goImport is a package imported by generated code.
+type goImport struct {
+	Alias string // empty unless the package name has to be changed
+	Path  string
+}
+
+// String formats the import for an import block.
+func (i goImport) String() string {
+	if i.Alias != "" {
+		return i.Alias + " " + strconv.Quote(i.Path)
+	}
+	return strconv.Quote(i.Path)
+}
+
+// resolveImports works out how the file generated by the template imports the
+// packages it uses: the standard packages first, then the packages of the Go
+// namespaces. A package whose name is already taken by the file's own package,
+// a standard package or another import is given a numbered alias.
+func (gen *goGenerator) resolveImports(template string) error {
+	own, err := gen.packageOf(gen.tplRootIdl.Namespaces["go"])
+	if err != nil {
+		return err
+	}
+	gen.imports = make([]goImport, 0)
+	gen.qualifiers = make(map[string]string)
+	gen.optionalQualifier = ""
+	used := map[string]bool{own.Name: true, "big": true, "time": true, "types": true, "rpc": true, "context": true, "fmt": true, "bytes": true}
+	for _, p := range gen.standardImports(template) {
+		imp := goImport{Path: p}
+		if gen.shape.optional != "" && p == gen.shape.optionalPackage() {
+			name := goPackageName(p)
+			for n := 2; used[name]; n++ {
+				name = goPackageName(p) + strconv.Itoa(n)
+			}
+			used[name] = true
+			if name != path.Base(p) {
+				imp.Alias = name
+			}
+			gen.optionalQualifier = name + "."
+		}
+		gen.imports = append(gen.imports, imp)
+	}
+	refs := gen.referencedNamespaces(template)
+	for _, ns := range gen.tplRootIdl.UniqueNamespaces("go") {
+		if !refs[ns] {
+			continue
+		}
+		p, err := gen.packageOf(ns)
+		if err != nil {
+			return err
+		}
+		if p.ImportPath == own.ImportPath {
+			continue
+		}
+		name := p.Name
+		for n := 2; used[name]; n++ {
+			name = p.Name + strconv.Itoa(n)
+		}
+		used[name] = true
+		imp := goImport{Path: p.ImportPath}
+		if name != path.Base(p.ImportPath) {
+			imp.Alias = name
+		}
+		gen.imports = append(gen.imports, imp)
+		gen.qualifiers[ns] = name
+	}
+	return nil
+}
+
+// referencedNamespaces returns the Go namespaces of the definitions used by the
+// file generated by the template: the structs for model.go, the services for
+// service.go and client.go, and none for invoker.go, which only uses the types
+// generated in its own package.
+func (gen *goGenerator) referencedNamespaces(template string) map[string]bool {
+	names := make([]string, 0)
+	var addType func(t *idl.Type)
+	addType = func(t *idl.Type) {
+		if t != nil {
+			names = append(names, t.Name)
+			addType(t.KeyType)
+			addType(t.ValueType)
+		}
+	}
+	addField := func(f *idl.Field) {
+		addType(f.Type)
+		if f.Initializer != nil && f.Initializer.DataType == "#ref" {
+			if s, ok := f.Initializer.Value.(string); ok {
+				names = append(names, strings.Split(s, ".")[0])
+			}
+		}
+	}
+	switch template {
+	case "invoker.go":
+	case "model.go":
+		for _, s := range gen.tplRootIdl.Structs {
+			if s.Extends != "" {
+				names = append(names, s.Extends)
+			}
+			for _, f := range s.Fields {
+				addField(f)
+			}
+			for _, f := range gen.modelFields(s) {
+				addType(f.Type)
+			}
+		}
+	default:
+		for _, s := range gen.tplRootIdl.Services {
+			for _, m := range s.Methods {
+				addType(m.Returns)
+				for _, p := range m.Parameters {
+					if template == "client.go" {
+						// the client leaves defaults to the server
+						addType(p.Type)
+					} else {
+						addField(p)
+					}
+				}
+			}
+		}
+	}
+	refs := make(map[string]bool)
+	for _, name := range names {
+		if d := gen.tplRootIdl.Resolve(name); d != nil {
+			refs[d.Idl.Namespaces["go"]] = true
+		}
+	}
+	return refs
+}
+
+// qualify returns the name of a definition prefixed with its package if it is
+// defined in another Go package than the current file.
+func (gen *goGenerator) qualify(name string) string {
+	return gen.qualifierOf(name) + name
+}
+
+// qualifierOf returns the package prefix, such as "common.", for the named
+// definition, or "" if it is in the current package.
+func (gen *goGenerator) qualifierOf(name string) string {
+	if d := gen.tplRootIdl.Resolve(name); d != nil {
+		if q, ok := gen.qualifiers[d.Idl.Namespaces["go"]]; ok {
+			return q + "."
+		}
+	}
+	return ""
+}
+
+// writeGoMod writes a go.mod file for the module into the output folder, unless
+// it has already been written or one exists. It returns the name of the file
+// if it was written.
+func (gen *goGenerator) writeGoMod() ([]string, error) {
+	if !gen.goMod || gen.wroteGoMod {
+		return nil, nil
+	}
+	gen.wroteGoMod = true
+	// gomod needs a module path, so the module is the output folder
+	fn := filepath.Join(gen.args.OutputDir, "go.mod")
+	if _, err := os.Stat(fn); err == nil {
+		return nil, nil
+	}
+	if err := os.MkdirAll(gen.args.OutputDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	goVersion := "1.16"
+	if gen.shape.omitsZero() {
+		goVersion = omitZeroGo
+	}
+	data := fmt.Sprintf("// Generated by babel. Run \"go mod tidy\" to add the requirements.\nmodule %s\n\ngo %s\n", gen.module.Path, goVersion)
+	if err := ioutil.WriteFile(fn, []byte(data), 0666); err != nil {
+		return nil, fmt.Errorf("can't create go.mod: %w", err)
+	}
+	return []string{fn}, nil
+}
@@ -0,0 +1,328 @@
+package generator
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+
+	"github.com/babelrpc/babel/idl"
+)
+
+// goShape holds the options that choose the Go types used for fields,
+// parameters and return values.
+type goShape struct {
+	primitives string // "pointer" or "value"
+	optional   string // generic wrapper for optional fields, such as "example.com/opt.Value"
+	datetime   string // "time" or "string"
+	decimal    string // "rat", "float" or "string"
+	omitempty  string // "all", "nil" or "none"
+
+	unknownEnums string // how enums unmarshal undeclared names: "keep", "error" or "empty"
+}
+
+// goShapeOptions lists the values allowed for the shape options. The first is
+// the default.
+var goShapeOptions = map[string][]string{
+	"primitives": {"pointer", "value"},
+	"datetime":   {"time", "string"},
+	"decimal":    {"rat", "float", "string"},
+	"omitempty":  {"all", "nil", "none"},
+
+	"unknownenums": {"keep", "error", "empty"},
+}
+
+// newGoShape returns the default shape, which is pointers to primitive types,
+// time.Time, *big.Rat, omitempty for every field, and enums that keep names
+// they don't declare.
+func newGoShape() goShape {
+	return goShape{primitives: "pointer", datetime: "time", decimal: "rat", omitempty: "all", unknownEnums: "keep"}
+}
+
+// set sets one of the shape options. It returns false if k is not a shape
+// option.
+func (s *goShape) set(k, v string) (bool, error) {
+	if k == "optional" {
+		i := strings.LastIndex(v, ".")
+		if i < 0 || i < strings.LastIndex(v, "/") || !isModulePath(v[:i]) || !token.IsExported(v[i+1:]) {
+			return true, fmt.Errorf("invalid optional option: %s.  It must be the import path and name of a generic type, such as example.com/opt.Value", v)
+		}
+		s.optional = v
+		return true, nil
+	}
+	allowed, ok := goShapeOptions[k]
+	if !ok {
+		return false, nil
+	}
+	valid := false
+	for _, a := range allowed {
+		valid = valid || a == v
+	}
+	if !valid {
+		return true, fmt.Errorf("invalid %s option: %s.  Valid options are %s", k, v, strings.Join(allowed, ", "))
+	}
+	switch k {
+	case "primitives":
+		s.primitives = v
+	case "datetime":
+		s.datetime = v
+	case "decimal":
+		s.decimal = v
+	case "omitempty":
+		s.omitempty = v
+	case "unknownenums":
+		s.unknownEnums = v
+	}
+	return true, nil
+}
+
+// check returns an error if the options conflict.
+func (s *goShape) check() error {
+	if s.optional != "" && s.primitives == "value" {
+		return fmt.Errorf("the optional option cannot be used with primitives=value")
+	}
+	return nil
+}
+
+// omitsZero returns true if fields with the optional wrapper are tagged
+// omitzero, which encoding/json only honours from Go 1.24.
+func (s *goShape) omitsZero() bool {
+	return s.optional != "" && s.omitempty != "none"
+}
+
+// optionalPackage returns the import path of the optional wrapper.
+func (s *goShape) optionalPackage() string {
+	return s.optional[:strings.LastIndex(s.optional, ".")]
+}
+
+// optionalName returns the name of the optional wrapper in its package.
+func (s *goShape) optionalName() string {
+	return s.optional[strings.LastIndex(s.optional, ".")+1:]
+}
+
+// wrapped returns true if fields of the type are declared with the optional
+// wrapper. Decimals are not wrapped, as they are already nil-able. Neither is
+// int64, since encoding/json ignores the string option for a struct, and the
+// value must be a JSON string.
+func (gen *goGenerator) wrapped(t *idl.Type) bool {
+	return gen.shape.optional != "" && !t.IsDecimal() && t.Name != "int64" && (t.IsPrimitive() || t.IsEnum(gen.tplRootIdl))
+}
+
+// goType returns the Go type for a field, parameter or return value, or for
+// the values of a list or map when elem is true. The optional wrapper is only
+// used for fields, parameters and return values.
+func (gen *goGenerator) goType(t *idl.Type, elem bool) string {
+	ms, ok := goTypes[t.Name]
+	if !ok {
+		ms = gen.qualify(t.Name)
+	}
+	switch {
+	case t.IsList():
+		return fmt.Sprintf(ms, gen.goType(t.ValueType, true))
+	case t.IsMap():
+		return fmt.Sprintf(ms, gen.goKeyType(t.KeyType), gen.goType(t.ValueType, true))
+	case t.IsBinary():
+		return ms
+	case t.IsVoid():
+		return ""
+	case t.IsDecimal() && gen.shape.decimal == "rat":
+		return "*big.Rat"
+	case t.IsDecimal() && gen.shape.decimal == "float":
+		return "*big.Float"
+	case t.IsDecimal():
+		if gen.shape.primitives == "value" || (elem && gen.shape.optional != "") {
+			return "string"
+		}
+		return "*string"
+	case !t.IsPrimitive() && !t.IsEnum(gen.tplRootIdl):
+		return "*" + ms
+	}
+	if t.IsDatetime() && gen.shape.datetime == "string" {
+		ms = "string"
+	}
+	switch {
+	case !elem && gen.wrapped(t):
+		return gen.optionalQualifier + gen.shape.optionalName() + "[" + ms + "]"
+	case gen.shape.primitives == "value" || (elem && gen.shape.optional != ""):
+		return ms
+	default:
+		return "*" + ms
+	}
+}
+
+// goKeyType returns the Go type for the keys of a map.
+func (gen *goGenerator) goKeyType(t *idl.Type) string {
+	if (t.IsDatetime() && gen.shape.datetime == "string") || (t.IsDecimal() && gen.shape.decimal == "string") {
+		return "string"
+	}
+	if ms, ok := goTypes[t.Name]; ok {
+		return ms
+	}
+	return gen.qualify(t.Name)
+}
+
+// serializerOptions returns the options of the json tag of a field.
+func (gen *goGenerator) serializerOptions(t *idl.Type) string {
+	s := ""
+	if t.Name == "int64" || (t.IsDecimal() && gen.shape.decimal != "string") {
+		s = ",string"
+	}
+	goType := gen.goType(t, false)
+	nilable := strings.HasPrefix(goType, "*") || strings.HasPrefix(goType, "[]") || strings.HasPrefix(goType, "map[")
+	switch {
+	case gen.shape.omitempty == "none":
+	case gen.wrapped(t):
+		// omitempty does not apply to structs
+		s += ",omitzero"
+	case gen.shape.omitempty == "all" || nilable:
+		s += ",omitempty"
+	}
+	return s
+}
+
+// setDefault returns the statements that set a field of obj to its initial
+// value.
+func (gen *goGenerator) setDefault(f *idl.Field) string {
+	name := "obj." + escapeIdent("go", gen.toPascalCase(f.Name))
+	value := gen.formatLiteral(f.Initializer.Value, f.Initializer.DataType)
+	goType := gen.goType(f.Type, false)
+	switch {
+	case gen.wrapped(f.Type):
+		return fmt.Sprintf("\n\t%s.Set(%s)", name, value)
+	case strings.HasPrefix(goType, "*"):
+		return fmt.Sprintf("\n\t%s = new(%s)\n\t*%s = %s", name, goType[1:], name, value)
+	default:
+		return fmt.Sprintf("\n\t%s = %s", name, value)
+	}
+}
+
+// roundDecimal returns the statements that round the decimals of field f of obj
+// to their declared scale, including the values of lists and maps.
+func (gen *goGenerator) roundDecimal(f *idl.Field) string {
+	name := "obj." + escapeIdent("go", gen.toPascalCase(f.Name))
+	return gen.eachDecimal(name, f.Type, false, 0, func(value, goType string, t *idl.Type) string {
+		switch {
+		case gen.shape.decimal == "rat":
+			return fmt.Sprintf("%s = types.RoundDecimal(%s, %d)", value, value, t.Scale)
+		case gen.shape.decimal == "float":
+			return fmt.Sprintf("%s = types.RoundFloat(%s, %d)", value, value, t.Scale)
+		case goType == "string":
+			return fmt.Sprintf("%s = types.RoundDecimalString(%s, %d)", value, value, t.Scale)
+		default:
+			return fmt.Sprintf("if %s != nil {\n\t*%s = types.RoundDecimalString(*%s, %d)\n}", value, value, value, t.Scale)
+		}
+	})
+}
+
+// checkDecimal returns the statements that return an error if a decimal of
+// field f of obj, including the values of lists and maps, does not fit its
+// declared precision and scale.
+func (gen *goGenerator) checkDecimal(f *idl.Field) string {
+	name := "obj." + escapeIdent("go", gen.toPascalCase(f.Name))
+	return gen.eachDecimal(name, f.Type, false, 0, func(value, goType string, t *idl.Type) string {
+		check := func(fn, value string) string {
+			return fmt.Sprintf("if err := types.%s(%s, %d, %d); err != nil {\n\treturn err\n}", fn, value, t.Precision, t.Scale)
+		}
+		switch {
+		case gen.shape.decimal == "rat":
+			return check("CheckRat", value)
+		case gen.shape.decimal == "float":
+			return check("CheckFloat", value)
+		case goType == "string":
+			return fmt.Sprintf("if %s != \"\" {\n\t%s\n}", value, strings.Replace(check("CheckDecimal", value), "\n", "\n\t", -1))
+		default:
+			return fmt.Sprintf("if %s != nil {\n\t%s\n}", value, strings.Replace(check("CheckDecimal", "*"+value), "\n", "\n\t", -1))
+		}
+	})
+}
+
+// eachDecimal returns the statements that apply stmt to every decimal that
+// expr, a value of type t, holds. Lists and maps are ranged over, and their
+// values are used by index, so stmt can assign to them. stmt is given the
+// expression of a decimal, its Go type and its IDL type. depth numbers the
+// variables of nested loops.
+func (gen *goGenerator) eachDecimal(expr string, t *idl.Type, elem bool, depth int, stmt func(value, goType string, t *idl.Type) string) string {
+	if t.IsList() || t.IsMap() {
+		i := fmt.Sprintf("i%d", depth)
+		if t.IsMap() {
+			i = fmt.Sprintf("k%d", depth)
+		}
+		body := gen.eachDecimal(expr+"["+i+"]", t.ValueType, true, depth+1, stmt)
+		return fmt.Sprintf("\n\tfor %s := range %s {%s\n\t}", i, expr, strings.Replace(body, "\n", "\n\t", -1))
+	}
+	return "\n\t" + strings.Replace(stmt(expr, gen.goType(t, elem), t), "\n", "\n\t", -1)
+}
+
+// modelFields returns the fields whose types the model of a struct refers to:
+// its own fields, and the fields it inherits when their With methods are
+// generated.
+func (gen *goGenerator) modelFields(s *idl.Struct) []*idl.Field {
+	if gen.builders {
+		if fields, err := gen.allFields(s); err == nil {
+			return fields
+		}
+	}
+	return s.Fields
+}
+
+// standardImports returns the packages other than Babel namespaces that the
+// file generated by the template imports.
+func (gen *goGenerator) standardImports(template string) []string {
+	fieldTypes := make([]*idl.Type, 0) // types of fields, parameters and return values
+	decimals := false                  // a struct has decimal fields to round and check
+	if template == "model.go" {
+		for _, s := range gen.tplRootIdl.Structs {
+			for _, f := range gen.modelFields(s) {
+				fieldTypes = append(fieldTypes, f.Type)
+			}
+			decimals = decimals || len(s.DecimalFields()) > 0
+		}
+	} else if template != "invoker.go" {
+		for _, s := range gen.tplRootIdl.Services {
+			for _, m := range s.Methods {
+				fieldTypes = append(fieldTypes, m.Returns)
+				for _, p := range m.Parameters {
+					fieldTypes = append(fieldTypes, p.Type)
+				}
+			}
+		}
+	}
+	uses := func(names ...string) bool {
+		for _, t := range fieldTypes {
+			if typeUses(t, names) {
+				return true
+			}
+		}
+		return false
+	}
+	wraps := false
+	for _, t := range fieldTypes {
+		wraps = wraps || gen.wrapped(t)
+	}
+
+	imports := make([]string, 0)
+	if gen.context && template != "model.go" {
+		imports = append(imports, "context")
+	}
+	if template == "model.go" && uses("binary") {
+		imports = append(imports, "bytes")
+	}
+	if template == "model.go" && len(gen.tplRootIdl.Enums) > 0 {
+		imports = append(imports, "fmt")
+	}
+	if uses("decimal") && gen.shape.decimal != "string" {
+		imports = append(imports, "math/big")
+	}
+	if uses("datetime") && gen.shape.datetime == "time" {
+		imports = append(imports, "time")
+	}
+	if uses("date", "time", "duration", "uuid") || decimals {
+		imports = append(imports, "github.com/babelrpc/babel/types")
+	}
+	if template == "client.go" || template == "invoker.go" {
+		imports = append(imports, "github.com/babelrpc/babel/rpc")
+	}
+	if wraps {
+		imports = append(imports, gen.shape.optionalPackage())
+	}
+	return imports
+}
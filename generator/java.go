@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/template"
 
 	"github.com/babelrpc/babel/idl"
+	"github.com/babelrpc/babel/rest"
 )
 
 var (
@@ -23,6 +25,10 @@ var (
 		"float64":  "double",
 		"string":   "String",
 		"datetime": "java.util.Date",
+		"date":     "java.time.LocalDate",
+		"time":     "java.time.LocalTime",
+		"duration": "java.time.Duration",
+		"uuid":     "java.util.UUID",
 		"decimal":  "java.math.BigDecimal",
 		"char":     "char",
 		"binary":   "byte[]", // java is signed
@@ -42,6 +48,10 @@ var (
 		"float64":  "Double",
 		"string":   "String",
 		"datetime": "java.util.Date",
+		"date":     "java.time.LocalDate",
+		"time":     "java.time.LocalTime",
+		"duration": "java.time.Duration",
+		"uuid":     "java.util.UUID",
 		"decimal":  "java.math.BigDecimal",
 		"char":     "Character",
 		"binary":   "byte[]", // java is signed
@@ -50,10 +60,24 @@ var (
 	}
 )
 
+// javaSupport maps the templates of the classes shared by the generated clients
+// and servers of a package to the names of the classes.
+var javaSupport = map[string]string{
+	"httpclient.java":       "BabelHttpClient",
+	"json.java":             "BabelJson",
+	"server.java":           "BabelServer",
+	"serviceexception.java": "ServiceException",
+}
+
 // javaGenerator is the code generator for Java.
 type javaGenerator struct {
 	templateManager
-	args *Arguments
+	args       *Arguments
+	restRoutes bool            // route the REST operations of methods in servers
+	style      string          // style of the model classes: beans, records or immutable
+	jackson    bool            // annotate models for Jackson as well as Gson
+	optional   bool            // getters of fields that may be unset return an Optional
+	support    map[string]bool // support classes already written, by file name
 }
 
 // formatListInit returns a string initializer for a list Type
@@ -69,7 +93,7 @@ func (gen *javaGenerator) formatListInit(t *idl.Type) string {
 func (gen *javaGenerator) formatMapInit(t *idl.Type) string {
 	var s string
 	ms := "java.util.HashMap<%s,%s>"
-	s = fmt.Sprintf(ms, nullJavaTypes[t.KeyType.Name], gen.formatType(t.ValueType))
+	s = fmt.Sprintf(ms, gen.formatKey(nullJavaTypes, t.KeyType), gen.formatType(t.ValueType))
 	return s
 
 }
@@ -84,7 +108,7 @@ func (gen *javaGenerator) formatType(t *idl.Type) string {
 	if t.Name == "list" {
 		s = fmt.Sprintf(ms, gen.formatType(t.ValueType))
 	} else if t.Name == "map" {
-		s = fmt.Sprintf(ms, nullJavaTypes[t.KeyType.Name], gen.formatType(t.ValueType))
+		s = fmt.Sprintf(ms, gen.formatKey(nullJavaTypes, t.KeyType), gen.formatType(t.ValueType))
 	} else {
 		s = ms
 	}
@@ -106,7 +130,7 @@ func (gen *javaGenerator) getParseType(t *idl.Type) string {
 		s = fmt.Sprintf(ms, gen.formatType(t.ValueType))
 	} else if t.Name == "map" {
 		ms = nullJavaTypes[t.Name]
-		s = fmt.Sprintf(ms, nullJavaTypes[t.KeyType.Name], gen.formatType(t.ValueType))
+		s = fmt.Sprintf(ms, gen.formatKey(nullJavaTypes, t.KeyType), gen.formatType(t.ValueType))
 	} else {
 		s = ms
 	}
@@ -124,7 +148,7 @@ func (gen *javaGenerator) fullTypeName(t *idl.Type) string {
 	if t.Name == "list" {
 		s = fmt.Sprintf(ms, gen.fullTypeName(t.ValueType))
 	} else if t.Name == "map" {
-		s = fmt.Sprintf(ms, nullJavaTypes[t.KeyType.Name], gen.fullTypeName(t.ValueType))
+		s = fmt.Sprintf(ms, gen.formatKey(nullJavaTypes, t.KeyType), gen.fullTypeName(t.ValueType))
 	} else {
 		ns := gen.tplRootIdl.NamespaceOf(ms, "java")
 		if ns != "" {
@@ -158,6 +182,50 @@ func (gen *javaGenerator) setterName(f *idl.Field) string {
 	return s
 }
 
+// fieldRef returns the expression that reads field f of obj, an instance of
+// struct s: the field itself when s declares it, or its getter when s inherits
+// it.
+func (gen *javaGenerator) fieldRef(s *idl.Struct, f *idl.Field, obj string) string {
+	if gen.declares(s, f) {
+		return obj + "." + escapeIdent("java", gen.toCamelCase(f.Name))
+	} else if gen.isOptional(f) {
+		return obj + "." + gen.getterName(f) + "().orElse(null)"
+	}
+	return obj + "." + gen.getterName(f) + "()"
+}
+
+// immutable returns true if values of the type can be shared by copies.
+func (gen *javaGenerator) immutable(t *idl.Type) bool {
+	return (t.IsPrimitive() && !t.IsDatetime()) || t.IsEnum(gen.tplRootIdl)
+}
+
+// copyOf returns an expression that makes a deep copy of expr, which is
+// evaluated more than once. depth numbers the parameters of nested lambdas.
+func (gen *javaGenerator) copyOf(t *idl.Type, expr string, depth int) string {
+	switch {
+	case gen.immutable(t):
+		return expr
+	case t.IsList() && gen.immutable(t.ValueType):
+		return fmt.Sprintf("%s == null ? null : new %s(%s)", expr, gen.formatListInit(t), expr)
+	case t.IsList():
+		l, v := fmt.Sprintf("l%d", depth), fmt.Sprintf("v%d", depth)
+		return fmt.Sprintf("%s == null ? null : %s.stream().collect(%s::new, (%s, %s) -> %s.add(%s), java.util.ArrayList::addAll)",
+			expr, expr, gen.formatListInit(t), l, v, l, gen.copyOf(t.ValueType, v, depth+1))
+	case t.IsMap() && gen.immutable(t.ValueType):
+		return fmt.Sprintf("%s == null ? null : new %s(%s)", expr, gen.formatMapInit(t), expr)
+	case t.IsMap():
+		m, e := fmt.Sprintf("m%d", depth), fmt.Sprintf("e%d", depth)
+		return fmt.Sprintf("%s == null ? null : %s.entrySet().stream().collect(%s::new, (%s, %s) -> %s.put(%s.getKey(), %s), java.util.HashMap::putAll)",
+			expr, expr, gen.formatMapInit(t), m, e, m, e, gen.copyOf(t.ValueType, e+".getValue()", depth+1))
+	case t.IsBinary():
+		return fmt.Sprintf("%s == null ? null : %s.clone()", expr, expr)
+	case t.IsDatetime():
+		return fmt.Sprintf("%s == null ? null : (java.util.Date) %s.clone()", expr, expr)
+	default:
+		return fmt.Sprintf("%s == null ? null : %s.copy()", expr, expr)
+	}
+}
+
 // formatLiteral formats a literal value for the Java parser.
 func (gen *javaGenerator) formatLiteral(value interface{}, typeName string) string {
 
@@ -165,7 +233,7 @@ func (gen *javaGenerator) formatLiteral(value interface{}, typeName string) stri
 		return fmt.Sprintf("%q", value)
 	} else {
 		if typeName == "#ref" {
-			return fmt.Sprintf("%s", value)
+			return escapeRef("java", fmt.Sprintf("%s", value))
 		} else {
 			return fmt.Sprintf("%#v", value)
 		}
@@ -175,7 +243,7 @@ func (gen *javaGenerator) formatLiteral(value interface{}, typeName string) stri
 // formatInitializerLiteral formats a initializer literal value for the Java parser.
 func (gen *javaGenerator) formatInitializerLiteral(value interface{}, fieldDataType string, initializerDataType string) string {
 	if initializerDataType == "#ref" {
-		return fmt.Sprintf("%s", value)
+		return escapeRef("java", fmt.Sprintf("%s", value))
 	} else if fieldDataType == "char" {
 		return fmt.Sprintf("%q", value)
 	} else if fieldDataType == "int64" {
@@ -207,12 +275,51 @@ func (gen *javaGenerator) filterAttributes(attrs []*idl.Attribute) []*idl.Attrib
 func (gen *javaGenerator) init(args *Arguments) error {
 	if !args.GenClient && !args.GenModel && !args.GenServer {
 		return fmt.Errorf("nothing to do")
-	} else if args.ServerType != "" {
-		return fmt.Errorf("-servertype is not applicable to language java")
-	} else if len(args.Options) > 0 {
-		return fmt.Errorf("-options is not applicable to language java")
+	} else if _, ok := javaServers[args.ServerType]; !ok && args.ServerType != "" {
+		return fmt.Errorf("invalid servertype: %s.  Valid options are 'spring' or 'jaxrs'", args.ServerType)
+	}
+	gen.style = "beans"
+	for k, v := range args.Options {
+		switch k {
+		case "style":
+			if _, ok := javaStyles[v]; !ok {
+				return fmt.Errorf("invalid style option: %s.  Valid options are 'beans', 'records' or 'immutable'", v)
+			}
+			gen.style = v
+		case "jackson":
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid jackson option: %s", v)
+			}
+			gen.jackson = b
+		case "optional":
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid optional option: %s", v)
+			}
+			gen.optional = b
+		case "builders":
+			if err := gen.setBuilders(v); err != nil {
+				return err
+			}
+		case "rest":
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid rest option: %s", v)
+			}
+			gen.restRoutes = b
+		default:
+			return fmt.Errorf("the %s option is not applicable to language java", k)
+		}
+	}
+	if gen.restRoutes && args.ServerType == "" {
+		return fmt.Errorf("the rest option needs -servertype spring or jaxrs")
+	}
+	if gen.optional && gen.style == "records" {
+		return fmt.Errorf("the optional option does not apply to style=records, whose components are read directly")
 	}
 	gen.args = args
+	gen.support = make(map[string]bool)
 	return gen.loadTempates(args.TemplateDir, "java", template.FuncMap{
 		"formatType":  func(t *idl.Type) string { return gen.formatType(t) },
 		"fullNameOf":  func(name string) string { return gen.fullNameOf(name) },
@@ -253,6 +360,32 @@ func (gen *javaGenerator) init(args *Arguments) error {
 		},
 		"formatListInit": func(t *idl.Type) string { return gen.formatListInit(t) },
 		"formatMapInit":  func(t *idl.Type) string { return gen.formatMapInit(t) },
+		"fieldRef":       func(s *idl.Struct, f *idl.Field, obj string) string { return gen.fieldRef(s, f, obj) },
+		"copyOf":         func(t *idl.Type, expr string) string { return gen.copyOf(t, expr, 0) },
+		"equalsOf": func(t *idl.Type, a, b string) string {
+			if t.IsBinary() {
+				return fmt.Sprintf("java.util.Arrays.equals(%s, %s)", a, b)
+			}
+			return fmt.Sprintf("java.util.Objects.equals(%s, %s)", a, b)
+		},
+		"hashOf": func(t *idl.Type, expr string) string {
+			if t.IsBinary() {
+				return fmt.Sprintf("java.util.Arrays.hashCode(%s)", expr)
+			}
+			return expr
+		},
+		"restOp":       func(m *idl.Method) (*rest.Operation, error) { return gen.restOp(m) },
+		"restParams":   func(m *idl.Method) (string, error) { return gen.restParams(m) },
+		"restPuts":     func(m *idl.Method, request string) ([]string, error) { return gen.restPuts(m, request) },
+		"unusedName":   func(m *idl.Method, name string) string { return gen.unusedName(m, name) },
+		"jackson":      func() bool { return gen.jackson },
+		"isOptional":   func(f *idl.Field) bool { return gen.isOptional(f) },
+		"jsonAttrs":    func(f *idl.Field) []string { return gen.jsonAttrs(f) },
+		"defaultOf":    func(f *idl.Field) string { return gen.defaultOf(f) },
+		"initOf":       func(f *idl.Field, expr string) string { return gen.initOf(f, expr) },
+		"roundDecimal": func(f *idl.Field, expr string) string { return gen.roundDecimal(f, expr) },
+		"checkDecimal": func(f *idl.Field, expr string) []string { return gen.checkDecimal(f, expr) },
+		"abstractBase": func(s *idl.Struct) (string, error) { return gen.abstractBase(s) },
 	})
 }
 
@@ -286,6 +419,22 @@ func (gen *javaGenerator) GenerateCode(pidl *idl.Idl) ([]string, error) {
 		outFnames = append(outFnames, serviceFnames...)
 	}
 
+	if gen.args.GenClient {
+		clientFnames, err := gen.GenClient(pidl)
+		if err != nil {
+			return nil, err
+		}
+		outFnames = append(outFnames, clientFnames...)
+	}
+
+	if gen.args.GenServer && gen.args.ServerType != "" {
+		serverFnames, err := gen.GenServer(pidl)
+		if err != nil {
+			return nil, err
+		}
+		outFnames = append(outFnames, serverFnames...)
+	}
+
 	if gen.args.GenModel {
 		constFnames, err := gen.GenConst(pidl)
 		if err != nil {
@@ -297,8 +446,90 @@ func (gen *javaGenerator) GenerateCode(pidl *idl.Idl) ([]string, error) {
 	return outFnames, nil
 }
 
+// GenClient generates an HTTP client for each service, and the classes they
+// share, once for each package.
 func (gen *javaGenerator) GenClient(pidl *idl.Idl) ([]string, error) {
-	return nil, nil
+
+	if len(pidl.Services) == 0 {
+		return []string{}, nil
+	}
+	gen.resetTemplate(pidl)
+	outFnames := make([]string, 0)
+	pkgPath := gen.BuildPkg(pidl)
+	err := os.MkdirAll(filepath.Join(gen.args.OutputDir, pkgPath), os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("can't create client output package dir: %w", err)
+	}
+
+	for _, s := range pidl.Services {
+		fname := filepath.Join(gen.args.OutputDir, pkgPath, s.Name+"Client.java")
+		outFnames = append(outFnames, fname)
+		err = gen.writeTemplate(fname, "client.java", s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	supportFnames, err := gen.genSupport(pidl, "httpclient.java", "json.java", "serviceexception.java")
+	if err != nil {
+		return nil, err
+	}
+	return append(outFnames, supportFnames...), nil
+
+}
+
+// GenServer generates the classes of the server type that route requests to
+// the implementation of each service, and the classes they share, once for each
+// package.
+func (gen *javaGenerator) GenServer(pidl *idl.Idl) ([]string, error) {
+
+	if len(pidl.Services) == 0 {
+		return []string{}, nil
+	}
+	gen.resetTemplate(pidl)
+	outFnames := make([]string, 0)
+	pkgPath := gen.BuildPkg(pidl)
+	err := os.MkdirAll(filepath.Join(gen.args.OutputDir, pkgPath), os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("can't create server output package dir: %w", err)
+	}
+
+	server := javaServers[gen.args.ServerType]
+	for _, s := range pidl.Services {
+		fname := filepath.Join(gen.args.OutputDir, pkgPath, s.Name+server[1]+".java")
+		outFnames = append(outFnames, fname)
+		err = gen.writeTemplate(fname, server[0], s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	supportFnames, err := gen.genSupport(pidl, "server.java", "json.java", "serviceexception.java")
+	if err != nil {
+		return nil, err
+	}
+	return append(outFnames, supportFnames...), nil
+
+}
+
+// genSupport writes the support classes of the given templates into the package
+// of the IDL, unless they have already been written.
+func (gen *javaGenerator) genSupport(pidl *idl.Idl, templates ...string) ([]string, error) {
+	outFnames := make([]string, 0)
+	pkgPath := gen.BuildPkg(pidl)
+	for _, t := range templates {
+		fname := filepath.Join(gen.args.OutputDir, pkgPath, javaSupport[t]+".java")
+		if gen.support[fname] {
+			continue
+		}
+		gen.support[fname] = true
+		outFnames = append(outFnames, fname)
+		err := gen.writeTemplate(fname, t, pidl)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return outFnames, nil
 }
 
 func (gen *javaGenerator) GenService(pidl *idl.Idl) ([]string, error) {
@@ -314,15 +545,9 @@ func (gen *javaGenerator) GenService(pidl *idl.Idl) ([]string, error) {
 		if err != nil {
 			return nil, fmt.Errorf("can't create service output package dir: %w", err)
 		}
-		outFile, err := os.Create(fname)
-		if err != nil {
-			return nil, fmt.Errorf("can't create service output file: %w", err)
-		}
-
-		defer outFile.Close()
-		err = gen.templates.ExecuteTemplate(outFile, "interface.java", s)
+		err = gen.writeTemplate(fname, "interface.java", s)
 		if err != nil {
-			return nil, fmt.Errorf("error executing service template: %w", err)
+			return nil, err
 		}
 	}
 
@@ -343,15 +568,9 @@ func (gen *javaGenerator) GenModel(pidl *idl.Idl) ([]string, error) {
 		if err != nil {
 			return nil, fmt.Errorf("can't create model output package dir: %w", err)
 		}
-		outFile, err := os.Create(fname)
+		err = gen.writeTemplate(fname, javaStyles[gen.style], s)
 		if err != nil {
-			return nil, fmt.Errorf("can't create model output file: %w", err)
-		}
-
-		defer outFile.Close()
-		err = gen.templates.ExecuteTemplate(outFile, "model.java", s)
-		if err != nil {
-			return nil, fmt.Errorf("error executing model template: %w", err)
+			return nil, err
 		}
 	}
 
@@ -372,15 +591,9 @@ func (gen *javaGenerator) GenEnum(pidl *idl.Idl) ([]string, error) {
 		if err != nil {
 			return nil, fmt.Errorf("can't create enum output package dir: %w", err)
 		}
-		outFile, err := os.Create(fname)
-		if err != nil {
-			return nil, fmt.Errorf("can't create enum output file: %w", err)
-		}
-
-		defer outFile.Close()
-		err = gen.templates.ExecuteTemplate(outFile, "enum.java", e)
+		err = gen.writeTemplate(fname, "enum.java", e)
 		if err != nil {
-			return nil, fmt.Errorf("error executing enum template: %w", err)
+			return nil, err
 		}
 	}
 
@@ -401,15 +614,9 @@ func (gen *javaGenerator) GenConst(pidl *idl.Idl) ([]string, error) {
 		if err != nil {
 			return nil, fmt.Errorf("can't create const output package dir: %w", err)
 		}
-		outFile, err := os.Create(fname)
+		err = gen.writeTemplate(fname, "constants.java", c)
 		if err != nil {
-			return nil, fmt.Errorf("can't create const output file: %w", err)
-		}
-
-		defer outFile.Close()
-		err = gen.templates.ExecuteTemplate(outFile, "constants.java", c)
-		if err != nil {
-			return nil, fmt.Errorf("error executing const template: %w", err)
+			return nil, err
 		}
 	}
 
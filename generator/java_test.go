@@ -0,0 +1,223 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	javaAnnotation = regexp.MustCompile(`^@[\w.]+(\(.*\))?$`)
+	javaDeclEnd    = regexp.MustCompile(`^\s*([{;=]|$)`)
+)
+
+// javaAnnotations returns the annotations on the lines before a declaration in
+// Java source, and false if no line starts with the declaration followed by a
+// body, an initial value or the end of the line.
+func javaAnnotations(src, decl string) ([]string, bool) {
+	lines := strings.Split(src, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, decl) || !javaDeclEnd.MatchString(line[len(decl):]) {
+			continue
+		}
+		var annotations []string
+		for j := i - 1; j >= 0 && javaAnnotation.MatchString(strings.TrimSpace(lines[j])); j-- {
+			annotations = append([]string{strings.TrimSpace(lines[j])}, annotations...)
+		}
+		return annotations, true
+	}
+	return nil, false
+}
+
+// expectJava checks that Java source declares members with the given
+// annotations. Each member is the declaration followed by its annotations.
+func expectJava(t *testing.T, name, src string, members ...[]string) {
+	t.Helper()
+	for _, m := range members {
+		annotations, ok := javaAnnotations(src, m[0])
+		if !ok {
+			t.Errorf("%s does not declare %q", name, m[0])
+		} else if strings.Join(annotations, " ") != strings.Join(m[1:], " ") {
+			t.Errorf("%s declares %q with %v instead of %v", name, m[0], annotations, m[1:])
+		}
+	}
+}
+
+func TestJavaClient(t *testing.T) {
+	// the second run only writes the files of the service again
+	g := generate(t, "java", Arguments{GenClient: true}, "gomod/users.babel", "gomod/users.babel")
+	written := make(map[string]int)
+	for _, fn := range g.Files {
+		written[path.Base(fn)]++
+	}
+	for name, n := range written {
+		want := 1
+		if name == "UsersClient.java" || name == "Users.java" {
+			want = 2
+		}
+		if n != want {
+			t.Errorf("%s was written %d times instead of %d", name, n, want)
+		}
+	}
+
+	expectJava(t, "UsersClient.java", g.file(t, "UsersClient.java"),
+		[]string{"public class UsersClient implements Users.Iface"},
+		[]string{"public UsersClient(String baseUrl)"},
+		[]string{"public UsersClient(BabelHttpClient client)"},
+		[]string{"public User lookup(String name, Status state)", "@Override"},
+		[]string{"public CompletableFuture<User> lookupAsync(String name, Status state)"},
+		[]string{"public java.util.List<User> all()", "@Override"},
+		[]string{"public CompletableFuture<java.util.List<User>> allAsync()"},
+	)
+	expectJava(t, "BabelHttpClient.java", g.file(t, "BabelHttpClient.java"),
+		[]string{"public <T> T call(String service, String method, Map<String, Object> params, Type type)"},
+		[]string{"public <T> CompletableFuture<T> callAsync(String service, String method, Map<String, Object> params, Type type)"},
+	)
+	expectJava(t, "BabelJson.java", g.file(t, "BabelJson.java"),
+		[]string{"public static final Gson GSON"},
+		[]string{"public static GsonBuilder builder()"},
+	)
+	expectJava(t, "ServiceException.java", g.file(t, "ServiceException.java"),
+		[]string{"public class ServiceException extends RuntimeException"},
+		[]string{"public int getStatusCode()"},
+	)
+}
+
+func TestJavaServer(t *testing.T) {
+	tests := []struct {
+		serverType string
+		typeName   string
+		members    [][]string // the declaration and the annotations of members that are always generated
+		routes     [][]string // the same for those only generated with the rest option
+	}{
+		{"spring", "ContactsController", [][]string{
+			{"public class ContactsController", "@RestController"},
+			{"public ResponseEntity<String> get(@RequestBody(required = false) String body)", `@PostMapping(path = "/Contacts/Get", produces = MediaType.APPLICATION_JSON_VALUE)`},
+			{"public ResponseEntity<String> count(@RequestBody(required = false) String body)", `@PostMapping(path = "/Contacts/Count", produces = MediaType.APPLICATION_JSON_VALUE)`},
+		}, [][]string{
+			{`public ResponseEntity<String> getRest(@PathVariable(name = "name", required = true) String name, @RequestHeader(name = "X-Tenant", required = false) String tenant)`,
+				`@RequestMapping(path = "/contacts/{name}", method = RequestMethod.GET, produces = MediaType.APPLICATION_JSON_VALUE)`},
+			{`public ResponseEntity<String> findRest(@RequestParam(name = "tags", required = false) String tags, @RequestParam(name = "kinds", required = false) java.util.List<String> kinds, @RequestParam(name = "max", required = false) String max)`,
+				"@Deprecated", `@RequestMapping(path = "/contacts", method = RequestMethod.GET, produces = MediaType.APPLICATION_JSON_VALUE)`},
+			{"public ResponseEntity<String> saveRest(@RequestBody(required = true) String request)",
+				`@RequestMapping(path = "/contacts", method = RequestMethod.PUT, produces = MediaType.APPLICATION_JSON_VALUE)`},
+		}},
+		{"jaxrs", "ContactsResource", [][]string{
+			{"public class ContactsResource", `@Path("/")`},
+			{"public Response get(String body)", "@POST", `@Path("Contacts/Get")`, "@Produces(MediaType.APPLICATION_JSON)"},
+			{"public Response count(String body)", "@POST", `@Path("Contacts/Count")`, "@Produces(MediaType.APPLICATION_JSON)"},
+		}, [][]string{
+			{`public Response getRest(@PathParam("name") String name, @HeaderParam("X-Tenant") String tenant)`, "@GET", `@Path("/contacts/{name}")`, "@Produces(MediaType.APPLICATION_JSON)"},
+			{`public Response findRest(@QueryParam("tags") String tags, @QueryParam("kinds") java.util.List<String> kinds, @QueryParam("max") String max)`,
+				"@Deprecated", "@GET", `@Path("/contacts")`, "@Produces(MediaType.APPLICATION_JSON)"},
+			{"public Response saveRest(String request)", "@PUT", `@Path("/contacts")`, "@Produces(MediaType.APPLICATION_JSON)"},
+		}},
+	}
+	for _, tc := range tests {
+		for _, rest := range []bool{true, false} {
+			g := generate(t, "java", Arguments{GenServer: true, ServerType: tc.serverType, Options: map[string]string{"rest": fmt.Sprint(rest)}}, "rest_routes.babel")
+			name := tc.typeName + ".java"
+			src := g.file(t, name)
+			expectJava(t, name, src, tc.members...)
+			if rest {
+				expectJava(t, name, src, tc.routes...)
+			}
+			for _, m := range tc.routes {
+				if _, ok := javaAnnotations(src, m[0]); ok != rest {
+					t.Errorf("With rest=%v, %s has the wrong route for %q", rest, name, m[0])
+				}
+			}
+			// the hidden operation is not routed
+			if strings.Contains(src, " countRest(") {
+				t.Errorf("%s routes a hidden operation", name)
+			}
+			// the request of an operation has a field for each parameter
+			if _, ok := javaAnnotations(src, "private Integer max"); !ok {
+				t.Errorf("%s has no field for the max parameter of Find", name)
+			}
+		}
+	}
+
+	for _, args := range []Arguments{
+		{GenServer: true, ServerType: "servlet"},
+		{GenServer: true, Options: map[string]string{"rest": "true"}},
+	} {
+		args.TemplateDir = filepath.Join("..", "babeltemplates")
+		args.OutputDir = t.TempDir()
+		if _, err := New("java", &args); err == nil {
+			t.Errorf("The java generator accepted servertype %q with options %v", args.ServerType, args.Options)
+		}
+	}
+}
+
+func TestJavaModelStyles(t *testing.T) {
+	tests := []struct {
+		options map[string]string
+		members [][]string // the declaration and the annotations of members of Payment
+		builder bool
+	}{
+		{map[string]string{"jackson": "true", "optional": "true"}, [][]string{
+			{"public class Payment extends Money implements Serializable", "@JsonInclude(JsonInclude.Include.NON_NULL)",
+				"@JsonAutoDetect(fieldVisibility = JsonAutoDetect.Visibility.ANY, getterVisibility = JsonAutoDetect.Visibility.NONE, isGetterVisibility = JsonAutoDetect.Visibility.NONE, setterVisibility = JsonAutoDetect.Visibility.NONE)"},
+			{"private java.math.BigDecimal fraction", `@SerializedName("Fraction")`, `@JsonProperty("Fraction")`, "@JsonFormat(shape = JsonFormat.Shape.STRING)"},
+			{"public java.util.Optional<java.math.BigDecimal> getFraction()"},
+			{"public void setFraction(java.math.BigDecimal fraction)"},
+			{"public Payment copy()", "@Override"},
+		}, false},
+		{map[string]string{"style": "records", "builders": "true"}, [][]string{
+			{"public record Payment("},
+			{`@SerializedName("Amount") java.math.BigDecimal amount,`},
+			{`@SerializedName("Fraction") java.math.BigDecimal fraction) implements Serializable`},
+			{"public Payment"},
+			{"public void checkDecimals()"},
+			{"public Payment copy()"},
+		}, true},
+		{map[string]string{"style": "immutable", "jackson": "true"}, [][]string{
+			{"private final java.math.BigDecimal fraction", `@SerializedName("Fraction")`, `@JsonProperty("Fraction")`, "@JsonFormat(shape = JsonFormat.Shape.STRING)"},
+			{"protected Payment()"},
+			{"public Payment("},
+			{"java.math.BigDecimal fraction)"},
+			{"public java.math.BigDecimal getFraction()"},
+			{"public Payment copy()", "@Override"},
+		}, true},
+	}
+	for _, tc := range tests {
+		g := generate(t, "java", Arguments{GenModel: true, Options: tc.options}, "decimal_precision.babel")
+		src := g.file(t, "Payment.java")
+		expectJava(t, "Payment.java", src, tc.members...)
+		// immutable models have no setters
+		if strings.Contains(src, " setFraction(") && tc.options["style"] != "" {
+			t.Errorf("With %v, Payment has a setter", tc.options)
+		}
+		if _, ok := javaAnnotations(src, "public Builder fraction(java.math.BigDecimal value)"); ok != tc.builder {
+			t.Errorf("With %v, Payment has the wrong builder", tc.options)
+		}
+		// decimals in lists and maps are checked too
+		src = g.file(t, "Quote.java")
+		expectJava(t, "Quote.java", src, []string{"public void checkDecimals()"})
+		loops := declarations(src, regexp.MustCompile(`\bfor \(`))
+		for _, loop := range []string{
+			"if (this.rates != null) for (java.math.BigDecimal v0 : this.rates)",
+			"if (this.fees != null) for (java.util.List<java.math.BigDecimal> v0 : this.fees.values())",
+			"if (v0 != null) for (java.math.BigDecimal v1 : v0)",
+		} {
+			if !loops[loop] {
+				t.Errorf("With %v, Quote.checkDecimals does not have %q: %v", tc.options, loop, loops)
+			}
+		}
+	}
+
+	for _, options := range []map[string]string{
+		{"style": "pojo"},
+		{"style": "records", "optional": "true"},
+		{"jackson": "maybe"},
+	} {
+		if _, err := New("java", &Arguments{OutputDir: t.TempDir(), GenModel: true, Options: options}); err == nil {
+			t.Errorf("The options %v should have failed", options)
+		}
+	}
+}
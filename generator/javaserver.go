@@ -0,0 +1,166 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/babelrpc/babel/idl"
+	"github.com/babelrpc/babel/rest"
+)
+
+// javaServers maps the Java server types to the templates of their classes and
+// the suffix of the class names.
+var javaServers = map[string][2]string{
+	"spring": {"spring.java", "Controller"},
+	"jaxrs":  {"jaxrs.java", "Resource"},
+}
+
+// javaSeparators are the separators of list parameters in REST routes.
+var javaSeparators = map[rest.ListFmt]string{
+	rest.NONE:  ",",
+	rest.CSV:   ",",
+	rest.SSV:   " ",
+	rest.TSV:   "\t",
+	rest.PIPES: "|",
+}
+
+// restOp returns the REST operation of a method, or nil if REST routes are not
+// generated or the method has no visible Op attribute.
+func (gen *javaGenerator) restOp(m *idl.Method) (*rest.Operation, error) {
+	if !gen.restRoutes {
+		return nil, nil
+	}
+	found := false
+	for _, a := range m.Attributes {
+		if a.Scope == "rest" && a.Name == "Op" {
+			found = true
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	op, err := rest.ReadOp(m)
+	if err != nil {
+		return nil, fmt.Errorf("can't route %s: %w", m.Name, err)
+	}
+	if op.Hide {
+		return nil, nil
+	}
+	return op, nil
+}
+
+// restParm is a parameter of a method read from a REST request.
+type restParm struct {
+	*rest.Parm
+	field *idl.Field
+	name  string // name of the value in the request
+	multi bool   // a list given as several values
+}
+
+// restParms reads the REST attributes of the parameters of a method.
+func (gen *javaGenerator) restParms(m *idl.Method) ([]restParm, error) {
+	parms := make([]restParm, 0, len(m.Parameters))
+	body := false
+	for _, f := range m.Parameters {
+		p, err := rest.ReadParm(f)
+		if err != nil {
+			return nil, fmt.Errorf("can't route %s: parameter %s: %w", m.Name, f.Name, err)
+		}
+		if p.In == rest.BODY {
+			if body {
+				return nil, fmt.Errorf("can't route %s: only one parameter can be read from the body", m.Name)
+			}
+			body = true
+		}
+		name := p.Name
+		if name == "" {
+			name = f.WireName()
+		}
+		parms = append(parms, restParm{Parm: p, field: f, name: name, multi: f.Type.IsList() && p.Format == rest.MULTI})
+	}
+	return parms, nil
+}
+
+// restParams returns the parameters of the Java method that handles the REST
+// route of a method, annotated for the server type. Values other than the body
+// are read as strings and converted when the request is read.
+func (gen *javaGenerator) restParams(m *idl.Method) (string, error) {
+	parms, err := gen.restParms(m)
+	if err != nil {
+		return "", err
+	}
+	s := make([]string, len(parms))
+	for i, p := range parms {
+		javaType := "String"
+		if p.multi {
+			javaType = "java.util.List<String>"
+		}
+		name := strconv.Quote(p.name)
+		var annotation string
+		if gen.args.ServerType == "spring" {
+			required := strconv.FormatBool(p.Required)
+			switch p.In {
+			case rest.PATH:
+				annotation = "@PathVariable(name = " + name + ", required = " + required + ")"
+			case rest.HEADER:
+				annotation = "@RequestHeader(name = " + name + ", required = " + required + ")"
+			case rest.BODY:
+				annotation = "@RequestBody(required = " + required + ")"
+			default:
+				annotation = "@RequestParam(name = " + name + ", required = " + required + ")"
+			}
+		} else {
+			switch p.In {
+			case rest.PATH:
+				annotation = "@PathParam(" + name + ")"
+			case rest.HEADER:
+				annotation = "@HeaderParam(" + name + ")"
+			case rest.FORMDATA:
+				annotation = "@FormParam(" + name + ")"
+			case rest.QUERY:
+				annotation = "@QueryParam(" + name + ")"
+			}
+		}
+		if annotation != "" {
+			annotation += " "
+		}
+		s[i] = annotation + javaType + " " + escapeIdent("java", gen.toCamelCase(p.field.Name))
+	}
+	return strings.Join(s, ", "), nil
+}
+
+// restPuts returns the statements that add the parameters of the REST route of
+// a method to request, the JSON object the method's request is read from.
+func (gen *javaGenerator) restPuts(m *idl.Method, request string) ([]string, error) {
+	parms, err := gen.restParms(m)
+	if err != nil {
+		return nil, err
+	}
+	s := make([]string, len(parms))
+	for i, p := range parms {
+		args := request + ", " + strconv.Quote(p.field.WireName()) + ", " + escapeIdent("java", gen.toCamelCase(p.field.Name))
+		switch {
+		case p.In == rest.BODY:
+			s[i] = "BabelServer.putJson(" + args + ");"
+		case p.field.Type.IsList() && !p.multi:
+			s[i] = "BabelServer.put(" + args + ", " + strconv.Quote(javaSeparators[p.Format]) + ");"
+		default:
+			s[i] = "BabelServer.put(" + args + ");"
+		}
+	}
+	return s, nil
+}
+
+// unusedName returns name, followed by underscores if needed so that it differs
+// from the names of the method's parameters. Generated methods use it to name
+// their local variables.
+func (gen *javaGenerator) unusedName(m *idl.Method, name string) string {
+	for i := 0; i < len(m.Parameters); i++ {
+		if escapeIdent("java", gen.toCamelCase(m.Parameters[i].Name)) == name {
+			name += "_"
+			i = -1
+		}
+	}
+	return name
+}
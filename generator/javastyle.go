@@ -0,0 +1,144 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/babelrpc/babel/idl"
+)
+
+// javaStyles maps the Java model styles to their templates.
+var javaStyles = map[string]string{
+	"beans":     "model.java",
+	"records":   "record.java",
+	"immutable": "immutable.java",
+}
+
+// isOptional returns true if the getter of a field returns an Optional. Lists
+// and maps are never unset, so they are returned as they are.
+func (gen *javaGenerator) isOptional(f *idl.Field) bool {
+	return gen.optional && !f.Type.IsList() && !f.Type.IsMap()
+}
+
+// jsonAttrs returns the serializer annotations of a field: its wire name for
+// Gson, and with the jackson option its wire name and format for Jackson.
+// int64 and decimal values are strings, and datetimes, dates, times and
+// durations are ISO 8601 strings, which needs Jackson's JavaTimeModule.
+func (gen *javaGenerator) jsonAttrs(f *idl.Field) []string {
+	attrs := []string{fmt.Sprintf("@SerializedName(%q)", f.WireName())}
+	if !gen.jackson {
+		return attrs
+	}
+	attrs = append(attrs, fmt.Sprintf("@JsonProperty(%q)", f.WireName()))
+	t := f.Type
+	for t.IsList() || t.IsMap() {
+		t = t.ValueType
+	}
+	switch {
+	case t.IsDatetime():
+		attrs = append(attrs, `@JsonFormat(shape = JsonFormat.Shape.STRING, timezone = "UTC")`)
+	case t.Name == "int64" || t.IsDecimal() || t.IsDate() || t.IsTime() || t.IsDuration():
+		attrs = append(attrs, "@JsonFormat(shape = JsonFormat.Shape.STRING)")
+	}
+	return attrs
+}
+
+// defaultOf returns the value a field is given when it isn't set, or "" if it
+// doesn't have one: its initializer, or an empty list or map.
+func (gen *javaGenerator) defaultOf(f *idl.Field) string {
+	switch {
+	case f.Initializer != nil:
+		return gen.formatInitializerLiteral(f.Initializer.Value, f.Type.Name, f.Initializer.DataType)
+	case f.Type.IsList():
+		return "new " + gen.formatListInit(f.Type) + "()"
+	case f.Type.IsMap():
+		return "new " + gen.formatMapInit(f.Type) + "()"
+	}
+	return ""
+}
+
+// initOf returns the value that records and immutable classes store for expr,
+// the value given for field f: its default value if expr is null, otherwise a
+// deep copy of expr, with decimals rounded to their declared scale. It returns
+// expr itself if there's nothing to do.
+func (gen *javaGenerator) initOf(f *idl.Field, expr string) string {
+	value := gen.copyOf(f.Type, expr, 0)
+	if f.Type.HoldsPrecision() {
+		value = gen.roundOf(f.Type, expr, 0)
+	}
+	d := gen.defaultOf(f)
+	switch {
+	case d == "":
+		return value
+	case value == expr:
+		return fmt.Sprintf("%s == null ? %s : %s", expr, d, expr)
+	default:
+		return strings.Replace(value, expr+" == null ? null : ", expr+" == null ? "+d+" : ", 1)
+	}
+}
+
+// roundOf returns an expression that makes a copy of expr, which is evaluated
+// more than once, with its decimals rounded to their declared scale. The type
+// must hold decimals with a precision, directly or in lists and maps. depth
+// numbers the parameters of nested lambdas.
+func (gen *javaGenerator) roundOf(t *idl.Type, expr string, depth int) string {
+	switch {
+	case t.IsList():
+		l, v := fmt.Sprintf("l%d", depth), fmt.Sprintf("v%d", depth)
+		return fmt.Sprintf("%s == null ? null : %s.stream().collect(%s::new, (%s, %s) -> %s.add(%s), java.util.ArrayList::addAll)",
+			expr, expr, gen.formatListInit(t), l, v, l, gen.roundOf(t.ValueType, v, depth+1))
+	case t.IsMap():
+		m, e := fmt.Sprintf("m%d", depth), fmt.Sprintf("e%d", depth)
+		return fmt.Sprintf("%s == null ? null : %s.entrySet().stream().collect(%s::new, (%s, %s) -> %s.put(%s.getKey(), %s), java.util.HashMap::putAll)",
+			expr, expr, gen.formatMapInit(t), m, e, m, e, gen.roundOf(t.ValueType, e+".getValue()", depth+1))
+	default:
+		return fmt.Sprintf("%s == null ? null : %s.setScale(%d, java.math.RoundingMode.HALF_UP)", expr, expr, t.Scale)
+	}
+}
+
+// roundDecimal returns the statement of roundDecimals that rounds the decimals
+// of field f, which is read and written as expr.
+func (gen *javaGenerator) roundDecimal(f *idl.Field, expr string) string {
+	if f.Type.HasPrecision() {
+		return fmt.Sprintf("if (%s != null) %s = %s.setScale(%d, java.math.RoundingMode.HALF_UP);", expr, expr, expr, f.Type.Scale)
+	}
+	return fmt.Sprintf("%s = %s;", expr, gen.roundOf(f.Type, expr, 0))
+}
+
+// checkDecimal returns the lines of the statement of checkDecimals that throws
+// an exception if a decimal of field f, read as expr, does not fit its declared
+// precision and scale. The values of lists and maps are checked in loops.
+func (gen *javaGenerator) checkDecimal(f *idl.Field, expr string) []string {
+	var lines []string
+	t, depth := f.Type, 0
+	for ; t.IsList() || t.IsMap(); t, depth = t.ValueType, depth+1 {
+		v := fmt.Sprintf("v%d", depth)
+		values := expr
+		if t.IsMap() {
+			values += ".values()"
+		}
+		lines = append(lines, fmt.Sprintf("%sif (%s != null) for (%s %s : %s)", strings.Repeat("\t", depth), expr, gen.formatType(t.ValueType), v, values))
+		expr = v
+	}
+	indent := strings.Repeat("\t", depth)
+	return append(lines,
+		fmt.Sprintf("%sif (%s != null && (%s.stripTrailingZeros().scale() > %d || %s.abs().compareTo(java.math.BigDecimal.TEN.pow(%d)) >= 0))",
+			indent, expr, expr, t.Scale, expr, t.IntegerDigits()),
+		fmt.Sprintf("%s\tthrow new IllegalArgumentException(%q + %s);", indent, gen.toCamelCase(f.Name)+" does not fit "+t.String()+": ", expr))
+}
+
+// abstractBase returns the name of the closest abstract struct that a struct
+// extends, or "" if there isn't one. Records implement the interface generated
+// for it, since they can't extend classes.
+func (gen *javaGenerator) abstractBase(s *idl.Struct) (string, error) {
+	bases, err := s.BaseClasses(gen.tplRootIdl)
+	if err != nil {
+		return "", err
+	}
+	for i := len(bases) - 1; i >= 0; i-- {
+		if bases[i].Abstract {
+			return bases[i].Name, nil
+		}
+	}
+	return "", nil
+}
@@ -23,6 +23,10 @@ var (
 		"float64":  "var",
 		"string":   "var",
 		"datetime": "var",
+		"date":     "var",
+		"time":     "var",
+		"duration": "var",
+		"uuid":     "var",
 		"decimal":  "var",
 		"char":     "var",
 		"binary":   "[]",
@@ -57,7 +61,7 @@ func (gen *jsGenerator) formatType(t *idl.Type) string {
 	if t.Name == "list" {
 		s = fmt.Sprintf(ms, gen.formatType(t.ValueType))
 	} else if t.Name == "map" {
-		s = fmt.Sprintf(ms, jsTypes[t.KeyType.Name], gen.formatType(t.ValueType))
+		s = fmt.Sprintf(ms, gen.formatKey(jsTypes, t.KeyType), gen.formatType(t.ValueType))
 	} else if t.IsPrimitive() && t.Name != "string" {
 		s = ms + "?"
 	} else if t.IsEnum(gen.tplRootIdl) {
@@ -79,7 +83,7 @@ func (gen *jsGenerator) fullTypeName(t *idl.Type) string {
 	if t.Name == "list" {
 		s = fmt.Sprintf(ms, gen.fullTypeName(t.ValueType))
 	} else if t.Name == "map" {
-		s = fmt.Sprintf(ms, jsTypes[t.KeyType.Name], gen.fullTypeName(t.ValueType))
+		s = fmt.Sprintf(ms, gen.formatKey(jsTypes, t.KeyType), gen.fullTypeName(t.ValueType))
 	} else {
 		ns := gen.tplRootIdl.NamespaceOf(ms, "js")
 		if ns != "" {
@@ -155,6 +159,10 @@ func (gen *jsGenerator) init(args *Arguments) error {
 				} else if v != "ns-flat" && v != "ns-nested" {
 					return fmt.Errorf("invalid output option: %s: valid options are 'ns-flat' and 'ns-nested'", v)
 				}
+			case "builders":
+				if err := gen.setBuilders(v); err != nil {
+					return err
+				}
 			default:
 				return fmt.Errorf("the %s option is not applicable to language js", k)
 			}
@@ -296,14 +304,9 @@ func (gen *jsGenerator) GenCodeInternal(pidl *idl.Idl, outFname string, template
 			return nil, fmt.Errorf("can't create output dir: %w", err)
 		}
 		os.Remove(outFname)
-		outFile, err := os.Create(outFname)
-		if err != nil {
-			return nil, fmt.Errorf("can't create output file: %w", err)
-		}
-		defer outFile.Close()
-		err = gen.templates.ExecuteTemplate(outFile, template, pidl)
+		err = gen.writeTemplate(outFname, template, pidl)
 		if err != nil {
-			return nil, fmt.Errorf("error executing template: %w", err)
+			return nil, err
 		}
 		return []string{outFname}, nil
 	} else {
@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// declarations returns the trimmed lines of src that match re.
+func declarations(src string, re *regexp.Regexp) map[string]bool {
+	decls := make(map[string]bool)
+	for _, line := range strings.Split(src, "\n") {
+		if re.MatchString(line) {
+			decls[strings.TrimSpace(line)] = true
+		}
+	}
+	return decls
+}
+
+var (
+	csharpMember = regexp.MustCompile(`^\s*(public|protected|private)\b[^;={]*\)\s*$`)
+	jsMember     = regexp.MustCompile(`^ns\['\w+'\]\.prototype\.\w+ = function\(`)
+)
+
+func TestModelHelpers(t *testing.T) {
+	for _, builders := range []bool{true, false} {
+		options := map[string]string{"builders": "false"}
+		if builders {
+			options["builders"] = "true"
+		}
+
+		// Go: the model compiles and copies and compares the embedded struct with its methods
+		g := generate(t, "go", Arguments{GenModel: true, Options: options}, "gomod/common.babel", "gomod/users.babel")
+		pkg := checkGo(t, g, "", nil)["company.com/users/common"]
+		if pkg == nil {
+			t.Fatalf("The users package was not generated: %v", g.Files)
+		}
+		expectMethod(t, pkg, "User.Clone", "func() *User")
+		expectMethod(t, pkg, "User.Equal", "func(other *User) bool")
+		expectCalls(t, pkg, "User.Clone", "Audit.Clone", true)
+		expectCalls(t, pkg, "User.Equal", "Audit.Equal", true)
+		if s := pkg.method("User.WithState"); (s == "func(value Status) *User") != builders {
+			t.Errorf("With builders=%v, User.WithState is %q", builders, s)
+		}
+
+		// Java
+		g = generate(t, "java", Arguments{GenModel: true, Options: options}, "gomod/users.babel")
+		src := g.file(t, "User.java")
+		expectJava(t, "User.java", src,
+			[]string{"public class User extends Audit implements Serializable"},
+			[]string{"public User copy()", "@Override"},
+			[]string{"public boolean equals(Object o)", "@Override"},
+			[]string{"public int hashCode()", "@Override"},
+		)
+		if _, ok := javaAnnotations(src, "public Builder createdAt(java.util.Date value)"); ok != builders {
+			t.Errorf("With builders=%v, User has the wrong builder", builders)
+		}
+
+		// C#
+		g = generate(t, "csharp", Arguments{GenModel: true, Options: options}, "gomod/users.babel")
+		cs := declarations(g.file(t, "usersModel.cs"), csharpMember)
+		for _, decl := range []string{"public new User Clone()", "protected override object CloneObject()", "public override bool Equals(object obj)", "public override int GetHashCode()"} {
+			if !cs[decl] {
+				t.Errorf("User does not declare %q: %v", decl, cs)
+			}
+		}
+		if cs["public new User WithCreatedAt(DateTime? value)"] != builders || cs["public User WithName(string value)"] != builders {
+			t.Errorf("With builders=%v, the C# model has the wrong builder methods: %v", builders, cs)
+		}
+
+		// JavaScript
+		g = generate(t, "js", Arguments{GenModel: true, Options: options}, "gomod/users.babel")
+		js := declarations(g.file(t, "model.js"), jsMember)
+		for _, decl := range []string{"ns['User'].prototype.clone = function()", "ns['User'].prototype.equals = function(other)"} {
+			if !js[decl] {
+				t.Errorf("User does not declare %q: %v", decl, js)
+			}
+		}
+		if js["ns['User'].prototype.withName = function(value)"] != builders {
+			t.Errorf("With builders=%v, the JavaScript model has the wrong builder methods: %v", builders, js)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package generator
+
+import (
+	"github.com/babelrpc/babel/idl"
+)
+
+// init registers the naming rules applied by the templates of each language so
+// that IDL validation can report names that collide after case conversion.
+func init() {
+	var tm templateManager
+
+	idl.RegisterNaming("go", &idl.Naming{
+		Field:     tm.toPascalCase,
+		Method:    tm.toPascalCase,
+		Parameter: tm.toPascalCase,
+		Value: func(owner, name string) string {
+			return owner + name
+		},
+		GlobalValues: true,
+		MethodTypes: func(service, method string) []string {
+			return []string{service + method + "Request", service + method + "Response"}
+		},
+	})
+
+	idl.RegisterNaming("csharp", &idl.Naming{
+		Field:  tm.toPascalCase,
+		Method: tm.toPascalCase,
+	})
+
+	idl.RegisterNaming("java", &idl.Naming{
+		Field:     tm.toCamelCase,
+		Method:    tm.toCamelCase,
+		Parameter: tm.toCamelCase,
+	})
+
+	idl.RegisterNaming("js", &idl.Naming{
+		Method:    tm.toCamelCase,
+		Parameter: tm.toCamelCase,
+	})
+}
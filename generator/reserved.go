@@ -0,0 +1,154 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/babelrpc/babel/idl"
+)
+
+var (
+	// Reserved words for each target language. Names that match one of these
+	// after case conversion are escaped in the generated code.
+	reservedWords = map[string][]string{
+		"csharp": {
+			"abstract", "as", "base", "bool", "break", "byte", "case", "catch", "char", "checked",
+			"class", "const", "continue", "decimal", "default", "delegate", "do", "double", "else",
+			"enum", "event", "explicit", "extern", "false", "finally", "fixed", "float", "for",
+			"foreach", "goto", "if", "implicit", "in", "int", "interface", "internal", "is", "lock",
+			"long", "namespace", "new", "null", "object", "operator", "out", "override", "params",
+			"private", "protected", "public", "readonly", "ref", "return", "sbyte", "sealed",
+			"short", "sizeof", "stackalloc", "static", "string", "struct", "switch", "this",
+			"throw", "true", "try", "typeof", "uint", "ulong", "unchecked", "unsafe", "ushort",
+			"using", "virtual", "void", "volatile", "while",
+		},
+		"java": {
+			"abstract", "assert", "boolean", "break", "byte", "case", "catch", "char", "class",
+			"const", "continue", "default", "do", "double", "else", "enum", "extends", "false",
+			"final", "finally", "float", "for", "goto", "if", "implements", "import", "instanceof",
+			"int", "interface", "long", "native", "new", "null", "package", "private", "protected",
+			"public", "return", "short", "static", "strictfp", "super", "switch", "synchronized",
+			"this", "throw", "throws", "transient", "true", "try", "void", "volatile", "while",
+		},
+		"go": {
+			"break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough",
+			"for", "func", "go", "goto", "if", "import", "interface", "map", "package", "range",
+			"return", "select", "struct", "switch", "type", "var",
+			// predeclared identifiers that break generated signatures when shadowed
+			"bool", "byte", "error", "false", "float32", "float64", "int8", "int16", "int32",
+			"int64", "nil", "rune", "string", "true",
+		},
+		"js": {
+			"await", "break", "case", "catch", "class", "const", "continue", "debugger", "default",
+			"delete", "do", "else", "enum", "export", "extends", "false", "finally", "for",
+			"function", "if", "implements", "import", "in", "instanceof", "interface", "let", "new",
+			"null", "package", "private", "protected", "public", "return", "static", "super",
+			"switch", "this", "throw", "true", "try", "typeof", "var", "void", "while", "with",
+			"yield",
+		},
+		"asp": {
+			"and", "byref", "byval", "call", "case", "class", "const", "dim", "do", "each", "else",
+			"elseif", "empty", "end", "eqv", "erase", "error", "exit", "explicit", "false", "for",
+			"function", "get", "goto", "if", "imp", "in", "is", "let", "loop", "me", "mod", "new",
+			"next", "not", "nothing", "null", "on", "option", "or", "preserve", "private",
+			"property", "public", "redim", "rem", "resume", "select", "set", "step", "sub", "then",
+			"to", "true", "until", "wend", "while", "with", "xor",
+		},
+	}
+
+	// Languages whose identifiers are not case sensitive.
+	caseInsensitiveLangs = map[string]bool{
+		"asp": true,
+	}
+
+	// reservedSets holds reservedWords indexed for lookup.
+	reservedSets = make(map[string]map[string]bool)
+)
+
+// init indexes the reserved words and registers them so that IDL validation
+// can warn about names that will be escaped.
+func init() {
+	for lang, words := range reservedWords {
+		set := make(map[string]bool)
+		for _, w := range words {
+			set[w] = true
+		}
+		reservedSets[lang] = set
+	}
+
+	// the identifiers that the templates of each language generate from a kind
+	// of name and pass through escapeIdent; names of other kinds are either not
+	// escaped or are emitted as strings
+	var tm templateManager
+	same := func(name string) string { return name }
+	escaped := map[string]map[string][]func(string) string{
+		"csharp": {
+			"Constant":          {same},
+			"Enumeration value": {same},
+			"Field":             {tm.toPascalCase},
+			"Method":            {tm.toPascalCase, same},
+			"Parameter":         {same, tm.toPascalCase},
+		},
+		"java": {
+			"Constant":          {same},
+			"Enumeration value": {same},
+			"Field":             {tm.toCamelCase},
+			"Method":            {tm.toCamelCase},
+			"Parameter":         {tm.toCamelCase},
+		},
+		"go": {
+			"Field":     {tm.toPascalCase},
+			"Method":    {tm.toPascalCase, same},
+			"Parameter": {same, tm.toPascalCase},
+		},
+		"js": {
+			"Method":    {tm.toCamelCase},
+			"Parameter": {tm.toCamelCase},
+		},
+		"asp": {
+			"Field":     {same},
+			"Method":    {same},
+			"Parameter": {same},
+		},
+	}
+	for lang, kinds := range escaped {
+		lang, kinds := lang, kinds
+		idl.RegisterReservedWords(lang, func(kind, name string) bool {
+			for _, f := range kinds[kind] {
+				if isReserved(lang, f(name)) {
+					return true
+				}
+			}
+			return false
+		})
+	}
+}
+
+// isReserved returns true if the identifier is a reserved word in the given language.
+func isReserved(lang, ident string) bool {
+	if caseInsensitiveLangs[lang] {
+		ident = strings.ToLower(ident)
+	}
+	return reservedSets[lang][ident]
+}
+
+// escapeIdent escapes an identifier that is a reserved word in the given language.
+// C# uses verbatim identifiers (@event) and the other languages append an underscore.
+func escapeIdent(lang, ident string) string {
+	if !isReserved(lang, ident) {
+		return ident
+	}
+	if lang == "csharp" {
+		return "@" + ident
+	}
+	return ident + "_"
+}
+
+// escapeRef escapes the member name of a Type.Member reference, such as an enum
+// value used as an initializer.
+func escapeRef(lang, ref string) string {
+	i := strings.LastIndex(ref, ".")
+	if i < 0 {
+		return escapeIdent(lang, ref)
+	}
+	return ref[:i+1] + escapeIdent(lang, ref[i+1:])
+}
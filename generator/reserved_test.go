@@ -0,0 +1,64 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/babelrpc/babel/idl"
+)
+
+// reservedWarnings generates code for reserved_words.babel and returns the
+// reserved word warnings of the validation along with the generated code.
+func reservedWarnings(t *testing.T, lang string, args Arguments) (map[string]bool, *generated) {
+	t.Helper()
+	warnings := make(map[string]bool)
+	defer func(w func(*idl.Error)) { idl.Warning = w }(idl.Warning)
+	idl.Warning = func(e *idl.Error) {
+		if e.Code == idl.CodeReservedWord {
+			msg := e.Message.Error()
+			warnings[msg[:strings.Index(msg, " is ")]] = true
+		}
+	}
+	g := generate(t, lang, args, "reserved_words.babel")
+	return warnings, g
+}
+
+func TestReservedWords(t *testing.T) {
+	// Go: only the parameter names and the method name used by the invoker are
+	// escaped; fields, values and the interface method are exported names
+	warnings, g := reservedWarnings(t, "go", Arguments{GenModel: true, GenClient: true, GenServer: true})
+	for name, warn := range map[string]bool{
+		`Parameter "Svc.func.range"`:       true,
+		`Method "Svc.func"`:                true,
+		`Parameter "Svc.func.class"`:       false,
+		`Field "Thing.type"`:               false,
+		`Enumeration value "Kind.default"`: false,
+	} {
+		if warnings[name] != warn {
+			t.Errorf("Go warning for %s: %v, expected %v", name, warnings[name], warn)
+		}
+	}
+	pkg := onlyPackage(t, checkGo(t, g, "example.com/gen", nil))
+	expectField(t, pkg, "Thing", "Type", "*int32", `json:"type,omitempty"`)
+	expectMethod(t, pkg, "ISvc.Func", "func(class *string, range_ *int32) error")
+	expectMethod(t, pkg, "Svc.func_", "func(req *SvcfuncRequest, rsp *SvcfuncResponse) error")
+
+	// Java: enumeration values are escaped, the camelCase field is not reserved
+	warnings, g = reservedWarnings(t, "java", Arguments{GenModel: true})
+	for name, warn := range map[string]bool{
+		`Enumeration value "Kind.default"`: true,
+		`Enumeration value "Kind.class"`:   true,
+		`Field "Thing.type"`:               false,
+	} {
+		if warnings[name] != warn {
+			t.Errorf("Java warning for %s: %v, expected %v", name, warnings[name], warn)
+		}
+	}
+	values := declarations(g.file(t, "Kind.java"), regexp.MustCompile(`^\s*\w+\(\d+\)[,;]$`))
+	for _, decl := range []string{"default_(1),", "class_(2);"} {
+		if !values[decl] {
+			t.Errorf("Kind does not declare %q: %v", decl, values)
+		}
+	}
+}
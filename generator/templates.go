@@ -1,11 +1,16 @@
 package generator
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"go/scanner"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"text/template"
 	"unicode"
@@ -40,11 +45,85 @@ func LocateTemplateDir() string {
 	return filepath.FromSlash(exePath) + "babeltemplates"
 }
 
+// postProcessor transforms the output of a template before it is written,
+// for example to format it. It returns an error if the output is not valid;
+// a *CodeError or scanner.ErrorList gives the line of the problem.
+type postProcessor func(src []byte) ([]byte, error)
+
 // templateManager holds data needed for template processing
 type templateManager struct {
-	tplRootIdl *idl.Idl
-	tplIndent  string
-	templates  *template.Template
+	tplRootIdl  *idl.Idl
+	tplIndent   string
+	templates   *template.Template
+	lang        string
+	postProcess postProcessor // applied to the output of every template, if set
+	builders    bool          // generate builder or With methods for models
+}
+
+// CodeError reports generated code that could not be post-processed, such as
+// Go code that does not parse.
+type CodeError struct {
+	Template string // name of the template
+	Source   string // IDL file the code was generated from
+	Line     int    // line of the generated code, or 0 if unknown
+	Text     string // text of that line
+	Err      error
+}
+
+// Implement the error interface
+func (e *CodeError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("template %s generated invalid code for %s at line %d: %s\n\t%s", e.Template, e.Source, e.Line, e.Err, e.Text)
+	}
+	return fmt.Sprintf("template %s generated invalid code for %s: %s", e.Template, e.Source, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *CodeError) Unwrap() error {
+	return e.Err
+}
+
+// writeTemplate executes a template into memory, post-processes the output and
+// writes it to the named file. Nothing is written if the template or the
+// post-processing fails.
+func (gen *templateManager) writeTemplate(fname, name string, data interface{}) error {
+	var buf bytes.Buffer
+	if err := gen.templates.ExecuteTemplate(&buf, name, data); err != nil {
+		return fmt.Errorf("error executing template: %w", err)
+	}
+	src := buf.Bytes()
+	if gen.postProcess != nil {
+		out, err := gen.postProcess(src)
+		if err != nil {
+			return gen.codeError(name, src, err)
+		}
+		src = out
+	}
+	if err := ioutil.WriteFile(fname, src, 0666); err != nil {
+		return fmt.Errorf("can't create output file: %w", err)
+	}
+	return nil
+}
+
+// codeError describes a post-processing error of the output of a template.
+func (gen *templateManager) codeError(name string, src []byte, err error) error {
+	e := &CodeError{Template: name, Err: err}
+	if gen.tplRootIdl != nil {
+		e.Source = gen.tplRootIdl.Filename
+	}
+	var list scanner.ErrorList
+	var ce *CodeError
+	if errors.As(err, &list) && len(list) > 0 {
+		e.Line = list[0].Pos.Line
+		e.Err = errors.New(list[0].Msg)
+	} else if errors.As(err, &ce) {
+		e.Line = ce.Line
+		e.Err = ce.Err
+	}
+	if lines := strings.Split(string(src), "\n"); e.Line > 0 && e.Line <= len(lines) {
+		e.Text = strings.TrimSpace(lines[e.Line-1])
+	}
+	return e
 }
 
 // resetTemplate reinitializes the IDL and indentation settings prior to
@@ -108,6 +187,66 @@ func (gen *templateManager) expandComments(s []string) []string {
 	return cmts
 }
 
+// baseHasDecimals returns true if a struct that the given struct extends,
+// directly or indirectly, has decimal fields with a declared precision.
+func (gen *templateManager) baseHasDecimals(s *idl.Struct) bool {
+	for s.Extends != "" {
+		s = gen.tplRootIdl.FindStruct(s.Extends)
+		if s == nil {
+			return false
+		}
+		if len(s.DecimalFields()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// allFields returns the fields of a struct, preceded by the fields of the
+// structs it extends, from the topmost base down.
+func (gen *templateManager) allFields(s *idl.Struct) ([]*idl.Field, error) {
+	bases, err := s.BaseClasses(gen.tplRootIdl)
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]*idl.Field, 0)
+	for _, b := range append(bases, s) {
+		fields = append(fields, b.Fields...)
+	}
+	return fields, nil
+}
+
+// declares returns true if the struct declares the field itself rather than
+// inheriting it.
+func (gen *templateManager) declares(s *idl.Struct, f *idl.Field) bool {
+	for _, sf := range s.Fields {
+		if sf == f {
+			return true
+		}
+	}
+	return false
+}
+
+// setBuilders sets the builders option, which languages that support it
+// accept in -options.
+func (gen *templateManager) setBuilders(v string) error {
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("invalid builders option: %s", v)
+	}
+	gen.builders = b
+	return nil
+}
+
+// formatKey returns the name of a map key type using the given mapping of IDL
+// types. Enumerations are not in the mapping and keep their own names.
+func (gen *templateManager) formatKey(typeMap map[string]string, t *idl.Type) string {
+	if ms, ok := typeMap[t.Name]; ok {
+		return ms
+	}
+	return t.Name
+}
+
 // getFuncMap returns a function map to use in the templates.
 func (gen *templateManager) getFuncMap(xtra template.FuncMap) template.FuncMap {
 	m := template.FuncMap{
@@ -117,11 +256,21 @@ func (gen *templateManager) getFuncMap(xtra template.FuncMap) template.FuncMap {
 		"getindent":    func() string { return gen.tplIndent },
 		"idl":          func() *idl.Idl { return gen.tplRootIdl },
 		"last":         func(x int, a interface{}) bool { return x == reflect.ValueOf(a).Len()-1 },
-		"toCamelCase":  func(name string) string { return gen.toCamelCase(name) },
-		"toPascalCase": func(name string) string { return gen.toPascalCase(name) },
+		"toCamelCase":  func(name string) string { return escapeIdent(gen.lang, gen.toCamelCase(name)) },
+		"toPascalCase": func(name string) string { return escapeIdent(gen.lang, gen.toPascalCase(name)) },
+		"rawCamelCase": func(name string) string { return gen.toCamelCase(name) },
+		"escape":       func(name string) string { return escapeIdent(gen.lang, name) },
 		"expandComments": func(s []string) []string {
 			return gen.expandComments(s)
 		},
+		"baseHasDecimals": func(s *idl.Struct) bool {
+			return gen.baseHasDecimals(s)
+		},
+		"allFields": func(s *idl.Struct) ([]*idl.Field, error) {
+			return gen.allFields(s)
+		},
+		"declares": func(s *idl.Struct, f *idl.Field) bool { return gen.declares(s, f) },
+		"builders": func() bool { return gen.builders },
 	}
 	for k, v := range xtra {
 		m[k] = v
@@ -132,6 +281,7 @@ func (gen *templateManager) getFuncMap(xtra template.FuncMap) template.FuncMap {
 // loadTemplates loads the templates for the given language that are found
 // in the templates directory.
 func (gen *templateManager) loadTempates(templatesDir, lang string, xtraFuncs template.FuncMap) error {
+	gen.lang = lang
 	fil, err := os.Open(filepath.Join(templatesDir, lang))
 	if err != nil {
 		return err
@@ -2,7 +2,6 @@ package generator
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 	"text/template"
@@ -22,6 +21,10 @@ var (
 		"float64":  "float64",
 		"string":   "string",
 		"datetime": "datetime",
+		"date":     "date",
+		"time":     "time",
+		"duration": "duration",
+		"uuid":     "uuid",
 		"decimal":  "decimal",
 		"char":     "char",
 		"binary":   "binary",
@@ -81,18 +84,28 @@ func (gen *testGenerator) init(args *Arguments) error {
 		},
 		"allStructs": func() []*idl.Struct {
 			s := make([]*idl.Struct, 0)
-			s = append(s, gen.tplRootIdl.Structs...)
-			for _, i := range gen.tplRootIdl.UniqueImports() {
-				s = append(s, i.Structs...)
-			}
+			idl.Inspect(gen.tplRootIdl, func(n interface{}) bool {
+				switch x := n.(type) {
+				case *idl.Idl:
+					return true
+				case *idl.Struct:
+					s = append(s, x)
+				}
+				return false
+			})
 			return s
 		},
 		"allEnums": func() []*idl.Enum {
 			s := make([]*idl.Enum, 0)
-			s = append(s, gen.tplRootIdl.Enums...)
-			for _, i := range gen.tplRootIdl.UniqueImports() {
-				s = append(s, i.Enums...)
-			}
+			idl.Inspect(gen.tplRootIdl, func(n interface{}) bool {
+				switch x := n.(type) {
+				case *idl.Idl:
+					return true
+				case *idl.Enum:
+					s = append(s, x)
+				}
+				return false
+			})
 			return s
 		},
 		// "escapeJSON": func(value string) string {
@@ -125,15 +138,9 @@ func (gen *testGenerator) GenService(pidl *idl.Idl) ([]string, error) {
 	for _, s := range pidl.Services {
 		fname := filepath.Join(gen.args.OutputDir, s.Name+".json")
 		outFnames = append(outFnames, fname)
-		outFile, err := os.Create(fname)
+		err := gen.writeTemplate(fname, "interface.test", s)
 		if err != nil {
-			return nil, fmt.Errorf("can't create test output file: %w", err)
-		}
-
-		defer outFile.Close()
-		err = gen.templates.ExecuteTemplate(outFile, "interface.test", s)
-		if err != nil {
-			return nil, fmt.Errorf("error executing test template: %w", err)
+			return nil, err
 		}
 	}
 
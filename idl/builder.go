@@ -0,0 +1,567 @@
+package idl
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Builder constructs an Idl in code and writes it as IDL source, for programs
+// that generate IDL from other descriptions of a service. For example:
+//
+//	b := idl.NewBuilder("users.babel", "company.com/Users")
+//	b.Enum("Status").Value("Active", 1).Value("Closed", 2)
+//	u := b.Struct("User").Comment("A registered user.")
+//	u.Field("string", "Name").Attr(idl.BabelScope, idl.WireNameAttribute, "login")
+//	u.Field("Status", "State").Default(idl.ValueRef("Status.Active"))
+//	b.Service("Users").Method("User", "Get").Param("string", "name")
+//	err := b.Write(os.Stdout)
+//
+// Each step is checked as it is made. The first error stops the build and is
+// returned by Err, Idl and Write; later steps are ignored.
+type Builder struct {
+	idl *Idl
+	err error
+}
+
+// Char is a character value for a Builder, since runes can't be told apart from int32 values.
+type Char rune
+
+// ValueRef is a value for a Builder that refers to an enum value or constant,
+// for example "Status.Active".
+type ValueRef string
+
+// NamedValue is a named attribute parameter for a Builder.
+type NamedValue struct {
+	Name  string
+	Value interface{}
+}
+
+// NewBuilder starts a new file with the given file name and default namespace,
+// which is written as "domain/path", for example "company.com/Users".
+func NewBuilder(filename, namespace string) *Builder {
+	b := &Builder{idl: new(Idl)}
+	b.idl.Init()
+	b.idl.Filename = filename
+	arr := strings.SplitN(namespace, "/", 2)
+	if len(arr) != 2 {
+		b.fail(fmt.Errorf("Default namespace must be written as domain/path: %s", namespace))
+	} else {
+		b.fail(b.idl.AddDefaultNamespace(arr[0], arr[1]))
+	}
+	return b
+}
+
+// fail records the first error of the build.
+func (b *Builder) fail(err error) {
+	if b.err == nil && err != nil {
+		b.err = err
+	}
+}
+
+// Err returns the first error of the build, if any.
+func (b *Builder) Err() error {
+	return b.err
+}
+
+// Comment adds documentation comments to the file.
+func (b *Builder) Comment(lines ...string) *Builder {
+	if b.err == nil {
+		b.idl.Comments = append(b.idl.Comments, b.comments(lines)...)
+	}
+	return b
+}
+
+// Import adds a parsed or built file as an import. Its file name is written
+// relative to the directory of this file.
+func (b *Builder) Import(i *Idl) *Builder {
+	if b.err == nil {
+		for _, itm := range b.idl.Imports {
+			if strings.ToLower(itm.Filename) == strings.ToLower(i.Filename) {
+				b.fail(fmt.Errorf("double import of \"%s\"", i.Filename))
+				return b
+			}
+		}
+		b.idl.symbols = nil
+		b.idl.Imports = append(b.idl.Imports, i)
+	}
+	return b
+}
+
+// Namespace sets the namespace for one language.
+func (b *Builder) Namespace(lang, ns string) *Builder {
+	if b.err == nil {
+		b.fail(b.idl.AddNamespace(lang, ns))
+	}
+	return b
+}
+
+// Attr adds an attribute to the file. The scope may be empty.
+func (b *Builder) Attr(scope, name string, params ...interface{}) *Builder {
+	if b.err == nil {
+		b.idl.Attributes = b.attr(b.idl.Attributes, scope, name, params)
+	}
+	return b
+}
+
+// Const adds a const block.
+func (b *Builder) Const(name string) *ConstBuilder {
+	cb := &ConstBuilder{b: b}
+	if b.ident("Const", name) {
+		var err error
+		cb.c, err = b.idl.AddConst(name)
+		b.fail(err)
+	}
+	return cb
+}
+
+// Enum adds an enum.
+func (b *Builder) Enum(name string) *EnumBuilder {
+	eb := &EnumBuilder{b: b}
+	if b.ident("Enum", name) {
+		var err error
+		eb.e, err = b.idl.AddEnum(name)
+		b.fail(err)
+	}
+	return eb
+}
+
+// Struct adds a struct.
+func (b *Builder) Struct(name string) *StructBuilder {
+	sb := &StructBuilder{b: b}
+	if b.ident("Struct", name) {
+		var err error
+		sb.s, err = b.idl.AddStruct(name)
+		b.fail(err)
+	}
+	return sb
+}
+
+// Service adds a service.
+func (b *Builder) Service(name string) *ServiceBuilder {
+	sb := &ServiceBuilder{b: b}
+	if b.ident("Service", name) {
+		var err error
+		sb.s, err = b.idl.AddService(name)
+		b.fail(err)
+	}
+	return sb
+}
+
+// Idl validates the file and returns it. Types may be used before they are
+// added, so references to undefined types are only reported here.
+func (b *Builder) Idl() (*Idl, error) {
+	if b.err == nil {
+		b.fail(b.idl.Validate("test"))
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.idl, nil
+}
+
+// Write validates the file and writes it as IDL source.
+func (b *Builder) Write(w io.Writer) error {
+	i, err := b.Idl()
+	if err != nil {
+		return err
+	}
+	return i.Print(w)
+}
+
+// ident checks that a name can be used as an identifier.
+func (b *Builder) ident(kind, name string) bool {
+	if b.err == nil && !isIdent(name) {
+		b.fail(fmt.Errorf("%s name \"%s\" is not a valid identifier", kind, name))
+	}
+	return b.err == nil
+}
+
+// comments converts lines of text to doc comments.
+func (b *Builder) comments(lines []string) []string {
+	cmts := make([]string, 0)
+	for _, l := range lines {
+		for _, s := range strings.Split(strings.Replace(l, "\r", "", -1), "\n") {
+			if strings.Contains(s, "*/") {
+				b.fail(fmt.Errorf("Comment cannot contain \"*/\": %s", s))
+			}
+			cmts = append(cmts, " "+s)
+		}
+	}
+	return cmts
+}
+
+// attr appends an attribute to a list.
+func (b *Builder) attr(attrs []*Attribute, scope, name string, params []interface{}) []*Attribute {
+	if scope != "" && !isIdent(scope) {
+		b.fail(fmt.Errorf("Attribute scope \"%s\" is not a valid identifier", scope))
+	}
+	for _, s := range strings.Split(name, ".") {
+		if !isIdent(s) {
+			b.fail(fmt.Errorf("Attribute name \"%s\" is not valid", name))
+		}
+	}
+	for _, a := range attrs {
+		if strings.ToLower(a.Name) == strings.ToLower(name) && a.Scope == "" && scope == "" {
+			b.fail(fmt.Errorf("Attribute used twice: %s", name))
+		}
+	}
+	a := &Attribute{Name: name, Scope: scope, Parameters: make([]*Pair, 0)}
+	for _, p := range params {
+		pname := ""
+		if nv, ok := p.(NamedValue); ok {
+			if !isIdent(nv.Name) {
+				b.fail(fmt.Errorf("Attribute parameter name \"%s\" is not a valid identifier", nv.Name))
+			}
+			pname, p = nv.Name, nv.Value
+		}
+		v, err := NewPair(pname, p)
+		b.fail(err)
+		a.Parameters = append(a.Parameters, v)
+	}
+	return append(attrs, a)
+}
+
+// field fills in a new field or parameter.
+func (b *Builder) field(f *Field, err error) *FieldBuilder {
+	b.fail(err)
+	return &FieldBuilder{b: b, f: f}
+}
+
+// NewPair converts a Go value to an IDL value. Integers, floats, strings,
+// bools, Char and ValueRef are supported.
+func NewPair(name string, v interface{}) (*Pair, error) {
+	p := &Pair{Name: name}
+	switch x := v.(type) {
+	case int:
+		p.Value, p.DataType = int64(x), "int"
+	case int8:
+		p.Value, p.DataType = int64(x), "int"
+	case int16:
+		p.Value, p.DataType = int64(x), "int"
+	case int32:
+		p.Value, p.DataType = int64(x), "int"
+	case int64:
+		p.Value, p.DataType = x, "int"
+	case uint8:
+		p.Value, p.DataType = int64(x), "int"
+	case uint16:
+		p.Value, p.DataType = int64(x), "int"
+	case uint32:
+		p.Value, p.DataType = int64(x), "int"
+	case float32:
+		p.Value, p.DataType = float64(x), "float"
+	case float64:
+		p.Value, p.DataType = x, "float"
+	case string:
+		p.Value, p.DataType = x, "string"
+	case bool:
+		p.Value, p.DataType = x, "bool"
+	case Char:
+		p.Value, p.DataType = rune(x), "char"
+	case ValueRef:
+		arr := strings.Split(string(x), ".")
+		if len(arr) != 2 || !isIdent(arr[0]) || !isIdent(arr[1]) {
+			return nil, fmt.Errorf("Reference \"%s\" must be written as Name.Value", x)
+		}
+		p.Value, p.DataType = string(x), "#ref"
+	default:
+		return nil, fmt.Errorf("Unsupported value %v of type %T", v, v)
+	}
+	return p, nil
+}
+
+// ParseType parses a type written as it is in IDL, for example
+// "map<string, list<User>>" or "decimal(10,2)".
+func ParseType(s string) (*Type, error) {
+	s = strings.TrimSpace(s)
+	// container returns the type arguments of a list or map
+	container := func(name string) (string, bool) {
+		rest := strings.TrimSpace(strings.TrimPrefix(s, name))
+		if strings.HasPrefix(s, name) && strings.HasPrefix(rest, "<") && strings.HasSuffix(rest, ">") {
+			return rest[1 : len(rest)-1], true
+		}
+		return "", false
+	}
+	if args, ok := container("list"); ok {
+		v, err := ParseType(args)
+		if err != nil {
+			return nil, err
+		}
+		return &Type{Name: "list", ValueType: v}, nil
+	}
+	if args, ok := container("map"); ok {
+		depth := 0
+		for i, c := range args {
+			switch c {
+			case '<':
+				depth++
+			case '>':
+				depth--
+			case ',':
+				if depth == 0 {
+					k, err := ParseType(args[:i])
+					if err != nil {
+						return nil, err
+					}
+					if k.IsList() || k.IsMap() {
+						return nil, fmt.Errorf("Map key must be a primitive type or an enum: %s", s)
+					}
+					v, err := ParseType(args[i+1:])
+					if err != nil {
+						return nil, err
+					}
+					return &Type{Name: "map", KeyType: k, ValueType: v}, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("Map must have a key and value type: %s", s)
+	}
+	if i := strings.Index(s, "("); i > 0 && strings.HasSuffix(s, ")") {
+		t := &Type{Name: strings.TrimSpace(s[:i])}
+		var nums []int64
+		for _, n := range strings.Split(s[i+1:len(s)-1], ",") {
+			v, err := strconv.ParseInt(strings.TrimSpace(n), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid precision in type %s", s)
+			}
+			nums = append(nums, v)
+		}
+		if len(nums) == 1 {
+			nums = append(nums, 0)
+		}
+		if len(nums) != 2 {
+			return nil, fmt.Errorf("Invalid precision in type %s", s)
+		}
+		if err := t.SetPrecision(nums[0], nums[1]); err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+	if contains(IdlTypes, s) || isIdent(s) {
+		return &Type{Name: s}, nil
+	}
+	return nil, fmt.Errorf("Invalid type: %s", s)
+}
+
+// ConstBuilder adds values to a const block.
+type ConstBuilder struct {
+	b *Builder
+	c *Const
+}
+
+// Comment adds documentation comments to the const block.
+func (cb *ConstBuilder) Comment(lines ...string) *ConstBuilder {
+	if cb.b.err == nil {
+		cb.c.Comments = append(cb.c.Comments, cb.b.comments(lines)...)
+	}
+	return cb
+}
+
+// Value adds a constant. The value may be an integer, float, string, bool or Char.
+func (cb *ConstBuilder) Value(name string, v interface{}) *ConstBuilder {
+	if cb.b.ident("Constant", name) {
+		p, err := NewPair(name, v)
+		if err == nil && p.DataType == "#ref" {
+			err = fmt.Errorf("Constant %s.%s cannot refer to another value", cb.c.Name, name)
+		}
+		if err == nil {
+			err = cb.c.Add(name, p.Value, p.DataType)
+		}
+		cb.b.fail(err)
+	}
+	return cb
+}
+
+// EnumBuilder adds values to an enum.
+type EnumBuilder struct {
+	b *Builder
+	e *Enum
+}
+
+// Comment adds documentation comments to the enum.
+func (eb *EnumBuilder) Comment(lines ...string) *EnumBuilder {
+	if eb.b.err == nil {
+		eb.e.Comments = append(eb.e.Comments, eb.b.comments(lines)...)
+	}
+	return eb
+}
+
+// Value adds an enum value.
+func (eb *EnumBuilder) Value(name string, v int64) *EnumBuilder {
+	if eb.b.ident("Enum value", name) {
+		eb.b.fail(eb.e.Add(name, v))
+	}
+	return eb
+}
+
+// StructBuilder adds fields to a struct.
+type StructBuilder struct {
+	b *Builder
+	s *Struct
+}
+
+// Comment adds documentation comments to the struct.
+func (sb *StructBuilder) Comment(lines ...string) *StructBuilder {
+	if sb.b.err == nil {
+		sb.s.Comments = append(sb.s.Comments, sb.b.comments(lines)...)
+	}
+	return sb
+}
+
+// Attr adds an attribute to the struct. The scope may be empty.
+func (sb *StructBuilder) Attr(scope, name string, params ...interface{}) *StructBuilder {
+	if sb.b.err == nil {
+		sb.s.Attributes = sb.b.attr(sb.s.Attributes, scope, name, params)
+	}
+	return sb
+}
+
+// Abstract marks the struct as abstract.
+func (sb *StructBuilder) Abstract() *StructBuilder {
+	if sb.b.err == nil {
+		sb.s.Abstract = true
+	}
+	return sb
+}
+
+// Extends sets the struct that this one extends.
+func (sb *StructBuilder) Extends(base string) *StructBuilder {
+	if sb.b.ident("Struct", base) {
+		sb.s.Extends = base
+	}
+	return sb
+}
+
+// Field adds a field with a type written as it is in IDL.
+func (sb *StructBuilder) Field(typ, name string) *FieldBuilder {
+	if !sb.b.ident("Field", name) {
+		return &FieldBuilder{b: sb.b}
+	}
+	t, err := ParseType(typ)
+	if err != nil {
+		return sb.b.field(nil, err)
+	}
+	return sb.b.field(sb.s.AddField(t, name))
+}
+
+// ServiceBuilder adds methods to a service.
+type ServiceBuilder struct {
+	b *Builder
+	s *Service
+}
+
+// Comment adds documentation comments to the service.
+func (sb *ServiceBuilder) Comment(lines ...string) *ServiceBuilder {
+	if sb.b.err == nil {
+		sb.s.Comments = append(sb.s.Comments, sb.b.comments(lines)...)
+	}
+	return sb
+}
+
+// Attr adds an attribute to the service. The scope may be empty.
+func (sb *ServiceBuilder) Attr(scope, name string, params ...interface{}) *ServiceBuilder {
+	if sb.b.err == nil {
+		sb.s.Attributes = sb.b.attr(sb.s.Attributes, scope, name, params)
+	}
+	return sb
+}
+
+// Method adds a method. The return type is written as it is in IDL, or as
+// "void" if the method doesn't return anything.
+func (sb *ServiceBuilder) Method(returns, name string) *MethodBuilder {
+	mb := &MethodBuilder{b: sb.b}
+	if !sb.b.ident("Method", name) {
+		return mb
+	}
+	t := &Type{Name: "void"}
+	if strings.TrimSpace(returns) != "void" {
+		var err error
+		if t, err = ParseType(returns); err != nil {
+			sb.b.fail(err)
+			return mb
+		}
+	}
+	var err error
+	mb.m, err = sb.s.AddMethod(t, name)
+	sb.b.fail(err)
+	return mb
+}
+
+// MethodBuilder adds parameters to a method.
+type MethodBuilder struct {
+	b *Builder
+	m *Method
+}
+
+// Comment adds documentation comments to the method.
+func (mb *MethodBuilder) Comment(lines ...string) *MethodBuilder {
+	if mb.b.err == nil {
+		mb.m.Comments = append(mb.m.Comments, mb.b.comments(lines)...)
+	}
+	return mb
+}
+
+// Attr adds an attribute to the method. The scope may be empty.
+func (mb *MethodBuilder) Attr(scope, name string, params ...interface{}) *MethodBuilder {
+	if mb.b.err == nil {
+		mb.m.Attributes = mb.b.attr(mb.m.Attributes, scope, name, params)
+	}
+	return mb
+}
+
+// Param adds a parameter with a type written as it is in IDL. It returns the
+// method so that parameters can be chained; use Parameter to add comments,
+// attributes or a default value.
+func (mb *MethodBuilder) Param(typ, name string) *MethodBuilder {
+	mb.Parameter(typ, name)
+	return mb
+}
+
+// Parameter adds a parameter with a type written as it is in IDL.
+func (mb *MethodBuilder) Parameter(typ, name string) *FieldBuilder {
+	if !mb.b.ident("Parameter", name) {
+		return &FieldBuilder{b: mb.b}
+	}
+	t, err := ParseType(typ)
+	if err != nil {
+		return mb.b.field(nil, err)
+	}
+	return mb.b.field(mb.m.AddParameter(t, name))
+}
+
+// FieldBuilder sets the details of a field or parameter.
+type FieldBuilder struct {
+	b *Builder
+	f *Field
+}
+
+// Comment adds documentation comments to the field.
+func (fb *FieldBuilder) Comment(lines ...string) *FieldBuilder {
+	if fb.b.err == nil {
+		fb.f.Comments = append(fb.f.Comments, fb.b.comments(lines)...)
+	}
+	return fb
+}
+
+// Attr adds an attribute to the field. The scope may be empty.
+func (fb *FieldBuilder) Attr(scope, name string, params ...interface{}) *FieldBuilder {
+	if fb.b.err == nil {
+		fb.f.Attributes = fb.b.attr(fb.f.Attributes, scope, name, params)
+	}
+	return fb
+}
+
+// Default sets the initial value of the field. References to enum values and
+// constants are written as ValueRef and checked when the file is validated.
+func (fb *FieldBuilder) Default(v interface{}) *FieldBuilder {
+	if fb.b.err == nil {
+		p, err := NewPair("", v)
+		if err == nil {
+			err = fb.f.SetInitializer(p.Value, p.DataType)
+		}
+		fb.b.fail(err)
+	}
+	return fb
+}
@@ -0,0 +1,201 @@
+package idl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Bundle merges the given files and everything they import into a single Idl
+// with no imports. The first file supplies the comments, attributes and
+// namespaces of the result. Imported files shared by several roots are only
+// included once.
+//
+// Consts, enums and structs whose names collide with one already in the bundle
+// are renamed by prefixing the last part of their file's namespace, and the
+// references to them are updated. Services are never renamed, since that would
+// change the URLs of their methods; two services with the same name are an
+// error. Wire names are written out as WireName attributes, since
+// the naming strategies of the individual files do not apply to the bundle.
+//
+// The files must have been validated. Their definitions are moved into the
+// result and modified, so the files should not be used afterwards.
+func Bundle(files ...*Idl) (*Idl, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("No files to bundle")
+	}
+
+	// imports come before the files that use them
+	var all []*Idl
+	seen := make(map[string]bool)
+	add := func(i *Idl) {
+		key := strings.ToLower(i.Filename)
+		if !seen[key] {
+			seen[key] = true
+			all = append(all, i)
+		}
+	}
+	for _, f := range files {
+		imps := f.UniqueImports()
+		for j := len(imps) - 1; j >= 0; j-- {
+			add(imps[j])
+		}
+		add(f)
+	}
+
+	// services keep their names, since they are part of the URLs of their methods
+	defined := make(map[string]map[string]string)
+	taken := make(map[string]bool)
+	owner := make(map[string]string)
+	for _, i := range all {
+		names := make(map[string]string)
+		defined[strings.ToLower(i.Filename)] = names
+		for _, s := range i.Services {
+			key := strings.ToLower(s.Name)
+			if f, ok := owner[key]; ok {
+				return nil, fmt.Errorf("Service %s is defined in both %s and %s", s.Name, f, i.Filename)
+			}
+			owner[key] = i.Filename
+			names[key] = s.Name
+			taken[key] = true
+		}
+	}
+
+	// pick a unique name for every other definition
+	for _, i := range all {
+		names := defined[strings.ToLower(i.Filename)]
+		for _, name := range i.definitionNames() {
+			names[strings.ToLower(name)] = uniqueName(taken, i, name)
+			taken[strings.ToLower(names[strings.ToLower(name)])] = true
+		}
+	}
+
+	b := new(Idl)
+	b.Init()
+	b.Filename = files[0].Filename
+	b.Comments = files[0].Comments
+	for k, v := range files[0].Namespaces {
+		b.Namespaces[k] = v
+	}
+	for _, a := range files[0].Attributes {
+		if a.Scope != BabelScope || a.Name != NamingAttribute {
+			b.Attributes = append(b.Attributes, a)
+		}
+	}
+
+	for _, i := range all {
+		// references resolve to this file first, then to its imports
+		scope := append([]*Idl{i}, i.UniqueImports()...)
+		ref := func(name string) string {
+			for _, s := range scope {
+				if n, ok := defined[strings.ToLower(s.Filename)][strings.ToLower(name)]; ok {
+					return n
+				}
+			}
+			return name
+		}
+		names := defined[strings.ToLower(i.Filename)]
+		for _, c := range i.Consts {
+			c.Name = names[strings.ToLower(c.Name)]
+			b.Consts = append(b.Consts, c)
+		}
+		for _, e := range i.Enums {
+			e.Name = names[strings.ToLower(e.Name)]
+			b.Enums = append(b.Enums, e)
+		}
+		for _, s := range i.Structs {
+			s.Name = names[strings.ToLower(s.Name)]
+			if s.Extends != "" {
+				s.Extends = ref(s.Extends)
+			}
+			for _, f := range s.Fields {
+				bundleField(f, ref)
+			}
+			b.Structs = append(b.Structs, s)
+		}
+		for _, s := range i.Services {
+			s.Name = names[strings.ToLower(s.Name)]
+			for _, m := range s.Methods {
+				renameType(m.Returns, ref)
+				for _, p := range m.Parameters {
+					bundleField(p, ref)
+				}
+			}
+			b.Services = append(b.Services, s)
+		}
+		// the definitions of the file were renamed in place
+		i.symbols = nil
+	}
+	return b, nil
+}
+
+// definitionNames returns the names of the consts, enums and structs defined in
+// this file.
+func (idl *Idl) definitionNames() []string {
+	names := make([]string, 0)
+	for _, c := range idl.Consts {
+		names = append(names, c.Name)
+	}
+	for _, e := range idl.Enums {
+		names = append(names, e.Name)
+	}
+	for _, s := range idl.Structs {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// uniqueName returns the name to use for a definition in a bundle. A name that
+// is taken gets the last part of its file's namespace as a prefix, and a number
+// if that is not enough.
+func uniqueName(taken map[string]bool, idl *Idl, name string) string {
+	if !taken[strings.ToLower(name)] {
+		return name
+	}
+	ns := idl.Namespaces["#default"]
+	prefix := ns[strings.LastIndex(ns, "/")+1:]
+	if prefix != "" {
+		prefix = strings.ToUpper(prefix[0:1]) + prefix[1:]
+	}
+	n := prefix + name
+	for j := 2; taken[strings.ToLower(n)]; j++ {
+		n = fmt.Sprintf("%s%s%d", prefix, name, j)
+	}
+	return n
+}
+
+// bundleField updates the references made by a field or parameter and records
+// its wire name as an attribute.
+func bundleField(f *Field, ref func(string) string) {
+	renameType(f.Type, ref)
+	if f.Initializer != nil && f.Initializer.DataType == "#ref" {
+		arr := strings.SplitN(f.Initializer.Value.(string), ".", 2)
+		arr[0] = ref(arr[0])
+		f.Initializer.Value = strings.Join(arr, ".")
+	}
+	attrs := make([]*Attribute, 0)
+	for _, a := range f.Attributes {
+		if a.Scope != BabelScope || a.Name != WireNameAttribute {
+			attrs = append(attrs, a)
+		}
+	}
+	if f.Wire != "" {
+		attrs = append(attrs, &Attribute{
+			Name:       WireNameAttribute,
+			Scope:      BabelScope,
+			Parameters: []*Pair{&Pair{Value: f.Wire, DataType: "string"}},
+		})
+	}
+	f.Attributes = attrs
+}
+
+// renameType updates the names of the user-defined types used by a type.
+func renameType(t *Type, ref func(string) string) {
+	if t == nil {
+		return
+	}
+	if !t.IsPrimitive() && !t.IsList() && !t.IsMap() && !t.IsVoid() {
+		t.Name = ref(t.Name)
+	}
+	renameType(t.KeyType, ref)
+	renameType(t.ValueType, ref)
+}
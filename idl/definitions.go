@@ -11,12 +11,20 @@ import (
 	"strings"
 )
 
+// Pos is the location of a definition in its IDL file. Line and Column start at
+// 1; the zero Pos means the location is not known.
+type Pos struct {
+	Line   int
+	Column int
+}
+
 // Pair is a name/value pair that includes an optional format string for Sprintf.
 // Pairs are used to represent constants and other values in the IDL.
 type Pair struct {
 	Name     string
 	Value    interface{}
 	DataType string
+	Pos      Pos `json:"-"`
 }
 
 // Const is a collection of constant value defintions. A Const block has a name
@@ -25,6 +33,7 @@ type Const struct {
 	Comments []string
 	Name     string
 	Values   []*Pair
+	Pos      Pos `json:"-"`
 }
 
 // Init initializes the Const for use.
@@ -62,6 +71,7 @@ type Enum struct {
 	Comments []string
 	Name     string
 	Values   []*Pair
+	Pos      Pos `json:"-"`
 }
 
 // Init initializes the Enum for use.
@@ -108,6 +118,8 @@ type Field struct {
 	Type        *Type
 	Name        string
 	Initializer *Pair
+	Wire        string `json:",omitempty"` // name in serialized data if different from Name
+	Pos         Pos    `json:"-"`
 }
 
 // Init initializes the Field for use.
@@ -244,6 +256,8 @@ type Struct struct {
 	Extends    string
 	Fields     []*Field
 	Abstract   bool
+	Pos        Pos `json:"-"`
+	ExtendsPos Pos `json:"-"` // location of the name of the struct it extends
 }
 
 // Init initializes the Struct for use.
@@ -279,6 +293,18 @@ func (s *Struct) HasRequiredFields() bool {
 	return hasRequired
 }
 
+// DecimalFields gets the fields of this struct that hold decimals that declare a
+// precision, either directly or as the values of lists and maps
+func (s *Struct) DecimalFields() []*Field {
+	flds := make([]*Field, 0)
+	for _, fld := range s.Fields {
+		if fld.Type.HoldsPrecision() {
+			flds = append(flds, fld)
+		}
+	}
+	return flds
+}
+
 // RequiredFields gets all required fields for this struct
 func (s *Struct) RequiredFields() []*Field {
 	flds := make([]*Field, 0)
@@ -339,6 +365,7 @@ type Method struct {
 	Returns    *Type
 	Name       string
 	Parameters []*Field
+	Pos        Pos `json:"-"`
 }
 
 // Init initializes a Method for use.
@@ -375,6 +402,7 @@ type Service struct {
 	Attributes []*Attribute
 	Name       string
 	Methods    []*Method
+	Pos        Pos `json:"-"`
 }
 
 // Init initializes the Service for use.
@@ -0,0 +1,381 @@
+package idl
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Codes used for the errors and warnings reported by the babel tools. The
+// numbers are stable: a code is never reused for a different problem, so build
+// tools and editors can match on them. The hundreds digit gives the stage that
+// found the problem.
+const (
+	// Command line (1xx)
+	CodeUsage     = 101 // invalid or missing command-line arguments
+	CodeNoFiles   = 102 // a file pattern is invalid or matches no files
+	CodeOutput    = 103 // an output file or folder cannot be created or written
+	CodeOptions   = 104 // invalid generator options
+	CodeGenerator = 105 // the code generator cannot be created for the language
+
+	// Parsing (2xx)
+	CodeSyntax     = 201 // the file does not follow the IDL grammar
+	CodeOpen       = 202 // the file or one of its imports cannot be read
+	CodeDefinition = 203 // a definition is invalid or already defined in the file
+
+	// Validation (3xx)
+	CodeReservedWord  = 301 // identifier must be escaped in the target language
+	CodeNameCollision = 302 // two names map to the same identifier in the target language
+	CodeBadAttribute  = 303 // a standard attribute has invalid parameters
+	CodeScopeMismatch = 304 // a definition refers to a struct that is not in the enabled scopes
+	CodeRedefined     = 305 // a name is defined in more than one file
+	CodeBadStruct     = 306 // a struct, its base classes or its field initializers are invalid
+	CodeUndefinedType = 307 // a type is not defined or cannot be used where it is
+	CodeBadService    = 308 // a service or method is invalid
+	CodeNamespace     = 309 // a namespace is missing or invalid
+
+	// Generation (4xx)
+	CodeGenerate  = 401 // code generation failed
+	CodeOverwrite = 402 // a file was generated more than once
+	CodeRest      = 403 // @rest attributes cannot be mapped to a REST operation
+	CodeSwagger   = 404 // the generated Swagger definition is not valid
+)
+
+// CodeDescriptions describes each code, for tools that list the rules they check.
+var CodeDescriptions = map[int]string{
+	CodeUsage:         "Invalid or missing command-line arguments",
+	CodeNoFiles:       "A file pattern is invalid or matches no files",
+	CodeOutput:        "An output file or folder cannot be created or written",
+	CodeOptions:       "Invalid generator options",
+	CodeGenerator:     "The code generator cannot be created for the language",
+	CodeSyntax:        "The file does not follow the IDL grammar",
+	CodeOpen:          "The file or one of its imports cannot be read",
+	CodeDefinition:    "A definition is invalid or already defined in the file",
+	CodeReservedWord:  "An identifier must be escaped in the target language",
+	CodeNameCollision: "Two names map to the same identifier in the target language",
+	CodeBadAttribute:  "A standard attribute has invalid parameters",
+	CodeScopeMismatch: "A definition refers to a struct that is not in the enabled scopes",
+	CodeRedefined:     "A name is defined in more than one file",
+	CodeBadStruct:     "A struct, its base classes or its field initializers are invalid",
+	CodeUndefinedType: "A type is not defined or cannot be used where it is",
+	CodeBadService:    "A service or method is invalid",
+	CodeNamespace:     "A namespace is missing or invalid",
+	CodeGenerate:      "Code generation failed",
+	CodeOverwrite:     "A file was generated more than once",
+	CodeRest:          "@rest attributes cannot be mapped to a REST operation",
+	CodeSwagger:       "The generated Swagger definition is not valid",
+}
+
+// DiagnosticFormats are the formats Diagnostics can write. "msbuild" is the
+// format of Error, one line per record; "json" is a list of records and
+// "sarif" is a SARIF 2.1.0 log.
+var DiagnosticFormats = []string{"msbuild", "json", "sarif"}
+
+// DiagnosticsUsage is the help text of the -diagnostics flag of the tools.
+var DiagnosticsUsage = "Write errors and warnings to standard error as " + strings.Join(DiagnosticFormats, "|")
+
+// ErrorList is a list of errors reported together, such as all of the syntax
+// errors in a file.
+type ErrorList []*Error
+
+// Implement the error interface
+func (l ErrorList) Error() string {
+	s := make([]string, len(l))
+	for i, e := range l {
+		s[i] = e.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+// ErrorCode returns the code of the Error that err is or wraps, or 0 if it
+// has none.
+func ErrorCode(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return 0
+}
+
+// Diagnostics collects the errors and warnings of a tool so they can be
+// written together in one of the DiagnosticFormats.
+type Diagnostics struct {
+	Tool    string // name of the tool, used as the source of records without a file
+	Format  string
+	Records ErrorList
+
+	w io.Writer // where Flush writes the records, and messages are written without a format
+}
+
+// NewDiagnostics creates an empty collection for the named tool, or returns an
+// error if the format is not one of DiagnosticFormats.
+func NewDiagnostics(tool, format string) (*Diagnostics, error) {
+	if !contains(DiagnosticFormats, format) {
+		return nil, fmt.Errorf("Diagnostics format must be one of %s", strings.Join(DiagnosticFormats, ", "))
+	}
+	return &Diagnostics{Tool: tool, Format: format, Records: make(ErrorList, 0)}, nil
+}
+
+// StartDiagnostics returns the Diagnostics of a command-line tool for the
+// format given with its -diagnostics flag. With a format, errors and warnings,
+// including the warnings of Validate, are collected and written to w by Flush.
+// Without one, the tool's messages are written to w as text when they are
+// reported.
+func StartDiagnostics(tool, format string, w io.Writer) (*Diagnostics, error) {
+	if format == "" {
+		return TextDiagnostics(tool, w), nil
+	}
+	d, err := NewDiagnostics(tool, format)
+	if err != nil {
+		return nil, err
+	}
+	d.w = w
+	Warning = d.Warning
+	return d, nil
+}
+
+// TextDiagnostics returns the Diagnostics of a command-line tool that writes
+// its messages to w as text when they are reported.
+func TextDiagnostics(tool string, w io.Writer) *Diagnostics {
+	return &Diagnostics{Tool: tool, Records: make(ErrorList, 0), w: w}
+}
+
+// Errorf reports an error of a tool: it is recorded when there is a format,
+// and otherwise written as text using format and args.
+func (d *Diagnostics) Errorf(err error, category string, code int, format string, args ...interface{}) {
+	if d.Format == "" {
+		fmt.Fprintf(d.w, format, args...)
+	} else {
+		d.Add(err, category, code)
+	}
+}
+
+// Warnf reports a warning the same way Errorf reports an error.
+func (d *Diagnostics) Warnf(err error, category string, code int, format string, args ...interface{}) {
+	if d.Format == "" {
+		fmt.Fprintf(d.w, format, args...)
+	} else {
+		d.Warn(err, category, code)
+	}
+}
+
+// Notef writes an informational message when there is no format. Notes are
+// left out of diagnostics.
+func (d *Diagnostics) Notef(format string, args ...interface{}) {
+	if d.Format == "" {
+		fmt.Fprintf(d.w, format, args...)
+	}
+}
+
+// Fatalf reports an error with Errorf and exits with the given status.
+func (d *Diagnostics) Fatalf(status int, err error, category string, code int, format string, args ...interface{}) {
+	d.Errorf(err, category, code, format, args...)
+	d.Exit(status)
+}
+
+// Flush writes the records collected so far and clears them, so a tool that
+// keeps running can flush again later. Without a format it only writes the
+// records that were added directly, as text.
+func (d *Diagnostics) Flush() error {
+	if d.Format == "" && len(d.Records) == 0 {
+		return nil
+	}
+	err := d.Write(d.w)
+	d.Records = d.Records[:0]
+	return err
+}
+
+// Exit flushes the records and exits with the given status.
+func (d *Diagnostics) Exit(status int) {
+	d.Flush()
+	os.Exit(status)
+}
+
+// Add records an error. An *Error or ErrorList, even when wrapped, keeps its
+// own details; other errors are recorded with the given category and code.
+func (d *Diagnostics) Add(err error, category string, code int) {
+	d.add(err, category, code, false)
+}
+
+// Warn records a warning the same way Add records an error. Errors that are
+// passed as warnings are recorded as warnings.
+func (d *Diagnostics) Warn(err error, category string, code int) {
+	d.add(err, category, code, true)
+}
+
+// Warning records a warning from Validate. Assign it to the package Warning
+// variable to collect them.
+func (d *Diagnostics) Warning(e *Error) {
+	d.Records = append(d.Records, e)
+}
+
+// add records an error or warning.
+func (d *Diagnostics) add(err error, category string, code int, isWarning bool) {
+	var list ErrorList
+	var e *Error
+	if errors.As(err, &e) {
+		list = ErrorList{e}
+	} else if !errors.As(err, &list) {
+		if err == nil {
+			return
+		}
+		list = ErrorList{&Error{Category: category, Code: code, Message: err}}
+	}
+	for _, e := range list {
+		r := *e
+		r.IsWarning = r.IsWarning || isWarning
+		d.Records = append(d.Records, &r)
+	}
+}
+
+// HasErrors returns true if any of the records is an error.
+func (d *Diagnostics) HasErrors() bool {
+	for _, e := range d.Records {
+		if !e.IsWarning {
+			return true
+		}
+	}
+	return false
+}
+
+// diagnostic is the JSON form of a record.
+type diagnostic struct {
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"`
+	Code     int    `json:"code"`
+	Category string `json:"category,omitempty"`
+	Message  string `json:"message"`
+}
+
+// record converts an error to its JSON form.
+func (d *Diagnostics) record(e *Error) diagnostic {
+	r := diagnostic{File: e.Source, Line: e.Line, Column: e.Column, Severity: "error", Code: e.Code, Category: e.Category}
+	if e.IsWarning {
+		r.Severity = "warning"
+	}
+	if e.Message != nil {
+		r.Message = e.Message.Error()
+	}
+	return r
+}
+
+// Write writes the records in the chosen format.
+func (d *Diagnostics) Write(w io.Writer) error {
+	switch d.Format {
+	case "json":
+		recs := make([]diagnostic, len(d.Records))
+		for i, e := range d.Records {
+			recs[i] = d.record(e)
+		}
+		return writeJSON(w, struct {
+			Tool        string       `json:"tool"`
+			Diagnostics []diagnostic `json:"diagnostics"`
+		}{d.Tool, recs})
+	case "sarif":
+		return writeJSON(w, d.sarif())
+	default:
+		b := bufio.NewWriter(w)
+		for _, e := range d.Records {
+			r := *e
+			if r.Source == "" {
+				r.Source = d.Tool
+			}
+			fmt.Fprintln(b, r.Error())
+		}
+		return b.Flush()
+	}
+}
+
+// writeJSON writes a value as indented JSON.
+func writeJSON(w io.Writer, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// sarif builds a SARIF 2.1.0 log with one run. Each code used by the records
+// is described as a rule.
+func (d *Diagnostics) sarif() interface{} {
+	type (
+		message struct {
+			Text string `json:"text"`
+		}
+		region struct {
+			StartLine   int `json:"startLine,omitempty"`
+			StartColumn int `json:"startColumn,omitempty"`
+		}
+		physicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region *region `json:"region,omitempty"`
+		}
+		location struct {
+			PhysicalLocation physicalLocation `json:"physicalLocation"`
+		}
+		result struct {
+			RuleID    string     `json:"ruleId"`
+			Level     string     `json:"level"`
+			Message   message    `json:"message"`
+			Locations []location `json:"locations,omitempty"`
+		}
+		rule struct {
+			ID               string  `json:"id"`
+			ShortDescription message `json:"shortDescription"`
+		}
+		driver struct {
+			Name  string `json:"name"`
+			Rules []rule `json:"rules"`
+		}
+		run struct {
+			Tool struct {
+				Driver driver `json:"driver"`
+			} `json:"tool"`
+			Results []result `json:"results"`
+		}
+	)
+
+	var r run
+	r.Tool.Driver.Name = d.Tool
+	r.Tool.Driver.Rules = make([]rule, 0)
+	r.Results = make([]result, 0)
+	codes := make(map[int]bool)
+	for _, e := range d.Records {
+		rec := d.record(e)
+		res := result{RuleID: fmt.Sprint(rec.Code), Level: rec.Severity, Message: message{rec.Message}}
+		if rec.File != "" {
+			var loc location
+			loc.PhysicalLocation.ArtifactLocation.URI = strings.Replace(rec.File, "\\", "/", -1)
+			if rec.Line > 0 {
+				loc.PhysicalLocation.Region = &region{StartLine: rec.Line, StartColumn: rec.Column}
+			}
+			res.Locations = []location{loc}
+		}
+		r.Results = append(r.Results, res)
+		codes[rec.Code] = true
+	}
+	sorted := make([]int, 0, len(codes))
+	for c := range codes {
+		sorted = append(sorted, c)
+	}
+	sort.Ints(sorted)
+	for _, c := range sorted {
+		r.Tool.Driver.Rules = append(r.Tool.Driver.Rules, rule{ID: fmt.Sprint(c), ShortDescription: message{CodeDescriptions[c]}})
+	}
+
+	return struct {
+		Schema  string `json:"$schema"`
+		Version string `json:"version"`
+		Runs    []run  `json:"runs"`
+	}{"https://json.schemastore.org/sarif-2.1.0.json", "2.1.0", []run{r}}
+}
@@ -9,6 +9,7 @@ import (
 // Idl represents the parse tree of an IDL document.
 type Idl struct {
 	Comments   []string
+	Attributes []*Attribute
 	Filename   string
 	Imports    []*Idl
 	Namespaces map[string]string
@@ -16,17 +17,20 @@ type Idl struct {
 	Enums      []*Enum
 	Structs    []*Struct
 	Services   []*Service
+	symbols    map[string]*Definition
 }
 
 // Init initializes the Idl for use.
 func (idl *Idl) Init() {
 	idl.Comments = make([]string, 0)
+	idl.Attributes = make([]*Attribute, 0)
 	idl.Imports = make([]*Idl, 0)
 	idl.Namespaces = make(map[string]string)
 	idl.Consts = make([]*Const, 0)
 	idl.Enums = make([]*Enum, 0)
 	idl.Structs = make([]*Struct, 0)
 	idl.Services = make([]*Service, 0)
+	idl.symbols = nil
 }
 
 // AddImport appends an imported IDL file to this Idl object.
@@ -169,6 +173,7 @@ func (idl *Idl) AddConst(name string) (*Const, error) {
 	c := new(Const)
 	c.Init()
 	c.Name = name
+	idl.symbols = nil
 	idl.Consts = append(idl.Consts, c)
 	return c, nil
 }
@@ -183,6 +188,7 @@ func (idl *Idl) AddEnum(name string) (*Enum, error) {
 	e := new(Enum)
 	e.Init()
 	e.Name = name
+	idl.symbols = nil
 	idl.Enums = append(idl.Enums, e)
 	return e, nil
 }
@@ -197,6 +203,7 @@ func (idl *Idl) AddStruct(name string) (*Struct, error) {
 	s := new(Struct)
 	s.Init()
 	s.Name = name
+	idl.symbols = nil
 	idl.Structs = append(idl.Structs, s)
 	return s, nil
 }
@@ -211,70 +218,39 @@ func (idl *Idl) AddService(name string) (*Service, error) {
 	s := new(Service)
 	s.Init()
 	s.Name = name
+	idl.symbols = nil
 	idl.Services = append(idl.Services, s)
 	return s, nil
 }
 
 // FindConst searches this Idl and imported Idls for the named Const definition.
 func (idl *Idl) FindConst(name string) *Const {
-	for _, s := range idl.Consts {
-		if strings.ToLower(s.Name) == strings.ToLower(name) {
-			return s
-		}
-	}
-	for _, i := range idl.Imports {
-		s := i.FindConst(name)
-		if s != nil {
-			return s
-		}
+	if d := idl.resolve("const", name); d != nil {
+		return d.Const
 	}
 	return nil
 }
 
 // FindEnum searches this Idl and imported Idls for the named Enum definition.
 func (idl *Idl) FindEnum(name string) *Enum {
-	for _, s := range idl.Enums {
-		if strings.ToLower(s.Name) == strings.ToLower(name) {
-			return s
-		}
-	}
-	for _, i := range idl.Imports {
-		s := i.FindEnum(name)
-		if s != nil {
-			return s
-		}
+	if d := idl.resolve("enum", name); d != nil {
+		return d.Enum
 	}
 	return nil
 }
 
 // FindStruct searches this Idl and imported Idls for the named Struct definition.
 func (idl *Idl) FindStruct(name string) *Struct {
-	for _, s := range idl.Structs {
-		if strings.ToLower(s.Name) == strings.ToLower(name) {
-			return s
-		}
-	}
-	for _, i := range idl.Imports {
-		s := i.FindStruct(name)
-		if s != nil {
-			return s
-		}
+	if d := idl.resolve("struct", name); d != nil {
+		return d.Struct
 	}
 	return nil
 }
 
 // FindService searches this Idl and imported Idls for the named Service definition.
 func (idl *Idl) FindService(name string) *Service {
-	for _, s := range idl.Services {
-		if strings.ToLower(s.Name) == strings.ToLower(name) {
-			return s
-		}
-	}
-	for _, i := range idl.Imports {
-		s := i.FindService(name)
-		if s != nil {
-			return s
-		}
+	if d := idl.resolve("service", name); d != nil {
+		return d.Service
 	}
 	return nil
 }
@@ -283,37 +259,15 @@ func (idl *Idl) FindService(name string) *Service {
 // from the Idl that the object is defined in. Objects may be
 // Structs, Enums, Consts, or Services.
 func (idl *Idl) NamespaceOf(name, lang string) string {
-	for _, x := range idl.Structs {
-		if strings.ToLower(x.Name) == strings.ToLower(name) {
-			return idl.Namespaces[lang]
-		}
-	}
-	for _, x := range idl.Enums {
-		if strings.ToLower(x.Name) == strings.ToLower(name) {
-			return idl.Namespaces[lang]
-		}
-	}
-	for _, x := range idl.Consts {
-		if strings.ToLower(x.Name) == strings.ToLower(name) {
-			return idl.Namespaces[lang]
-		}
-	}
-	for _, x := range idl.Services {
-		if strings.ToLower(x.Name) == strings.ToLower(name) {
-			return idl.Namespaces[lang]
-		}
-	}
-	for _, i := range idl.Imports {
-		m := i.NamespaceOf(name, lang)
-		if m != "" {
-			return m
-		}
+	if d := idl.Resolve(name); d != nil {
+		return d.Idl.Namespaces[lang]
 	}
 	return ""
 }
 
 // Validate tests this Idl for collisions, redefinitions, and other problems.
 func (idl *Idl) Validate(lang string) error {
+	idl.BuildSymbols()
 	err := idl.checkForCollisions()
 	if err != nil {
 		return err
@@ -334,14 +288,195 @@ func (idl *Idl) Validate(lang string) error {
 	if err != nil {
 		return err
 	}
+	err = idl.ResolveWireNames()
+	if err != nil {
+		return err
+	}
+	err = idl.checkNameCollisions(lang)
+	if err != nil {
+		return err
+	}
+	idl.checkReservedWords(lang)
+	return nil
+}
+
+// invalid returns an error for a problem found by Validate at a location in
+// this file.
+func (idl *Idl) invalid(code int, pos Pos, err error) error {
+	return &Error{Source: idl.Filename, Line: pos.Line, Column: pos.Column, Category: "Validation", Code: code, Message: err}
+}
+
+// nameScope tracks the identifiers generated within one scope of the target
+// code so that names colliding after conversion can be reported.
+type nameScope struct {
+	lang     string
+	filename string
+	names    map[string]string
+}
+
+// newNameScope creates an empty scope for identifiers generated from the given file.
+func newNameScope(lang, filename string) *nameScope {
+	return &nameScope{lang: lang, filename: filename, names: make(map[string]string)}
+}
+
+// add records the identifier generated for an IDL name, returning an error if
+// another name already produced the same identifier.
+func (s *nameScope) add(kind, name, ident string, pos Pos) error {
+	if prev, ok := s.names[ident]; ok {
+		return &Error{
+			Source:   s.filename,
+			Line:     pos.Line,
+			Column:   pos.Column,
+			Category: "Validation",
+			Code:     CodeNameCollision,
+			Message:  fmt.Errorf("%s \"%s\" collides with \"%s\" as %s identifier \"%s\"", kind, name, prev, s.lang, ident),
+		}
+	}
+	s.names[ident] = name
+	return nil
+}
+
+// convert applies a naming function, leaving the name unchanged if there is none.
+func convert(f func(string) string, name string) string {
+	if f == nil {
+		return name
+	}
+	return f(name)
+}
+
+// checkNameCollisions verifies that names in this Idl and all imports remain
+// distinct after the case conversion applied by the code generator for the
+// given language.
+func (idl *Idl) checkNameCollisions(lang string) error {
+	n, ok := namings[lang]
+	if !ok {
+		return nil
+	}
+	value := func(owner, name string) string {
+		if n.Value == nil {
+			return name
+		}
+		return n.Value(owner, name)
+	}
+	for _, i := range append([]*Idl{idl}, idl.UniqueImports()...) {
+		// definition names are already known to be unique
+		types := newNameScope(lang, i.Filename)
+		for _, c := range i.Consts {
+			types.add("Constant", c.Name, c.Name, c.Pos)
+		}
+		for _, e := range i.Enums {
+			types.add("Enum", e.Name, e.Name, e.Pos)
+		}
+		for _, s := range i.Structs {
+			types.add("Struct", s.Name, s.Name, s.Pos)
+		}
+		for _, s := range i.Services {
+			types.add("Service", s.Name, s.Name, s.Pos)
+		}
+		for _, c := range i.Consts {
+			scope := newNameScope(lang, i.Filename)
+			if n.GlobalValues {
+				scope = types
+			}
+			for _, v := range c.Values {
+				if err := scope.add("Constant", c.Name+"."+v.Name, value(c.Name, v.Name), v.Pos); err != nil {
+					return err
+				}
+			}
+		}
+		for _, e := range i.Enums {
+			scope := newNameScope(lang, i.Filename)
+			if n.GlobalValues {
+				scope = types
+			}
+			for _, v := range e.Values {
+				if err := scope.add("Enumeration value", e.Name+"."+v.Name, value(e.Name, v.Name), v.Pos); err != nil {
+					return err
+				}
+			}
+		}
+		for _, s := range i.Structs {
+			scope := newNameScope(lang, i.Filename)
+			for _, f := range s.Fields {
+				if err := scope.add("Field", s.Name+"."+f.Name, convert(n.Field, f.Name), f.Pos); err != nil {
+					return err
+				}
+			}
+		}
+		for _, s := range i.Services {
+			methods := newNameScope(lang, i.Filename)
+			for _, m := range s.Methods {
+				if err := methods.add("Method", s.Name+"."+m.Name, convert(n.Method, m.Name), m.Pos); err != nil {
+					return err
+				}
+				if n.MethodTypes != nil {
+					for _, t := range n.MethodTypes(s.Name, m.Name) {
+						if err := types.add("Method", s.Name+"."+m.Name, t, m.Pos); err != nil {
+							return err
+						}
+					}
+				}
+				parms := newNameScope(lang, i.Filename)
+				for _, p := range m.Parameters {
+					if err := parms.add("Parameter", s.Name+"."+m.Name+"."+p.Name, convert(n.Parameter, p.Name), p.Pos); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
 	return nil
 }
 
+// checkReservedWords warns about members of this Idl and all imports whose
+// names are reserved words in the given language. Such names are escaped by the
+// code generators, but the name used on the wire does not change.
+func (idl *Idl) checkReservedWords(lang string) {
+	warn := func(filename, kind, owner, name string, pos Pos) {
+		if IsReservedWord(lang, kind, name) {
+			Warning(&Error{
+				Source:    filename,
+				Line:      pos.Line,
+				Column:    pos.Column,
+				Category:  "Validation",
+				IsWarning: true,
+				Code:      CodeReservedWord,
+				Message:   fmt.Errorf("%s \"%s\" is a reserved word in %s and may be escaped in generated code", kind, owner+"."+name, lang),
+			})
+		}
+	}
+	for _, i := range append([]*Idl{idl}, idl.UniqueImports()...) {
+		for _, c := range i.Consts {
+			for _, v := range c.Values {
+				warn(i.Filename, "Constant", c.Name, v.Name, v.Pos)
+			}
+		}
+		for _, e := range i.Enums {
+			for _, v := range e.Values {
+				warn(i.Filename, "Enumeration value", e.Name, v.Name, v.Pos)
+			}
+		}
+		for _, s := range i.Structs {
+			for _, f := range s.Fields {
+				warn(i.Filename, "Field", s.Name, f.Name, f.Pos)
+			}
+		}
+		for _, s := range i.Services {
+			for _, m := range s.Methods {
+				warn(i.Filename, "Method", s.Name, m.Name, m.Pos)
+				for _, p := range m.Parameters {
+					warn(i.Filename, "Parameter", s.Name+"."+m.Name, p.Name, p.Pos)
+				}
+			}
+		}
+	}
+}
+
 // checkNamespaces verfifies that this Idl and all imported Idls specify a
 // namespace for the given language.
 func (idl *Idl) checkNamespaces(lang string) error {
 	if idl.Namespaces[lang] == "" && lang != "test" {
-		return fmt.Errorf("Namespaces for %s are required in %s", lang, idl.Filename)
+		return idl.invalid(CodeNamespace, Pos{}, fmt.Errorf("Namespaces for %s are required in %s", lang, idl.Filename))
 	}
 	for _, i := range idl.Imports {
 		err := i.checkNamespaces(lang)
@@ -363,12 +498,12 @@ func (idl *Idl) checkStructs() error {
 		for _, f := range s.Fields {
 			fields[f.Name] = true
 			if f.Type.IsAbstract(idl) == true {
-				return fmt.Errorf("Field %s.%s uses an abstract type which is not allowed. Polymorphic types are not supported.", s.Name, f.Name)
+				return idl.invalid(CodeBadStruct, f.Pos, fmt.Errorf("Field %s.%s uses an abstract type which is not allowed. Polymorphic types are not supported.", s.Name, f.Name))
 			}
 			if f.Initializer != nil {
 				err := f.CheckInitializer(idl)
 				if err != nil {
-					return err
+					return idl.invalid(CodeBadStruct, f.Pos, err)
 				}
 			}
 		}
@@ -377,18 +512,18 @@ func (idl *Idl) checkStructs() error {
 		for baseName != "" {
 			_, ok := tree[baseName]
 			if ok {
-				return fmt.Errorf("Inheritance cycle detected: %s", baseName)
+				return idl.invalid(CodeBadStruct, s.Pos, fmt.Errorf("Inheritance cycle detected: %s", baseName))
 			} else {
 				tree[baseName] = true
 			}
 			inner := idl.FindStruct(baseName)
 			if inner == nil {
-				return fmt.Errorf("Parent not found: %s", baseName)
+				return idl.invalid(CodeBadStruct, s.Pos, fmt.Errorf("Parent not found: %s", baseName))
 			}
 			for _, fld := range inner.Fields {
 				_, ok := fields[fld.Name]
 				if ok {
-					return fmt.Errorf("Field %s.%s redefined somewhere up to %s", baseName, fld.Name, s.Name)
+					return idl.invalid(CodeBadStruct, s.Pos, fmt.Errorf("Field %s.%s redefined somewhere up to %s", baseName, fld.Name, s.Name))
 				}
 				fields[fld.Name] = true
 			}
@@ -410,22 +545,22 @@ func (idl *Idl) checkServices() error {
 		// methods are already checked for uniqueness when added
 		for _, m := range s.Methods {
 			if m.Returns.IsAbstract(idl) == true {
-				return fmt.Errorf("Method %s.%s returns an abstract type which is not allowed. Polymorphic types are not supported.", s.Name, m.Name)
+				return idl.invalid(CodeBadService, m.Pos, fmt.Errorf("Method %s.%s returns an abstract type which is not allowed. Polymorphic types are not supported.", s.Name, m.Name))
 			}
 			// parameters are already checked for uniqueness when added
 			hasInitializer := false
 			for _, p := range m.Parameters {
 				if p.Type.IsAbstract(idl) == true {
-					return fmt.Errorf("Parameter %s of method %s.%s uses an abstract type which is not allowed. Polymorphic types are not supported.", p.Name, s.Name, m.Name)
+					return idl.invalid(CodeBadService, p.Pos, fmt.Errorf("Parameter %s of method %s.%s uses an abstract type which is not allowed. Polymorphic types are not supported.", p.Name, s.Name, m.Name))
 				}
 				if p.Initializer == nil && hasInitializer {
-					return fmt.Errorf("All initialized parameters of method %s.%s must appear at the end of the method. %s is not initialized.", s.Name, m.Name, p.Name)
+					return idl.invalid(CodeBadService, p.Pos, fmt.Errorf("All initialized parameters of method %s.%s must appear at the end of the method. %s is not initialized.", s.Name, m.Name, p.Name))
 				}
 				if p.Initializer != nil {
 					hasInitializer = true
 					err := p.CheckInitializer(idl)
 					if err != nil {
-						return err
+						return idl.invalid(CodeBadService, p.Pos, err)
 					}
 				}
 			}
@@ -446,7 +581,7 @@ func (idl *Idl) checkTypes() error {
 		for _, f := range s.Fields {
 			err := f.Type.Check(idl)
 			if err != nil {
-				return err
+				return idl.invalid(CodeUndefinedType, f.Pos, err)
 			}
 		}
 	}
@@ -455,12 +590,12 @@ func (idl *Idl) checkTypes() error {
 			for _, p := range m.Parameters {
 				err := p.Type.Check(idl)
 				if err != nil {
-					return err
+					return idl.invalid(CodeUndefinedType, p.Pos, err)
 				}
 			}
 			err := m.Returns.Check(idl)
 			if err != nil {
-				return err
+				return idl.invalid(CodeUndefinedType, m.Pos, err)
 			}
 		}
 	}
@@ -475,39 +610,43 @@ func (idl *Idl) checkTypes() error {
 
 // checkForCollisions checks for redefined items across this Idl and all imports.
 func (idl *Idl) checkForCollisions() error {
-	m := make(map[string]bool)
+	m := make(map[string]*Idl)
 	return idl.checkCollisions(m, false)
 }
 
 // checkCollisions checks for redefined items across this Idl and all imports.
-func (idl *Idl) checkCollisions(data map[string]bool, shallow bool) error {
+// data holds the Idl that defines each name seen so far.
+func (idl *Idl) checkCollisions(data map[string]*Idl, shallow bool) error {
+	redefined := func(kind, name string, pos Pos) error {
+		key := strings.ToLower(name)
+		if prev, ok := data[key]; ok {
+			if prev == idl {
+				return idl.invalid(CodeDefinition, pos, fmt.Errorf("%s \"%s\" is already defined in \"%s\"", kind, name, idl.Filename))
+			}
+			return idl.invalid(CodeRedefined, pos, fmt.Errorf("%s \"%s\" redefined in \"%s\"", kind, name, idl.Filename))
+		}
+		data[key] = idl
+		return nil
+	}
 	for _, itm := range idl.Consts {
-		_, ok := data[strings.ToLower(itm.Name)]
-		if ok {
-			return fmt.Errorf("Constant \"%s\" redefined in \"%s\"", itm.Name, idl.Filename)
+		if err := redefined("Constant", itm.Name, itm.Pos); err != nil {
+			return err
 		}
-		data[strings.ToLower(itm.Name)] = true
 	}
 	for _, itm := range idl.Enums {
-		_, ok := data[strings.ToLower(itm.Name)]
-		if ok {
-			return fmt.Errorf("Enum \"%s\" redefined in \"%s\"", itm.Name, idl.Filename)
+		if err := redefined("Enum", itm.Name, itm.Pos); err != nil {
+			return err
 		}
-		data[strings.ToLower(itm.Name)] = true
 	}
 	for _, itm := range idl.Structs {
-		_, ok := data[strings.ToLower(itm.Name)]
-		if ok {
-			return fmt.Errorf("Struct \"%s\" redefined in \"%s\"", itm.Name, idl.Filename)
+		if err := redefined("Struct", itm.Name, itm.Pos); err != nil {
+			return err
 		}
-		data[strings.ToLower(itm.Name)] = true
 	}
 	for _, itm := range idl.Services {
-		_, ok := data[strings.ToLower(itm.Name)]
-		if ok {
-			return fmt.Errorf("Service \"%s\" redefined in \"%s\"", itm.Name, idl.Filename)
+		if err := redefined("Service", itm.Name, itm.Pos); err != nil {
+			return err
 		}
-		data[strings.ToLower(itm.Name)] = true
 	}
 	if !shallow {
 		for _, imp := range idl.UniqueImports() {
@@ -575,29 +714,33 @@ func (idl *Idl) UniqueImports() []*Idl {
 func (idl *Idl) UniqueTypes() ([]string, []string) {
 	rs := make([]string, 0)
 	rv := make([]string, 0)
-
-	for _, s := range idl.Structs {
-		if !contains(rs, s.Name) {
-			rs = append(rs, s.Name)
-		}
-		for _, f := range s.Fields {
-			if !contains(rs, f.Type.String()) {
-				rs = append(rs, f.Type.String())
+	add := func(list []string, t string) []string {
+		if !contains(list, t) {
+			list = append(list, t)
+		}
+		return list
+	}
+
+	Inspect(idl, func(n interface{}) bool {
+		switch x := n.(type) {
+		case *Idl:
+			// imports are not included
+			return x == idl
+		case *Struct:
+			rs = add(rs, x.Name)
+			for _, f := range x.Fields {
+				rs = add(rs, f.Type.String())
 			}
-		}
-	}
-	for _, s := range idl.Services {
-		for _, m := range s.Methods {
-			for _, p := range m.Parameters {
-				if !contains(rv, p.Type.String()) {
-					rv = append(rv, p.Type.String())
-				}
-			}
-			if !contains(rv, m.Returns.String()) {
-				rv = append(rv, m.Returns.String())
+		case *Service:
+			return true
+		case *Method:
+			for _, p := range x.Parameters {
+				rv = add(rv, p.Type.String())
 			}
+			rv = add(rv, x.Returns.String())
 		}
-	}
+		return false
+	})
 
 	return rs, rv
 }
@@ -2,6 +2,7 @@ package idl
 
 import (
 	"fmt"
+	"os"
 )
 
 var (
@@ -16,6 +17,10 @@ var (
 		"float64",  // 64-bit floating point   (3.141592)
 		"string",   // string of utf8 text     ("\tSome text\r\n")
 		"datetime", // date-time object        ("2013-09-09T13:44.22.341-05:00")
+		"date",     // calendar date           ("2013-09-09")
+		"time",     // time of day, no zone    ("13:44:22.341")
+		"duration", // elapsed time, ISO 8601  ("PT1H30M", "P1DT0.5S")
+		"uuid",     // RFC 4122 UUID           ("7d444840-9dc0-11d1-b245-5ffdce74fad2")
 		"decimal",  // large decimal value     ("300.2415443")
 		"char",     // single utf8 character   ("\r", "A")
 		"binary",   // byte array or blob      ("YXNhZGFzZAo=")
@@ -24,8 +29,61 @@ var (
 		"list",
 		"map",
 	}
+
+	// Warning is called for problems found by Validate that do not prevent code
+	// generation. The default writes the warning to standard error.
+	Warning = func(e *Error) {
+		fmt.Fprintln(os.Stderr, e.Error())
+	}
+
+	// reservedWords holds the reserved word tests registered for each language.
+	reservedWords = make(map[string]func(kind, name string) bool)
+
+	// namings holds the naming rules registered for each language.
+	namings = make(map[string]*Naming)
 )
 
+// RegisterReservedWords installs the test used to decide whether a name is
+// escaped by the code generator for the given language. The test receives the
+// kind of name ("Constant", "Enumeration value", "Field", "Method" or
+// "Parameter") and the name as written in the IDL, so that it can check the
+// identifiers the generator actually emits for it. Code generators register
+// their tables so that Validate can warn about names that will be escaped.
+func RegisterReservedWords(lang string, isReserved func(kind, name string) bool) {
+	reservedWords[lang] = isReserved
+}
+
+// IsReservedWord returns true if a name of the given kind has to be escaped
+// when it is used as an identifier in the given language.
+func IsReservedWord(lang, kind, name string) bool {
+	f, ok := reservedWords[lang]
+	return ok && f(kind, name)
+}
+
+// Naming describes how a code generator derives target-language identifiers from
+// IDL names. Validate uses it to find names that are distinct in the IDL but
+// collide once converted. Functions that are nil leave the name unchanged.
+type Naming struct {
+	Field     func(name string) string        // struct fields
+	Method    func(name string) string        // service methods
+	Parameter func(name string) string        // method parameters
+	Value     func(owner, name string) string // enumeration and constant values
+
+	// GlobalValues is true when enumeration and constant values are declared in
+	// the same scope as the types, as they are in Go.
+	GlobalValues bool
+
+	// MethodTypes returns the names of additional types generated for a service
+	// method, such as request and response structures.
+	MethodTypes func(service, method string) []string
+}
+
+// RegisterNaming installs the naming rules used by the code generator for the
+// given language.
+func RegisterNaming(lang string, n *Naming) {
+	namings[lang] = n
+}
+
 // Error defines fields that will be logged in a uniform format for build tools to process
 // Source (Line, Column): Category (error|warning) Code: Message
 type Error struct {
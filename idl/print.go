@@ -0,0 +1,253 @@
+package idl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Print writes the Idl as IDL source. The output is canonical: definitions are
+// written as consts, enums, structs and services in the order they were
+// defined, with one attribute per line and tabs for indentation. Only doc
+// comments are kept, since other comments are not part of the parse tree.
+func (idl *Idl) Print(w io.Writer) error {
+	p := &printer{w: bufio.NewWriter(w)}
+	p.comments("", idl.Comments)
+	if len(idl.Comments) > 0 {
+		p.line("")
+	}
+	for _, i := range idl.Imports {
+		p.line("import %s", strconv.Quote(relativePath(idl.Filename, i.Filename)))
+	}
+	if len(idl.Imports) > 0 {
+		p.line("")
+	}
+	p.attributes("", idl.Attributes)
+	p.namespaces(idl.Namespaces)
+	for _, c := range idl.Consts {
+		p.line("")
+		p.comments("", c.Comments)
+		p.line("const %s {", c.Name)
+		for _, v := range c.Values {
+			p.line("\t%s = %s", v.Name, literal(v))
+		}
+		p.line("}")
+	}
+	for _, e := range idl.Enums {
+		p.line("")
+		p.comments("", e.Comments)
+		p.line("enum %s {", e.Name)
+		for i, v := range e.Values {
+			sep := ","
+			if i == len(e.Values)-1 {
+				sep = ""
+			}
+			p.line("\t%s = %s%s", v.Name, literal(v), sep)
+		}
+		p.line("}")
+	}
+	for _, s := range idl.Structs {
+		p.line("")
+		p.comments("", s.Comments)
+		p.attributes("", s.Attributes)
+		decl := "struct " + s.Name
+		if s.Abstract {
+			decl = "abstract " + decl
+		}
+		if s.Extends != "" {
+			decl += " extends " + s.Extends
+		}
+		p.line("%s {", decl)
+		for i, f := range s.Fields {
+			if i > 0 && (len(f.Comments) > 0 || len(f.Attributes) > 0) {
+				p.line("")
+			}
+			p.comments("\t", f.Comments)
+			p.attributes("\t", f.Attributes)
+			p.line("\t%s %s%s;", formatType(f.Type), f.Name, initializer(f))
+		}
+		p.line("}")
+	}
+	for _, s := range idl.Services {
+		p.line("")
+		p.comments("", s.Comments)
+		p.attributes("", s.Attributes)
+		p.line("service %s {", s.Name)
+		for i, m := range s.Methods {
+			if i > 0 {
+				p.line("")
+			}
+			p.comments("\t", m.Comments)
+			p.attributes("\t", m.Attributes)
+			p.method(m)
+		}
+		p.line("}")
+	}
+	if p.err != nil {
+		return p.err
+	}
+	return p.w.Flush()
+}
+
+// printer writes IDL source, remembering the first error.
+type printer struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line writes a formatted line.
+func (p *printer) line(format string, args ...interface{}) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format+"\n", args...)
+	}
+}
+
+// comments writes doc comments. Comments that span lines are written as block comments.
+func (p *printer) comments(indent string, cmts []string) {
+	for _, c := range cmts {
+		if strings.Contains(c, "\n") {
+			p.line("%s/**%s*/", indent, c)
+		} else {
+			p.line("%s///%s", indent, c)
+		}
+	}
+}
+
+// attributes writes one attribute per line.
+func (p *printer) attributes(indent string, attrs []*Attribute) {
+	for _, a := range attrs {
+		s := "[" + a.Name
+		if len(a.Parameters) > 0 {
+			parms := make([]string, 0)
+			for _, v := range a.Parameters {
+				if v.Name != "" {
+					parms = append(parms, v.Name+" = "+literal(v))
+				} else {
+					parms = append(parms, literal(v))
+				}
+			}
+			s += "(" + strings.Join(parms, ", ") + ")"
+		}
+		s += "]"
+		if a.Scope != "" {
+			s = "@" + a.Scope + " " + s
+		}
+		p.line("%s%s", indent, s)
+	}
+}
+
+// namespaces writes the default namespace followed by any language namespaces
+// that differ from the ones it implies.
+func (p *printer) namespaces(ns map[string]string) {
+	def, ok := ns["#default"]
+	if !ok {
+		return
+	}
+	p.line("namespace %s", def)
+	var implied Idl
+	implied.Init()
+	arr := strings.SplitN(def, "/", 2)
+	if len(arr) == 2 {
+		implied.AddDefaultNamespace(arr[0], arr[1])
+	}
+	langs := make([]string, 0)
+	for lang := range ns {
+		if lang != "#default" && implied.Namespaces[lang] != ns[lang] {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		p.line("namespace %s %s", lang, strconv.Quote(ns[lang]))
+	}
+}
+
+// method writes a method, putting its parameters on separate lines if any of
+// them have comments or attributes.
+func (p *printer) method(m *Method) {
+	long := false
+	parms := make([]string, 0)
+	for _, f := range m.Parameters {
+		long = long || len(f.Comments) > 0 || len(f.Attributes) > 0
+		parms = append(parms, formatType(f.Type)+" "+f.Name+initializer(f))
+	}
+	if !long {
+		p.line("\t%s %s(%s);", formatType(m.Returns), m.Name, strings.Join(parms, ", "))
+		return
+	}
+	p.line("\t%s %s(", formatType(m.Returns), m.Name)
+	for i, f := range m.Parameters {
+		sep := ","
+		if i == len(m.Parameters)-1 {
+			sep = ""
+		}
+		p.comments("\t\t", f.Comments)
+		p.attributes("\t\t", f.Attributes)
+		p.line("\t\t%s%s", parms[i], sep)
+	}
+	p.line("\t);")
+}
+
+// formatType returns the IDL syntax for a type, including the renames of list
+// and map elements.
+func formatType(t *Type) string {
+	elem := func(e *Type) string {
+		s := formatType(e)
+		if e.Rename != "" {
+			s += " as " + strconv.Quote(e.Rename)
+		}
+		return s
+	}
+	if t.IsList() {
+		return "list<" + elem(t.ValueType) + ">"
+	} else if t.IsMap() {
+		return "map<" + elem(t.KeyType) + ", " + elem(t.ValueType) + ">"
+	}
+	return t.String()
+}
+
+// initializer returns the IDL syntax for the initial value of a field, if it has one.
+func initializer(f *Field) string {
+	if f.Initializer == nil {
+		return ""
+	}
+	return " = " + literal(f.Initializer)
+}
+
+// literal returns the IDL syntax for a value.
+func literal(v *Pair) string {
+	switch x := v.Value.(type) {
+	case string:
+		if v.DataType == "#ref" {
+			return x
+		}
+		return strconv.Quote(x)
+	case rune:
+		return strconv.QuoteRune(x)
+	case float64:
+		s := strconv.FormatFloat(x, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eE") {
+			// keep it a float when it is read back
+			s += ".0"
+		}
+		return s
+	default:
+		return fmt.Sprintf("%v", x)
+	}
+}
+
+// relativePath returns the path of an imported file relative to the file that
+// imports it. Both paths are relative to the working directory, as the parser
+// records them.
+func relativePath(from, to string) string {
+	rel, err := filepath.Rel(path.Dir(from), to)
+	if err != nil {
+		return to
+	}
+	return filepath.ToSlash(rel)
+}
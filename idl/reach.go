@@ -0,0 +1,192 @@
+package idl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reachable is the set of definitions used by a group of services or
+// methods. The maps are keyed by the qualified names of the definitions, for
+// example "company.com/Users.User"; methods are keyed by the qualified name of
+// the service followed by the method, as in "company.com/Users.Users.Lookup".
+type Reachable struct {
+	Structs map[string]bool
+	Enums   map[string]bool
+	Consts  map[string]bool
+	Methods map[string]bool
+}
+
+// Reachable computes the structs, enums and consts used by the given services
+// or methods, written as "Service" or "Service.Method". It follows parameter
+// and return types, the key and value types of maps and lists, the structs
+// that a struct extends or that extend it, and the enums and consts used by
+// initializers.
+func (idl *Idl) Reachable(roots ...string) (*Reachable, error) {
+	return ReachableIn([]*Idl{idl}, roots...)
+}
+
+// ReachableIn computes the definitions used by the given services or methods
+// like Reachable, for several files that are retained together. Each root
+// must be defined in one of the files or their imports, and the structs that
+// extend a used struct are found in all of them.
+func ReachableIn(files []*Idl, roots ...string) (*Reachable, error) {
+	r := &Reachable{
+		Structs: make(map[string]bool),
+		Enums:   make(map[string]bool),
+		Consts:  make(map[string]bool),
+		Methods: make(map[string]bool),
+	}
+	for _, root := range roots {
+		arr := strings.SplitN(root, ".", 2)
+		defined := false
+		for _, f := range files {
+			d := f.resolve("service", arr[0])
+			if d == nil {
+				continue
+			}
+			defined = true
+			found := false
+			for _, m := range d.Service.Methods {
+				if len(arr) == 1 || strings.ToLower(m.Name) == strings.ToLower(arr[1]) {
+					found = true
+					r.Methods[d.Idl.QualifiedName(d.Service.Name)+"."+m.Name] = true
+					r.addType(d.Idl, m.Returns)
+					for _, p := range m.Parameters {
+						r.addType(d.Idl, p.Type)
+						r.addInitializer(d.Idl, p.Initializer)
+					}
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("Method %s is not defined", root)
+			}
+		}
+		if !defined {
+			return nil, fmt.Errorf("Service %s is not defined", arr[0])
+		}
+	}
+	r.addDerived(files)
+	return r, nil
+}
+
+// addType adds the definitions used by a type, whose names are resolved in
+// the given file.
+func (r *Reachable) addType(in *Idl, t *Type) {
+	if t == nil {
+		return
+	}
+	if d := in.resolve("enum", t.Name); d != nil {
+		r.Enums[d.Idl.QualifiedName(d.Enum.Name)] = true
+	} else if d := in.resolve("struct", t.Name); d != nil {
+		r.addStruct(d)
+	}
+	r.addType(in, t.KeyType)
+	r.addType(in, t.ValueType)
+}
+
+// addStruct adds a struct, the structs it extends, and the definitions used by its fields.
+func (r *Reachable) addStruct(d *Definition) {
+	key := d.Idl.QualifiedName(d.Struct.Name)
+	if r.Structs[key] {
+		return
+	}
+	r.Structs[key] = true
+	for _, f := range d.Struct.Fields {
+		r.addType(d.Idl, f.Type)
+		r.addInitializer(d.Idl, f.Initializer)
+	}
+	if d.Struct.Extends != "" {
+		if base := d.Idl.resolve("struct", d.Struct.Extends); base != nil {
+			r.addStruct(base)
+		}
+	}
+}
+
+// addDerived adds the structs that extend a used struct, since a value of the
+// base type may hold them, until no more are found.
+func (r *Reachable) addDerived(files []*Idl) {
+	all := make([]*Idl, 0)
+	seen := make(map[*Idl]bool)
+	for _, f := range files {
+		for _, i := range append([]*Idl{f}, f.UniqueImports()...) {
+			if !seen[i] {
+				seen[i] = true
+				all = append(all, i)
+			}
+		}
+	}
+	for added := true; added; {
+		added = false
+		for _, i := range all {
+			for _, s := range i.Structs {
+				if s.Extends == "" || r.Structs[i.QualifiedName(s.Name)] {
+					continue
+				}
+				if base := i.resolve("struct", s.Extends); base != nil && r.Structs[base.Idl.QualifiedName(base.Struct.Name)] {
+					r.addStruct(&Definition{Idl: i, Struct: s})
+					added = true
+				}
+			}
+		}
+	}
+}
+
+// addInitializer adds the enum or const that an initializer refers to.
+func (r *Reachable) addInitializer(in *Idl, p *Pair) {
+	if p == nil || p.DataType != "#ref" {
+		return
+	}
+	ref := p.Value.(string)
+	dot := strings.LastIndex(ref, ".")
+	if dot < 0 {
+		return
+	}
+	if d := in.resolve("enum", ref[:dot]); d != nil {
+		r.Enums[d.Idl.QualifiedName(d.Enum.Name)] = true
+	} else if d := in.resolve("const", ref[:dot]); d != nil {
+		r.Consts[d.Idl.QualifiedName(d.Const.Name)] = true
+	}
+}
+
+// Retain removes the definitions that are not in the reachable set from this
+// Idl and all imports. Services left without methods are removed too.
+func (idl *Idl) Retain(r *Reachable) {
+	for _, i := range append([]*Idl{idl}, idl.UniqueImports()...) {
+		consts := make([]*Const, 0)
+		for _, c := range i.Consts {
+			if r.Consts[i.QualifiedName(c.Name)] {
+				consts = append(consts, c)
+			}
+		}
+		i.Consts = consts
+		enums := make([]*Enum, 0)
+		for _, e := range i.Enums {
+			if r.Enums[i.QualifiedName(e.Name)] {
+				enums = append(enums, e)
+			}
+		}
+		i.Enums = enums
+		structs := make([]*Struct, 0)
+		for _, s := range i.Structs {
+			if r.Structs[i.QualifiedName(s.Name)] {
+				structs = append(structs, s)
+			}
+		}
+		i.Structs = structs
+		services := make([]*Service, 0)
+		for _, s := range i.Services {
+			methods := make([]*Method, 0)
+			for _, m := range s.Methods {
+				if r.Methods[i.QualifiedName(s.Name)+"."+m.Name] {
+					methods = append(methods, m)
+				}
+			}
+			if len(methods) > 0 {
+				s.Methods = methods
+				services = append(services, s)
+			}
+		}
+		i.Services = services
+	}
+	idl.BuildSymbols()
+}
@@ -0,0 +1,216 @@
+package idl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Ref is a place in an IDL file where a definition is named.
+type Ref struct {
+	Filename string
+	Pos      Pos
+	Name     string // the name as written at Pos
+}
+
+// Symbol is a definition or struct field together with every place that
+// names it. The declaration is always the first Ref.
+type Symbol struct {
+	Kind  string // Const, Enum, Struct, Service or Field
+	Name  string // the definition name, or "Struct.Field" for fields
+	File  *Idl   // the file that declares the symbol
+	Field *Field // set for fields
+	Refs  []Ref
+}
+
+// Index records the symbols declared in a set of IDL files and their imports,
+// and where each of them is referenced. References are found in extends
+// clauses, the types of fields, parameters and return values, the key and
+// value types of maps and lists, and enum or const initializers.
+type Index struct {
+	symbols map[string]*Symbol // keyed by lower-case file name and symbol name
+	files   []*Idl
+}
+
+// NewIndex builds an Index over the given files and everything they import.
+// Files imported by several of them are only indexed once.
+func NewIndex(files ...*Idl) *Index {
+	x := &Index{symbols: make(map[string]*Symbol)}
+	seen := make(map[string]bool)
+	for _, f := range files {
+		for _, i := range append([]*Idl{f}, f.UniqueImports()...) {
+			if !seen[strings.ToLower(i.Filename)] {
+				seen[strings.ToLower(i.Filename)] = true
+				x.files = append(x.files, i)
+				x.declare(i)
+			}
+		}
+	}
+	for _, i := range x.files {
+		x.resolve(i)
+	}
+	for _, s := range x.symbols {
+		decl := s.Refs[0]
+		rest := s.Refs[1:]
+		sort.SliceStable(rest, func(a, b int) bool {
+			if rest[a].Filename != rest[b].Filename {
+				return rest[a].Filename < rest[b].Filename
+			}
+			if rest[a].Pos.Line != rest[b].Pos.Line {
+				return rest[a].Pos.Line < rest[b].Pos.Line
+			}
+			return rest[a].Pos.Column < rest[b].Pos.Column
+		})
+		s.Refs = append([]Ref{decl}, rest...)
+	}
+	return x
+}
+
+// symbolKey returns the key of a symbol declared in the named file.
+func symbolKey(filename, name string) string {
+	return strings.ToLower(filename) + "|" + strings.ToLower(name)
+}
+
+// declare adds the symbols declared in a file.
+func (x *Index) declare(i *Idl) {
+	add := func(kind, name string, pos Pos, f *Field) {
+		x.symbols[symbolKey(i.Filename, name)] = &Symbol{
+			Kind:  kind,
+			Name:  name,
+			File:  i,
+			Field: f,
+			Refs:  []Ref{Ref{Filename: i.Filename, Pos: pos, Name: name[strings.LastIndex(name, ".")+1:]}},
+		}
+	}
+	for _, c := range i.Consts {
+		add("Const", c.Name, c.Pos, nil)
+	}
+	for _, e := range i.Enums {
+		add("Enum", e.Name, e.Pos, nil)
+	}
+	for _, s := range i.Structs {
+		add("Struct", s.Name, s.Pos, nil)
+		for _, f := range s.Fields {
+			add("Field", s.Name+"."+f.Name, f.Pos, f)
+		}
+	}
+	for _, s := range i.Services {
+		add("Service", s.Name, s.Pos, nil)
+	}
+}
+
+// resolve adds the references made by a file. Names are looked up in the file
+// itself and then in its imports, the same way the parser finds them.
+func (x *Index) resolve(i *Idl) {
+	scope := append([]*Idl{i}, i.UniqueImports()...)
+	ref := func(name string, pos Pos) {
+		if pos.Line == 0 {
+			return
+		}
+		for _, s := range scope {
+			if sym, ok := x.symbols[symbolKey(s.Filename, name)]; ok && sym.Kind != "Field" {
+				sym.Refs = append(sym.Refs, Ref{Filename: i.Filename, Pos: pos, Name: name})
+				return
+			}
+		}
+	}
+	var refType func(t *Type)
+	refType = func(t *Type) {
+		if t != nil {
+			ref(t.Name, t.Pos)
+			refType(t.KeyType)
+			refType(t.ValueType)
+		}
+	}
+	refField := func(f *Field) {
+		refType(f.Type)
+		if f.Initializer != nil && f.Initializer.DataType == "#ref" {
+			ref(strings.Split(f.Initializer.Value.(string), ".")[0], f.Initializer.Pos)
+		}
+	}
+	for _, s := range i.Structs {
+		if s.Extends != "" {
+			ref(s.Extends, s.ExtendsPos)
+		}
+		for _, f := range s.Fields {
+			refField(f)
+		}
+	}
+	for _, s := range i.Services {
+		for _, m := range s.Methods {
+			refType(m.Returns)
+			for _, p := range m.Parameters {
+				refField(p)
+			}
+		}
+	}
+}
+
+// Lookup returns the symbols with the given name, written as "Name" for
+// definitions or "Struct.Field" for fields. The name is matched without regard
+// to case. There is more than one symbol when unrelated files declare the same
+// name.
+func (x *Index) Lookup(name string) []*Symbol {
+	syms := make([]*Symbol, 0)
+	for _, i := range x.files {
+		if s, ok := x.symbols[symbolKey(i.Filename, name)]; ok {
+			syms = append(syms, s)
+		}
+	}
+	return syms
+}
+
+// CheckRename verifies that a symbol can be renamed to newName without
+// colliding with another definition, or with another field of the same struct
+// or the structs it extends.
+func (x *Index) CheckRename(sym *Symbol, newName string) error {
+	if !isIdent(newName) {
+		return fmt.Errorf("\"%s\" is not a valid name", newName)
+	}
+	if sym.Kind != "Field" {
+		for _, s := range x.Lookup(newName) {
+			if s != sym {
+				return fmt.Errorf("%s \"%s\" is already defined in %s", s.Kind, s.Name, s.File.Filename)
+			}
+		}
+		return nil
+	}
+	owner := strings.Split(sym.Name, ".")[0]
+	for _, s := range x.files {
+		for _, st := range s.Structs {
+			if !related(s, st, owner) && !related(sym.File, sym.File.FindStruct(owner), st.Name) {
+				continue
+			}
+			for _, f := range st.Fields {
+				if f != sym.Field && strings.ToLower(f.Name) == strings.ToLower(newName) {
+					return fmt.Errorf("Field \"%s.%s\" is already defined in %s", st.Name, f.Name, s.Filename)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// related returns true if the struct is the named struct or extends it.
+func related(idl *Idl, s *Struct, name string) bool {
+	if strings.ToLower(s.Name) == strings.ToLower(name) {
+		return true
+	}
+	bases, _ := s.BaseClasses(idl)
+	for _, b := range bases {
+		if strings.ToLower(b.Name) == strings.ToLower(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isIdent returns true if s can be used as an IDL identifier.
+func isIdent(s string) bool {
+	keywords := []string{"import", "namespace", "const", "enum", "abstract", "struct", "extends",
+		"void", "service", "as", "true", "false", "csharp", "java", "python", "ruby", "go", "js", "asp", "php", "ios"}
+	if contains(keywords, s) || contains(IdlTypes, s) || contains(IdlContainers, s) {
+		return false
+	}
+	return isWireName(s)
+}
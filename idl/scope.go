@@ -0,0 +1,203 @@
+package idl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseScopes splits a comma-separated list of scopes, as given to the -scopes
+// flag of the Babel tools.
+func ParseScopes(s string) []string {
+	scopes := make([]string, 0)
+	for _, x := range strings.Split(s, ",") {
+		sc := strings.TrimSpace(x)
+		if sc != "" {
+			scopes = append(scopes, sc)
+		}
+	}
+	return scopes
+}
+
+// definitionScopes returns the scopes named by the Scope attributes in the
+// list, or nil if the definition is not limited to any scopes.
+func definitionScopes(attrs []*Attribute) ([]string, error) {
+	var scopes []string
+	for _, a := range attrs {
+		if a.Scope == BabelScope && a.Name == ScopeAttribute {
+			if len(a.Parameters) == 0 {
+				return nil, fmt.Errorf("%s should have one or more string parameters", a.Name)
+			}
+			for _, p := range a.Parameters {
+				if p.Name != "" || p.DataType != "string" {
+					return nil, fmt.Errorf("%s should have one or more string parameters", a.Name)
+				}
+				scopes = append(scopes, p.Value.(string))
+			}
+		}
+	}
+	return scopes, nil
+}
+
+// inScope returns true if a definition limited to the given scopes is enabled.
+func inScope(scopes, enabled []string) bool {
+	if scopes == nil {
+		return true
+	}
+	for _, s := range scopes {
+		for _, e := range enabled {
+			if strings.EqualFold(s, e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Prune removes the structs, fields, methods and services of this Idl and all
+// imports that a Scope attribute limits to scopes that are not enabled, for
+// example:
+//
+//	@babel [Scope("internal")]
+//	struct AuditInfo { ... }
+//
+// Definitions without a Scope attribute are always kept. Prune returns an
+// error if a definition that is kept refers to a struct that was removed.
+func (idl *Idl) Prune(enabled []string) error {
+	// names used by what is kept are resolved with the definitions as they
+	// were before pruning, so removed structs are keyed by qualified name
+	idl.BuildSymbols()
+	removed := make(map[string][]string)
+	for _, i := range append([]*Idl{idl}, idl.UniqueImports()...) {
+		bad := func(pos Pos, err error) error {
+			return &Error{Source: i.Filename, Line: pos.Line, Column: pos.Column, Category: "Validation", Code: CodeBadAttribute, Message: err}
+		}
+		structs := make([]*Struct, 0)
+		for _, s := range i.Structs {
+			sc, err := definitionScopes(s.Attributes)
+			if err != nil {
+				return bad(s.Pos, err)
+			}
+			if !inScope(sc, enabled) {
+				removed[i.QualifiedName(s.Name)] = sc
+				continue
+			}
+			flds := make([]*Field, 0)
+			for _, f := range s.Fields {
+				sc, err := definitionScopes(f.Attributes)
+				if err != nil {
+					return bad(f.Pos, err)
+				}
+				if inScope(sc, enabled) {
+					flds = append(flds, f)
+				}
+			}
+			s.Fields = flds
+			structs = append(structs, s)
+		}
+		i.Structs = structs
+
+		services := make([]*Service, 0)
+		for _, s := range i.Services {
+			sc, err := definitionScopes(s.Attributes)
+			if err != nil {
+				return bad(s.Pos, err)
+			}
+			if !inScope(sc, enabled) {
+				continue
+			}
+			methods := make([]*Method, 0)
+			for _, m := range s.Methods {
+				sc, err := definitionScopes(m.Attributes)
+				if err != nil {
+					return bad(m.Pos, err)
+				}
+				for _, p := range m.Parameters {
+					psc, _ := definitionScopes(p.Attributes)
+					if psc != nil {
+						// removing a parameter would change the signature of the method
+						return bad(p.Pos, fmt.Errorf("%s cannot be used on parameter %s.%s.%s", ScopeAttribute, s.Name, m.Name, p.Name))
+					}
+				}
+				if inScope(sc, enabled) {
+					methods = append(methods, m)
+				}
+			}
+			s.Methods = methods
+			services = append(services, s)
+		}
+		i.Services = services
+	}
+	err := idl.checkScopes(removed)
+	idl.BuildSymbols()
+	return err
+}
+
+// checkScopes verifies that nothing left in this Idl and its imports refers to
+// one of the removed structs, which are keyed by qualified name. It must be
+// called before the symbol tables are rebuilt.
+func (idl *Idl) checkScopes(removed map[string][]string) error {
+	if len(removed) == 0 {
+		return nil
+	}
+	for _, i := range append([]*Idl{idl}, idl.UniqueImports()...) {
+		i := i
+		// removedStruct returns the qualified name of the removed struct that
+		// a name used in this file refers to, or "" if it isn't one.
+		removedStruct := func(name string) string {
+			if d := i.resolve("struct", name); d != nil {
+				if q := d.Idl.QualifiedName(d.Struct.Name); removed[q] != nil {
+					return q
+				}
+			}
+			return ""
+		}
+		mismatch := func(pos Pos, kind, name, uses string) error {
+			return &Error{
+				Source:   i.Filename,
+				Line:     pos.Line,
+				Column:   pos.Column,
+				Category: "Validation",
+				Code:     CodeScopeMismatch,
+				Message:  fmt.Errorf("%s \"%s\" uses struct \"%s\", which is only in scopes %s", kind, name, uses, strings.Join(removed[uses], ", ")),
+			}
+		}
+		for _, s := range i.Structs {
+			if q := removedStruct(s.Extends); q != "" {
+				return mismatch(s.Pos, "Struct", s.Name, q)
+			}
+			for _, f := range s.Fields {
+				if q := removedType(f.Type, removedStruct); q != "" {
+					return mismatch(f.Pos, "Field", s.Name+"."+f.Name, q)
+				}
+			}
+		}
+		for _, s := range i.Services {
+			for _, m := range s.Methods {
+				if q := removedType(m.Returns, removedStruct); q != "" {
+					return mismatch(m.Pos, "Method", s.Name+"."+m.Name, q)
+				}
+				for _, p := range m.Parameters {
+					if q := removedType(p.Type, removedStruct); q != "" {
+						return mismatch(p.Pos, "Parameter", s.Name+"."+m.Name+"."+p.Name, q)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// removedType returns the qualified name of a removed struct used by the type,
+// its keys or its values, or an empty string if there isn't one.
+func removedType(t *Type, removedStruct func(name string) string) string {
+	if t == nil {
+		return ""
+	}
+	if q := removedStruct(t.Name); q != "" {
+		return q
+	}
+	if q := removedType(t.KeyType, removedStruct); q != "" {
+		return q
+	}
+	return removedType(t.ValueType, removedStruct)
+}
@@ -0,0 +1,134 @@
+package idl
+
+import (
+	"strings"
+)
+
+// Definition is an entry in the symbol table of an Idl. Exactly one of Const,
+// Enum, Struct or Service is set.
+type Definition struct {
+	Idl     *Idl // the file that defines it
+	Const   *Const
+	Enum    *Enum
+	Struct  *Struct
+	Service *Service
+}
+
+// QualifiedName returns the name of a definition in this file qualified by
+// the default namespace, for example "company.com/Users.User".
+func (idl *Idl) QualifiedName(name string) string {
+	return idl.Namespaces["#default"] + "." + name
+}
+
+// Resolve returns the definition with the given short or qualified name from
+// this Idl or its imports, or nil if there isn't one. Names are matched
+// without regard to case. A definition of any kind in this file hides one
+// with the same name in an import, and earlier imports hide later ones.
+func (idl *Idl) Resolve(name string) *Definition {
+	return idl.resolveAny(strings.ToLower(name), make(map[*Idl]bool))
+}
+
+// resolveAny returns the definition of any kind with a lower-case short or
+// qualified name, searching this file before its imports. Files that have
+// been searched are skipped.
+func (idl *Idl) resolveAny(name string, searched map[*Idl]bool) *Definition {
+	if searched[idl] {
+		return nil
+	}
+	searched[idl] = true
+	for _, kind := range []string{"const", "enum", "struct", "service"} {
+		if d := idl.lookup(kind + ":" + name); d != nil {
+			return d
+		}
+	}
+	for _, i := range idl.Imports {
+		if d := i.resolveAny(name, searched); d != nil {
+			return d
+		}
+	}
+	return nil
+}
+
+// resolve returns the definition of the given kind with a short or qualified name.
+func (idl *Idl) resolve(kind, name string) *Definition {
+	key := kind + ":" + strings.ToLower(name)
+	if d := idl.lookup(key); d != nil {
+		return d
+	}
+	return idl.resolveImports(key, map[*Idl]bool{idl: true})
+}
+
+// resolveImports searches the imports of this Idl for a key of its symbol
+// table. Imports are searched when a name is looked up rather than copied into
+// the table, so changes to them are seen without rebuilding this one.
+func (idl *Idl) resolveImports(key string, searched map[*Idl]bool) *Definition {
+	for _, i := range idl.Imports {
+		if searched[i] {
+			continue
+		}
+		searched[i] = true
+		if d := i.lookup(key); d != nil {
+			return d
+		}
+		if d := i.resolveImports(key, searched); d != nil {
+			return d
+		}
+	}
+	return nil
+}
+
+// lookup returns the definition in this file with the given key, building the
+// symbol table if needed.
+func (idl *Idl) lookup(key string) *Definition {
+	if idl.symbols == nil {
+		idl.buildSymbols()
+	}
+	return idl.symbols[key]
+}
+
+// BuildSymbols rebuilds the symbol tables of this Idl and all imports. Symbol
+// tables only hold the definitions of their own file and are built when they
+// are first used; code that renames definitions or changes namespaces
+// afterwards must call BuildSymbols so lookups see the changes.
+func (idl *Idl) BuildSymbols() {
+	idl.rebuildSymbols(make(map[*Idl]bool))
+}
+
+// rebuildSymbols rebuilds the symbol tables of this Idl and all imports that
+// haven't been rebuilt during this pass.
+func (idl *Idl) rebuildSymbols(built map[*Idl]bool) {
+	if built[idl] {
+		return
+	}
+	built[idl] = true
+	idl.buildSymbols()
+	for _, i := range idl.Imports {
+		i.rebuildSymbols(built)
+	}
+}
+
+// buildSymbols builds the symbol table of the definitions in this file.
+func (idl *Idl) buildSymbols() {
+	// keys are the kind of definition followed by its short or qualified name
+	t := make(map[string]*Definition)
+	add := func(kind, name string, d *Definition) {
+		for _, key := range []string{kind + ":" + strings.ToLower(name), kind + ":" + strings.ToLower(idl.QualifiedName(name))} {
+			if _, ok := t[key]; !ok {
+				t[key] = d
+			}
+		}
+	}
+	for _, c := range idl.Consts {
+		add("const", c.Name, &Definition{Idl: idl, Const: c})
+	}
+	for _, e := range idl.Enums {
+		add("enum", e.Name, &Definition{Idl: idl, Enum: e})
+	}
+	for _, s := range idl.Structs {
+		add("struct", s.Name, &Definition{Idl: idl, Struct: s})
+	}
+	for _, s := range idl.Services {
+		add("service", s.Name, &Definition{Idl: idl, Service: s})
+	}
+	idl.symbols = t
+}
@@ -12,17 +12,25 @@ import (
 //   (primitive)  (empty)      (empty)      Primitive type
 //   (user)       (empty)      (empty)      User defined (Struct, Enum)
 //   list         (empty)      Type         List of Type
-//   map          (primitive)  Type         Map of (primitive) to Type
+//   map          (primitive)  Type         Map of (primitive) or (enum) to Type
 //   void         (empty)      (empty)      No return type (only for methods)
 //
 // Note that types can nest.
 type Type struct {
 	Name      string // map, list, or type name
-	KeyType   *Type  // for maps - this will only ever be a basic primitive type
+	KeyType   *Type  // for maps - this will only ever be a basic primitive type or an enum
 	ValueType *Type  // for maps and lists
 	Rename    string // used to rename this type for some serializers
+	Precision int    `json:",omitempty"` // for decimals - total number of digits, or 0 if not limited
+	Scale     int    `json:",omitempty"` // for decimals - number of digits after the decimal point
+	Pos       Pos    `json:"-"`          // location of the name for user-defined types
 }
 
+// MaxPrecision is the largest precision allowed for a decimal. It is the most
+// digits that every target language can represent exactly; C# decimals hold 28
+// to 29 significant digits.
+const MaxPrecision = 28
+
 // String returns the Type as a string in IDL format.
 func (t *Type) String() string {
 	var s string
@@ -30,12 +38,52 @@ func (t *Type) String() string {
 		s = fmt.Sprintf("list<%s>", t.ValueType)
 	} else if t.Name == "map" {
 		s = fmt.Sprintf("map<%s,%s>", t.KeyType, t.ValueType)
+	} else if t.Precision > 0 {
+		s = fmt.Sprintf("%s(%d,%d)", t.Name, t.Precision, t.Scale)
 	} else {
 		s = fmt.Sprintf("%s", t.Name)
 	}
 	return s
 }
 
+// SetPrecision limits a decimal Type to the given total number of digits, of
+// which scale are after the decimal point. It returns an error if the Type is
+// not a decimal or the limits are out of range.
+func (t *Type) SetPrecision(precision, scale int64) error {
+	if !t.IsDecimal() {
+		return fmt.Errorf("Precision can only be specified for decimal, not %s", t.Name)
+	}
+	if precision < 1 || precision > MaxPrecision {
+		return fmt.Errorf("Decimal precision must be between 1 and %d, not %d", MaxPrecision, precision)
+	}
+	if scale < 0 || scale > precision {
+		return fmt.Errorf("Decimal scale must be between 0 and the precision %d, not %d", precision, scale)
+	}
+	t.Precision = int(precision)
+	t.Scale = int(scale)
+	return nil
+}
+
+// HasPrecision checks if the Type is a decimal with a declared precision and scale.
+func (t *Type) HasPrecision() bool {
+	return t.IsDecimal() && t.Precision > 0
+}
+
+// HoldsPrecision checks if the Type is a decimal with a declared precision and
+// scale, or a list or map whose values are, at any depth.
+func (t *Type) HoldsPrecision() bool {
+	for t.IsList() || t.IsMap() {
+		t = t.ValueType
+	}
+	return t.HasPrecision()
+}
+
+// IntegerDigits returns the number of digits a decimal with a declared precision
+// may have before the decimal point.
+func (t *Type) IntegerDigits() int {
+	return t.Precision - t.Scale
+}
+
 // TagName returns the name of the type for use in various serializers.
 func (t *Type) TagName() string {
 	var s string
@@ -70,7 +118,8 @@ func (t *Type) IsAbstract(idl *Idl) bool {
 // IsPrimitive checks if the Type is one of the primitive types.
 func (t *Type) IsPrimitive() bool {
 	switch t.Name {
-	case "bool", "byte", "int8", "int16", "int32", "int64", "float32", "float64", "string", "datetime", "decimal", "char":
+	case "bool", "byte", "int8", "int16", "int32", "int64", "float32", "float64", "string", "datetime", "decimal", "char",
+		"date", "time", "duration", "uuid":
 		return true
 	default:
 		return false
@@ -87,6 +136,26 @@ func (t *Type) IsDatetime() bool {
 	return t.Name == "datetime"
 }
 
+// IsDate checks if the Type is a date.
+func (t *Type) IsDate() bool {
+	return t.Name == "date"
+}
+
+// IsTime checks if the Type is a time of day.
+func (t *Type) IsTime() bool {
+	return t.Name == "time"
+}
+
+// IsDuration checks if the Type is a duration.
+func (t *Type) IsDuration() bool {
+	return t.Name == "duration"
+}
+
+// IsUUID checks if the Type is a UUID.
+func (t *Type) IsUUID() bool {
+	return t.Name == "uuid"
+}
+
 // IsDecimal checks if the Type is a decimal.
 func (t *Type) IsDecimal() bool {
 	return t.Name == "decimal"
@@ -168,7 +237,14 @@ func (t *Type) Check(idl *Idl) error {
 		if !t.IsStruct(idl) && !t.IsEnum(idl) {
 			return fmt.Errorf("Type %s is not defined", t.Name)
 		}
-	} else if t.IsList() || t.IsMap() {
+	} else if t.IsMap() {
+		if t.KeyType.IsStruct(idl) {
+			return fmt.Errorf("Map key %s must be a primitive type or an enum", t.KeyType.Name)
+		} else if err := t.KeyType.Check(idl); err != nil {
+			return err
+		}
+		return t.ValueType.Check(idl)
+	} else if t.IsList() {
 		return t.ValueType.Check(idl)
 	}
 	return nil
@@ -0,0 +1,120 @@
+package idl
+
+// Visitor is used by Walk to visit the nodes of the parse tree. Nodes are
+// *Idl, *Attribute, *Pair, *Const, *Enum, *Struct, *Field, *Service, *Method
+// and *Type.
+//
+// Enter is called before the children of a node are visited. If it returns
+// false, the children are skipped and Leave is not called for the node.
+// Otherwise Leave is called after all of the children have been visited.
+type Visitor interface {
+	Enter(node interface{}) bool
+	Leave(node interface{})
+}
+
+// Walk visits this Idl and each unique import once, in the order returned by
+// UniqueImports. Imports are visited after the file instead of inside it. The
+// children of each node are visited in the order they are written:
+//
+//	Idl        attributes, consts, enums, structs, services
+//	Const      values
+//	Enum       values
+//	Struct     attributes, fields
+//	Field      attributes, type, initializer
+//	Service    attributes, methods
+//	Method     attributes, return type, parameters (as Fields)
+//	Attribute  parameters
+//	Type       key type, value type
+func Walk(v Visitor, idl *Idl) {
+	for _, i := range append([]*Idl{idl}, idl.UniqueImports()...) {
+		walk(v, i)
+	}
+}
+
+// Inspect calls f for each node visited by Walk. If f returns false, the
+// children of the node are skipped.
+func Inspect(idl *Idl, f func(node interface{}) bool) {
+	Walk(inspector(f), idl)
+}
+
+// inspector adapts a function to the Visitor interface.
+type inspector func(node interface{}) bool
+
+func (f inspector) Enter(node interface{}) bool {
+	return f(node)
+}
+
+func (f inspector) Leave(node interface{}) {
+}
+
+// walk visits a node and its children.
+func walk(v Visitor, node interface{}) {
+	if !v.Enter(node) {
+		return
+	}
+	switch n := node.(type) {
+	case *Idl:
+		walkAttributes(v, n.Attributes)
+		for _, c := range n.Consts {
+			walk(v, c)
+		}
+		for _, e := range n.Enums {
+			walk(v, e)
+		}
+		for _, s := range n.Structs {
+			walk(v, s)
+		}
+		for _, s := range n.Services {
+			walk(v, s)
+		}
+	case *Const:
+		for _, p := range n.Values {
+			walk(v, p)
+		}
+	case *Enum:
+		for _, p := range n.Values {
+			walk(v, p)
+		}
+	case *Struct:
+		walkAttributes(v, n.Attributes)
+		for _, f := range n.Fields {
+			walk(v, f)
+		}
+	case *Field:
+		walkAttributes(v, n.Attributes)
+		walk(v, n.Type)
+		if n.Initializer != nil {
+			walk(v, n.Initializer)
+		}
+	case *Service:
+		walkAttributes(v, n.Attributes)
+		for _, m := range n.Methods {
+			walk(v, m)
+		}
+	case *Method:
+		walkAttributes(v, n.Attributes)
+		walk(v, n.Returns)
+		for _, p := range n.Parameters {
+			walk(v, p)
+		}
+	case *Attribute:
+		for _, p := range n.Parameters {
+			walk(v, p)
+		}
+	case *Type:
+		if n.KeyType != nil {
+			walk(v, n.KeyType)
+		}
+		if n.ValueType != nil {
+			walk(v, n.ValueType)
+		}
+	}
+	v.Leave(node)
+}
+
+// walkAttributes visits a list of attributes.
+func walkAttributes(v Visitor, attrs []*Attribute) {
+	for _, a := range attrs {
+		walk(v, a)
+	}
+}
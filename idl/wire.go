@@ -0,0 +1,202 @@
+package idl
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Standard attributes are understood by all of the Babel tools. They are
+// written in the "babel" scope, for example:
+//
+//	@babel [Naming("snake")]
+//	namespace company.com/Users
+//
+//	struct User {
+//		@babel [WireName("user_id")]
+//		int64 ID;
+//	}
+const (
+	BabelScope        = "babel"
+	WireNameAttribute = "WireName" // name of a field or parameter in serialized data
+	NamingAttribute   = "Naming"   // naming strategy for the wire names of a file
+	ScopeAttribute    = "Scope"    // scopes a struct, field, method or service is limited to
+)
+
+// Naming strategies that can be used with the Naming attribute.
+var wireNamings = map[string]func(string) string{
+	"none":   func(s string) string { return s },
+	"camel":  toCamelWords,
+	"pascal": toPascalWords,
+	"snake":  toSnakeWords,
+}
+
+// WireName returns the name used for the field in serialized data. It is set
+// when the file is parsed, or by Validate for a file built in code.
+func (f *Field) WireName() string {
+	if f.Wire != "" {
+		return f.Wire
+	}
+	return f.Name
+}
+
+// Naming returns the naming strategy declared for the wire names in this file,
+// or "none" if there isn't one.
+func (idl *Idl) Naming() (string, error) {
+	for _, a := range idl.Attributes {
+		if a.Scope == BabelScope && a.Name == NamingAttribute {
+			s, err := stringParameter(a)
+			if err != nil {
+				return "", err
+			}
+			if _, ok := wireNamings[s]; !ok {
+				return "", fmt.Errorf("Unknown naming strategy \"%s\", expected camel, snake, pascal or none", s)
+			}
+			return s, nil
+		}
+	}
+	return "none", nil
+}
+
+// stringParameter returns the single string parameter of an attribute.
+func stringParameter(a *Attribute) (string, error) {
+	if len(a.Parameters) != 1 || a.Parameters[0].Name != "" || a.Parameters[0].DataType != "string" {
+		return "", fmt.Errorf("%s should have a single string parameter", a.Name)
+	}
+	return a.Parameters[0].Value.(string), nil
+}
+
+// isWireName returns true if the name is usable as a wire name. Wire names are
+// restricted to identifier characters so that they can be used unquoted in
+// every target language.
+func isWireName(s string) bool {
+	for i, c := range s {
+		if c != '_' && !unicode.IsLetter(c) && !(i > 0 && unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// ResolveWireNames assigns the wire names of fields and parameters in this Idl
+// and all imports from the WireName attributes and the naming strategy of each
+// file. The parser calls it as soon as a file is parsed, and Validate calls it
+// again for files that were built or changed in code. A field keeps its wire
+// name if an error is found.
+func (idl *Idl) ResolveWireNames() error {
+	for _, i := range append([]*Idl{idl}, idl.UniqueImports()...) {
+		naming, err := i.Naming()
+		if err != nil {
+			return &Error{Source: i.Filename, Category: "Validation", Code: CodeBadAttribute, Message: err}
+		}
+		resolve := func(owner string, flds []*Field) error {
+			names := newNameScope("wire", i.Filename)
+			for _, f := range flds {
+				wire := wireNamings[naming](f.Name)
+				for _, a := range f.Attributes {
+					if a.Scope == BabelScope && a.Name == WireNameAttribute {
+						wire, err = stringParameter(a)
+						if err == nil && !isWireName(wire) {
+							err = fmt.Errorf("Invalid wire name \"%s\" for %s.%s", wire, owner, f.Name)
+						}
+						if err != nil {
+							return &Error{Source: i.Filename, Line: f.Pos.Line, Column: f.Pos.Column, Category: "Validation", Code: CodeBadAttribute, Message: err}
+						}
+					}
+				}
+				f.Wire = ""
+				if wire != f.Name {
+					f.Wire = wire
+				}
+				if err := names.add("Field", owner+"."+f.Name, wire, f.Pos); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for _, s := range i.Structs {
+			if err := resolve(s.Name, s.Fields); err != nil {
+				return err
+			}
+		}
+		for _, s := range i.Services {
+			for _, m := range s.Methods {
+				if err := resolve(s.Name+"."+m.Name, m.Parameters); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// words splits a name into words at underscores and changes of case, so that
+// "user_id", "userId" and "UserID" all split into "user" and "id" or "ID".
+func words(s string) []string {
+	result := make([]string, 0)
+	r := []rune(s)
+	start := 0
+	for i := 0; i <= len(r); i++ {
+		split := i == len(r) || r[i] == '_'
+		if !split && i > start && unicode.IsUpper(r[i]) {
+			// boundary before an upper case letter that follows a lower case letter or digit,
+			// or that starts a new word after an acronym (the S in "HTTPServer")
+			prev := r[i-1]
+			split = unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && i+1 < len(r) && unicode.IsLower(r[i+1]))
+			if split {
+				result = append(result, string(r[start:i]))
+				start = i
+			}
+			continue
+		}
+		if split {
+			if i > start {
+				result = append(result, string(r[start:i]))
+			}
+			start = i + 1
+		}
+	}
+	return result
+}
+
+// upperFirst converts the first letter of a word to upper case.
+func upperFirst(w string) string {
+	r := []rune(w)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// toCamelWords converts a name to camelCase.
+func toCamelWords(s string) string {
+	w := words(s)
+	if len(w) == 0 {
+		return s
+	}
+	res := strings.ToLower(w[0])
+	for _, x := range w[1:] {
+		res += upperFirst(x)
+	}
+	return res
+}
+
+// toPascalWords converts a name to PascalCase.
+func toPascalWords(s string) string {
+	w := words(s)
+	if len(w) == 0 {
+		return s
+	}
+	res := ""
+	for _, x := range w {
+		res += upperFirst(x)
+	}
+	return res
+}
+
+// toSnakeWords converts a name to snake_case.
+func toSnakeWords(s string) string {
+	w := words(s)
+	if len(w) == 0 {
+		return s
+	}
+	return strings.ToLower(strings.Join(w, "_"))
+}
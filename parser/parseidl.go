@@ -15,6 +15,7 @@ import __yyfmt__ "fmt"
 //line parseidl.y:14
 
 import (
+	"errors"
 	"fmt"
 	"github.com/babelrpc/babel/idl"
 	"io"
@@ -38,7 +39,7 @@ type globalData struct {
 	basedir        string
 }
 
-//line parseidl.y:41
+//line parseidl.y:42
 type yySymType struct {
 	yys         int
 	Ident       string
@@ -56,6 +57,7 @@ type yySymType struct {
 	AttrVals    []*idl.Pair
 	Initializer *idl.Pair
 	As          string
+	Pos         idl.Pos
 }
 
 const IDENT = 57346
@@ -114,9 +116,9 @@ var yyToknames = [...]string{
 	"'-'",
 	"'('",
 	"')'",
+	"','",
 	"'<'",
 	"'>'",
-	"','",
 	"'.'",
 	"'['",
 	"']'",
@@ -130,14 +132,14 @@ const yyEofCode = 1
 const yyErrCode = 2
 const yyInitialStackSize = 16
 
-//line parseidl.y:638
+//line parseidl.y:688
 
 // IdlLex is a lexer usable by yacc that uses Go's built-in lexer
 // to provide lexical analysis for IDL files.
 type IdlLex struct {
 	s        scanner.Scanner
 	Filename string
-	Errors   []string
+	Errors   idl.ErrorList
 	globals  globalData
 }
 
@@ -151,6 +153,7 @@ again:
 	if tok == scanner.EOF {
 		return 0
 	}
+	yylval.Pos = idl.Pos{Line: lex.s.Position.Line, Column: lex.s.Position.Column}
 	// fmt.Printf("lex: %s %s\n", scanner.TokenString(tok), lex.s.TokenText())
 	switch scanner.TokenString(tok) {
 	case "Ident":
@@ -176,7 +179,7 @@ again:
 			return VOID
 		case "service":
 			return SERVICE
-		case "bool", "byte", "int8", "int16", "int32", "int64", "float32", "float64", "string", "datetime", "decimal", "char":
+		case "bool", "byte", "int8", "int16", "int32", "int64", "float32", "float64", "string", "datetime", "decimal", "char", "date", "time", "duration", "uuid":
 			return BASETYPE
 		case "binary":
 			return BINARY
@@ -243,8 +246,25 @@ again:
 
 // Error is called when a parsing error occcurs. Errors are collected in an array.
 func (lex *IdlLex) Error(s string) {
+	lex.report(idl.CodeSyntax, s)
+}
+
+// report adds an error with the given code at the current position.
+func (lex *IdlLex) report(code int, s string) {
 	p := lex.s.Pos()
-	lex.Errors = append(lex.Errors, fmt.Sprintf("Line %d, col %d of %s: %s", p.Line, p.Column, lex.Filename, s))
+	lex.reportAt(idl.Pos{Line: p.Line, Column: p.Column}, code, s)
+}
+
+// reportAt adds an error with the given code at a position.
+func (lex *IdlLex) reportAt(p idl.Pos, code int, s string) {
+	lex.Errors = append(lex.Errors, &idl.Error{
+		Source:   lex.Filename,
+		Line:     p.Line,
+		Column:   p.Column,
+		Category: "Parsing",
+		Code:     code,
+		Message:  errors.New(s),
+	})
 }
 
 // Init prepares the lexer for use.
@@ -252,17 +272,22 @@ func (lex *IdlLex) Init(src io.Reader, fname string) {
 	lex.s.Init(src)
 	lex.s.Mode = scanner.ScanChars | scanner.ScanInts | scanner.ScanFloats | scanner.ScanStrings | scanner.ScanIdents | scanner.ScanComments
 	lex.Filename = fname
-	lex.Errors = make([]string, 0)
+	lex.Errors = make(idl.ErrorList, 0)
+}
+
+// abort is the panic raised by check to stop parsing after a fatal error.
+type abort struct {
+	lex *IdlLex
 }
 
 // check tests for errors logs them to lex's Error function. Fatal
-// errors result in a panic.
+// errors result in a panic, which ParseIdl recovers.
 func check(err error, fatal bool, lex yyLexer) bool {
 	if err != nil {
 		m := map[bool]string{false: "Error", true: "Fatal"}
-		lex.Error(m[fatal] + ": " + err.Error())
+		lex.(*IdlLex).report(idl.CodeDefinition, m[fatal]+": "+err.Error())
 		if fatal {
-			panic(fmt.Errorf("%s", strings.Join(lex.(*IdlLex).Errors, "\n")))
+			panic(abort{lex.(*IdlLex)})
 		}
 		return false
 	} else {
@@ -271,11 +296,12 @@ func check(err error, fatal bool, lex yyLexer) bool {
 }
 
 // ParseIdl parses the idl in the given file with tests for the given
-// language. The Idl object is returned unless an error occured.
-func ParseIdl(fileName, lang string) (*idl.Idl, error) {
+// language. The Idl object is returned unless an error occured. Parsing
+// errors are returned as an idl.ErrorList and other errors as an *idl.Error.
+func ParseIdl(fileName, lang string) (pidl *idl.Idl, err error) {
 	f, err := os.Open(fileName)
 	if err != nil {
-		return nil, fmt.Errorf("Error opening input file: %s", err)
+		return nil, &idl.Error{Source: fileName, Category: "Parsing", Code: idl.CodeOpen, Message: fmt.Errorf("Error opening input file: %s", err)}
 	}
 	defer f.Close()
 
@@ -286,175 +312,201 @@ func ParseIdl(fileName, lang string) (*idl.Idl, error) {
 	lexer.globals.pidl.Filename = filepath.ToSlash(fileName)
 	lexer.globals.basedir = filepath.ToSlash(filepath.Dir(fileName))
 
+	// a fatal error in an import stops parsing before its errors are added
+	defer func() {
+		if r := recover(); r != nil {
+			a, ok := r.(abort)
+			if !ok {
+				panic(r)
+			}
+			if a.lex != &lexer {
+				lexer.Errors = append(lexer.Errors, a.lex.Errors...)
+			}
+			pidl, err = nil, lexer.Errors
+		}
+	}()
+
 	yyParse(&lexer)
 	if len(lexer.Errors) > 0 {
-		return nil, fmt.Errorf("%s\n%d parsing errors, exiting", strings.Join(lexer.Errors, "\n"), len(lexer.Errors))
+		return nil, lexer.Errors
+	}
+
+	// wire names don't depend on the checks of Validate, so that they are
+	// known even by callers that combine files without validating them
+	err = lexer.globals.pidl.ResolveWireNames()
+	if err != nil {
+		return nil, err
 	}
 
 	err = lexer.globals.pidl.Validate(lang)
 	if err != nil {
-		return nil, fmt.Errorf("IDL does not validate: %s", err)
+		return nil, err
 	}
 
 	return lexer.globals.pidl, nil
 }
 
 //line yacctab:1
-var yyExca = [...]int{
+var yyExca = [...]int8{
 	-1, 1,
 	1, -1,
 	-2, 0,
-	-1, 14,
+	-1, 23,
 	1, 1,
-	-2, 100,
+	-2, 103,
 }
 
 const yyPrivate = 57344
 
-const yyLast = 226
-
-var yyAct = [...]int{
-	64, 132, 169, 172, 57, 19, 107, 2, 49, 82,
-	134, 31, 12, 11, 12, 58, 134, 135, 189, 38,
-	40, 23, 66, 135, 186, 20, 199, 133, 136, 137,
-	21, 131, 33, 133, 136, 137, 184, 41, 43, 42,
-	154, 34, 153, 41, 164, 42, 78, 102, 55, 41,
-	20, 42, 65, 66, 18, 21, 66, 18, 95, 91,
-	93, 88, 90, 92, 17, 190, 170, 67, 118, 150,
-	103, 80, 79, 105, 18, 182, 179, 181, 176, 178,
-	180, 83, 94, 85, 89, 73, 86, 109, 81, 110,
-	61, 113, 114, 115, 116, 117, 70, 108, 173, 62,
-	54, 177, 119, 126, 122, 123, 124, 125, 166, 71,
-	46, 45, 138, 139, 128, 47, 5, 25, 16, 129,
-	68, 29, 30, 146, 9, 8, 147, 148, 149, 5,
-	127, 151, 185, 12, 143, 145, 140, 142, 144, 187,
-	188, 155, 32, 158, 159, 160, 161, 162, 99, 101,
-	96, 98, 100, 156, 157, 165, 13, 167, 168, 141,
-	134, 120, 121, 200, 111, 112, 194, 135, 163, 152,
-	174, 74, 53, 97, 50, 175, 48, 133, 136, 137,
-	44, 36, 35, 28, 27, 12, 191, 183, 193, 171,
-	192, 84, 106, 72, 69, 76, 195, 197, 198, 63,
-	75, 104, 202, 201, 196, 60, 52, 59, 51, 22,
-	15, 7, 14, 10, 6, 3, 1, 26, 37, 130,
-	77, 87, 39, 56, 4, 24,
+const yyLast = 241
+
+var yyAct = [...]uint8{
+	39, 152, 173, 178, 6, 20, 122, 137, 2, 48,
+	154, 31, 17, 17, 21, 154, 21, 155, 30, 54,
+	55, 22, 155, 22, 198, 11, 212, 153, 156, 157,
+	16, 33, 153, 156, 157, 166, 151, 12, 45, 13,
+	211, 165, 47, 12, 168, 13, 50, 28, 12, 12,
+	13, 13, 56, 67, 63, 65, 60, 62, 64, 72,
+	192, 73, 26, 76, 77, 78, 79, 80, 66, 40,
+	41, 41, 27, 27, 27, 86, 87, 176, 177, 61,
+	41, 58, 189, 186, 188, 183, 185, 187, 195, 100,
+	194, 103, 104, 105, 106, 107, 202, 199, 164, 18,
+	94, 174, 117, 118, 132, 120, 119, 161, 184, 81,
+	135, 123, 17, 91, 93, 88, 90, 92, 129, 131,
+	126, 128, 130, 121, 125, 113, 110, 133, 85, 69,
+	138, 42, 139, 146, 142, 143, 144, 145, 89, 68,
+	179, 148, 149, 127, 97, 154, 158, 159, 160, 111,
+	108, 162, 155, 98, 172, 5, 70, 35, 5, 25,
+	43, 44, 153, 156, 157, 8, 191, 170, 196, 197,
+	169, 171, 140, 141, 147, 180, 181, 101, 102, 182,
+	74, 75, 193, 46, 14, 213, 205, 167, 163, 114,
+	84, 71, 57, 52, 51, 38, 203, 204, 201, 37,
+	19, 17, 200, 190, 175, 206, 207, 208, 209, 210,
+	124, 136, 112, 109, 116, 215, 214, 99, 115, 134,
+	96, 83, 95, 82, 32, 24, 7, 23, 15, 9,
+	3, 1, 36, 53, 150, 49, 59, 10, 29, 4,
+	34,
 }
 
-var yyPact = [...]int{
-	-1000, -1000, 119, 112, -1000, -1000, -1000, -1000, 181, 151,
-	105, 38, -1000, -10, -1000, -1000, 103, 180, 179, -1000,
-	-1000, -1000, -1000, 106, 137, -1000, 15, -1000, -1000, 178,
-	177, 0, -10, -1000, 176, 84, 83, 98, 172, -1000,
-	-1000, -1000, 170, -1000, -1000, -1000, -1000, 168, 73, 10,
-	-22, -1000, -1000, 72, -1000, -1000, -1000, 21, -1000, 92,
-	81, 167, -1000, -1000, -1000, -1000, -1000, 8, -1000, -1000,
-	43, -1000, -1000, 42, 61, -1000, 53, 54, -1000, 143,
-	40, -1000, 45, -1000, -1000, 119, -13, -1000, -13, 157,
-	-13, -13, -13, -13, 18, 39, -10, 154, -10, -10,
-	-10, -10, -13, 123, -1000, -1000, -1000, 119, 6, -1000,
-	-1000, -13, -13, -1000, -1000, -1000, -1000, -1000, 129, -1000,
-	-10, -10, -1000, -1000, -1000, -1000, -1000, -13, 41, 12,
-	165, -1000, -1000, -1000, -1000, -1000, 9, 7, -1000, -1000,
-	-13, 146, -13, -13, -13, -13, 18, -1000, -1000, -1000,
-	-1000, 164, 13, 156, 87, -1000, -13, -13, -1000, -1000,
-	-1000, -1000, -1000, 37, -1000, 74, 74, -1000, -1000, -10,
-	71, -1000, 2, 127, -11, -1000, -1000, 132, -1000, -1000,
-	-1000, -1000, -18, 33, -1000, -1000, 156, -1000, -1000, 162,
-	-10, -1000, 119, 74, -1000, -1000, 12, -8, 159, -1000,
-	37, -13, -1000,
+var yyPact = [...]int16{
+	-1000, -1000, 148, 153, -1000, -1000, 12, -1000, 179, -1000,
+	-1000, 197, -1000, 196, -19, 146, 36, -1000, 9, -26,
+	-1000, -1000, -1000, -1000, -1000, 143, 195, 191, -1000, -1000,
+	38, -1000, -1000, 145, 178, -1000, -17, -1000, -1000, -1000,
+	-1000, -1000, 8, 190, 189, 0, -19, -1000, 188, 49,
+	-1000, 112, 102, 139, 187, -1000, -1000, -1000, 47, -1000,
+	47, 173, 47, 47, 47, 47, 37, 80, -1000, -1000,
+	186, 101, -1000, -1000, 47, 47, -1000, -1000, -1000, -1000,
+	-1000, 108, -1000, -1000, 126, -1000, -1000, -1000, 47, 170,
+	47, 47, 47, 47, 37, 122, 121, 185, -1000, -1000,
+	-1000, 47, 47, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	77, -1000, -1000, 76, 96, -1000, 83, -1000, -1000, 113,
+	97, -1000, 82, -1000, -1000, 148, -19, 165, -19, -19,
+	-19, -19, 47, 167, -1000, -1000, -1000, 148, 11, -1000,
+	-19, -19, -1000, -1000, -1000, -1000, -1000, 47, 79, 6,
+	184, -1000, -1000, 67, -1000, -1000, 7, 1, -1000, -1000,
+	-1000, -1000, 183, 13, 163, 141, 150, 72, -1000, 45,
+	116, 116, 116, -19, 78, -1000, -1000, 159, 25, 177,
+	57, 55, -1000, -1000, 161, -1000, -1000, -1000, -1000, -12,
+	65, 64, -1000, -1000, 141, 141, -1000, -1000, 182, -19,
+	-1000, 148, -1000, 116, 116, -1000, -1000, 6, 5, -9,
+	181, -1000, -1000, 72, 47, -1000,
 }
 
-var yyPgo = [...]int{
-	0, 225, 1, 6, 224, 223, 8, 222, 11, 221,
-	220, 2, 3, 219, 218, 4, 217, 216, 215, 214,
-	213, 212, 211, 5, 210, 209, 208, 207, 206, 205,
-	201, 9, 200, 199, 195, 194, 193, 0, 192, 191,
-	189, 187, 186,
+var yyPgo = [...]uint8{
+	0, 240, 1, 7, 239, 238, 99, 237, 4, 236,
+	235, 2, 3, 234, 233, 18, 232, 231, 230, 229,
+	228, 227, 226, 5, 225, 224, 223, 222, 221, 220,
+	219, 6, 218, 217, 214, 213, 212, 0, 211, 210,
+	204, 203, 202,
 }
 
-var yyR1 = [...]int{
+var yyR1 = [...]int8{
 	0, 17, 18, 18, 22, 20, 20, 24, 19, 16,
 	16, 1, 21, 21, 26, 25, 28, 25, 30, 25,
 	32, 25, 33, 25, 14, 14, 27, 27, 35, 35,
 	35, 35, 35, 35, 35, 29, 29, 36, 36, 31,
 	31, 38, 34, 34, 40, 39, 13, 13, 41, 41,
-	42, 2, 2, 2, 2, 2, 12, 12, 11, 11,
-	11, 11, 11, 11, 11, 11, 11, 8, 8, 7,
-	7, 6, 6, 5, 5, 15, 15, 10, 10, 9,
-	9, 9, 9, 9, 9, 9, 9, 9, 9, 9,
-	9, 9, 9, 9, 9, 37, 37, 23, 23, 23,
-	3, 3, 4,
+	42, 2, 2, 2, 2, 2, 2, 2, 2, 12,
+	12, 11, 11, 11, 11, 11, 11, 11, 11, 11,
+	8, 8, 7, 7, 6, 6, 5, 5, 15, 15,
+	10, 10, 9, 9, 9, 9, 9, 9, 9, 9,
+	9, 9, 9, 9, 9, 9, 9, 9, 37, 37,
+	23, 23, 23, 3, 3, 4,
 }
 
-var yyR2 = [...]int{
-	0, 5, 0, 2, 3, 0, 2, 4, 5, 1,
+var yyR2 = [...]int8{
+	0, 6, 0, 2, 3, 0, 2, 4, 5, 1,
 	3, 1, 0, 2, 0, 7, 0, 7, 0, 11,
 	0, 9, 0, 8, 0, 1, 0, 2, 4, 5,
 	4, 5, 4, 4, 4, 0, 2, 4, 5, 0,
 	2, 6, 0, 2, 0, 9, 1, 1, 0, 2,
-	6, 1, 1, 1, 5, 8, 0, 2, 0, 2,
-	3, 2, 3, 2, 2, 2, 4, 0, 2, 3,
-	5, 0, 2, 2, 5, 1, 3, 0, 2, 2,
-	3, 2, 3, 2, 2, 2, 2, 4, 5, 4,
-	5, 4, 4, 4, 4, 0, 1, 0, 1, 1,
-	0, 2, 1,
+	6, 1, 4, 6, 1, 1, 5, 8, 8, 0,
+	2, 0, 2, 3, 2, 3, 2, 2, 2, 4,
+	0, 2, 3, 5, 0, 2, 2, 5, 1, 3,
+	0, 2, 2, 3, 2, 3, 2, 2, 2, 2,
+	4, 5, 4, 5, 4, 4, 4, 4, 0, 1,
+	0, 1, 1, 0, 2, 1,
 }
 
-var yyChk = [...]int{
-	-1000, -17, -3, -18, -4, 10, -19, -22, 13, 12,
-	-20, -15, 4, 5, -21, -24, 13, 26, 36, -23,
-	35, 40, -25, -3, -1, 14, -16, 4, 4, 15,
-	16, -8, 5, -23, 26, 4, 4, -14, 19, -7,
-	20, 37, 39, -23, 4, 27, 27, 17, 4, -6,
-	4, -26, -28, 4, 27, 38, -5, -15, 37, -27,
-	-29, 18, 27, -33, -37, 31, 35, -6, 28, -35,
-	4, 28, -36, 4, 4, -32, -34, -10, 38, 29,
-	29, 27, -31, 28, -39, -3, 32, -9, 7, 30,
-	8, 5, 9, 6, -15, 4, 7, 30, 8, 5,
-	9, 6, 7, 30, -30, 28, -38, -3, -8, -37,
-	-37, 7, 8, -37, -37, -37, -37, -37, 29, -23,
+var yyChk = [...]int16{
+	-1000, -17, -3, -18, -4, 10, -8, -22, 12, -19,
+	-7, 13, 37, 39, 5, -20, -15, 4, -6, 4,
+	-23, 33, 40, -21, -24, 13, 26, 36, 38, -5,
+	-15, 37, -25, -3, -1, 14, -16, 4, 4, -37,
+	31, 33, -6, 15, 16, -8, 5, -23, 26, -10,
+	38, 4, 4, -14, 19, 20, -23, 4, 32, -9,
+	7, 30, 8, 5, 9, 6, -15, 4, 27, 27,
+	17, 4, -37, -37, 7, 8, -37, -37, -37, -37,
+	-37, 29, -26, -28, 4, 27, -37, -37, 7, 30,
+	8, 5, 9, 6, -15, -27, -29, 18, 27, -33,
+	-37, 7, 8, -37, -37, -37, -37, -37, 28, -35,
+	4, 28, -36, 4, 4, -32, -34, -37, -37, 29,
+	29, 27, -31, 28, -39, -3, 7, 30, 8, 5,
+	9, 6, 7, 30, -30, 28, -38, -3, -8, -23,
 	7, 8, -23, -23, -23, -23, -37, 7, -31, -8,
-	-13, 25, -2, 21, 4, 11, 22, 23, -37, -37,
-	7, 30, 8, 5, 9, 6, -15, -23, -23, -37,
-	28, -2, 4, 33, 33, -37, 7, 8, -37, -37,
-	-37, -37, -37, 4, 31, -2, 21, -37, -37, -11,
-	29, -40, -12, 24, -12, -23, 7, 30, 8, 5,
-	9, 6, 4, -41, 34, 5, 35, 7, 8, 36,
-	32, -42, -3, -2, 4, -23, -8, -12, -2, 34,
-	4, -11, -37,
+	-13, 25, -2, 21, 4, 11, 22, 23, -23, -23,
+	-37, 28, -2, 4, 31, 34, 34, 4, 31, 7,
+	-2, 21, 4, -11, 29, -40, 32, 33, -12, 24,
+	-12, -12, -23, 7, 30, 8, 5, 9, 6, 4,
+	-41, 7, 35, 5, 33, 33, 7, 8, 36, 32,
+	-42, -3, 32, -2, -2, 4, -23, -8, -12, -12,
+	-2, 35, 35, 4, -11, -37,
 }
 
-var yyDef = [...]int{
-	100, -2, 2, 0, 101, 102, 5, 3, 0, 0,
-	12, 0, 75, 97, -2, 6, 0, 0, 0, 4,
-	98, 99, 13, 67, 0, 11, 97, 9, 76, 0,
-	0, 24, 97, 8, 0, 0, 0, 0, 0, 68,
-	25, 71, 0, 7, 10, 14, 16, 0, 0, 0,
-	0, 26, 35, 0, 22, 69, 72, 95, 71, 0,
-	0, 0, 20, 42, 73, 77, 96, 0, 15, 27,
-	0, 17, 36, 0, 0, 39, 100, 0, 70, 0,
-	0, 18, 100, 23, 43, 67, 95, 78, 95, 0,
-	95, 95, 95, 95, 95, 75, 97, 0, 97, 97,
-	97, 97, 95, 0, 39, 21, 40, 67, 0, 74,
-	79, 95, 95, 81, 83, 84, 85, 86, 0, 28,
-	97, 97, 30, 32, 33, 34, 37, 95, 100, 0,
-	0, 46, 47, 51, 52, 53, 0, 0, 80, 82,
-	95, 0, 95, 95, 95, 95, 95, 29, 31, 38,
-	19, 0, 0, 0, 0, 87, 95, 95, 89, 91,
-	92, 93, 94, 58, 44, 56, 56, 88, 90, 97,
-	0, 48, 0, 0, 0, 41, 59, 0, 61, 63,
-	64, 65, 0, 100, 54, 57, 0, 60, 62, 0,
-	97, 49, 67, 56, 66, 45, 0, 0, 0, 55,
-	58, 95, 50,
+var yyDef = [...]int8{
+	103, -2, 2, 70, 104, 105, 0, 3, 0, 5,
+	71, 0, 74, 0, 100, 12, 0, 78, 0, 0,
+	4, 101, 102, -2, 6, 0, 0, 0, 72, 75,
+	98, 74, 13, 70, 0, 11, 100, 9, 79, 76,
+	80, 99, 0, 0, 0, 24, 100, 8, 0, 0,
+	73, 0, 0, 0, 0, 25, 7, 10, 98, 81,
+	98, 0, 98, 98, 98, 98, 98, 78, 14, 16,
+	0, 0, 77, 82, 98, 98, 84, 86, 87, 88,
+	89, 0, 26, 35, 0, 22, 83, 85, 98, 0,
+	98, 98, 98, 98, 98, 0, 0, 0, 20, 42,
+	90, 98, 98, 92, 94, 95, 96, 97, 15, 27,
+	0, 17, 36, 0, 0, 39, 103, 91, 93, 0,
+	0, 18, 103, 23, 43, 70, 100, 0, 100, 100,
+	100, 100, 98, 0, 39, 21, 40, 70, 0, 28,
+	100, 100, 30, 32, 33, 34, 37, 98, 103, 0,
+	0, 46, 47, 51, 54, 55, 0, 0, 29, 31,
+	38, 19, 0, 0, 0, 0, 0, 61, 44, 0,
+	59, 59, 59, 100, 0, 48, 52, 0, 0, 0,
+	0, 0, 41, 62, 0, 64, 66, 67, 68, 0,
+	103, 0, 56, 60, 0, 0, 63, 65, 0, 100,
+	49, 70, 53, 59, 59, 69, 45, 0, 0, 0,
+	0, 57, 58, 61, 98, 50,
 }
 
-var yyTok1 = [...]int{
+var yyTok1 = [...]int8{
 	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	31, 32, 3, 3, 35, 30, 36, 26, 3, 3,
+	31, 32, 3, 3, 33, 30, 36, 26, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 40,
-	33, 29, 34, 3, 39, 3, 3, 3, 3, 3,
+	34, 29, 35, 3, 39, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 37, 3, 38, 3, 3, 3, 3, 3, 3,
@@ -463,13 +515,13 @@ var yyTok1 = [...]int{
 	3, 3, 3, 27, 3, 28,
 }
 
-var yyTok2 = [...]int{
+var yyTok2 = [...]int8{
 	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
 	12, 13, 14, 15, 16, 17, 18, 19, 20, 21,
 	22, 23, 24, 25,
 }
 
-var yyTok3 = [...]int{
+var yyTok3 = [...]int8{
 	0,
 }
 
@@ -551,9 +603,9 @@ func yyErrorMessage(state, lookAhead int) string {
 	expected := make([]int, 0, 4)
 
 	// Look for shiftable tokens.
-	base := yyPact[state]
+	base := int(yyPact[state])
 	for tok := TOKSTART; tok-1 < len(yyToknames); tok++ {
-		if n := base + tok; n >= 0 && n < yyLast && yyChk[yyAct[n]] == tok {
+		if n := base + tok; n >= 0 && n < yyLast && int(yyChk[int(yyAct[n])]) == tok {
 			if len(expected) == cap(expected) {
 				return res
 			}
@@ -563,13 +615,13 @@ func yyErrorMessage(state, lookAhead int) string {
 
 	if yyDef[state] == -2 {
 		i := 0
-		for yyExca[i] != -1 || yyExca[i+1] != state {
+		for yyExca[i] != -1 || int(yyExca[i+1]) != state {
 			i += 2
 		}
 
 		// Look for tokens that we accept or reduce.
 		for i += 2; yyExca[i] >= 0; i += 2 {
-			tok := yyExca[i]
+			tok := int(yyExca[i])
 			if tok < TOKSTART || yyExca[i+1] == 0 {
 				continue
 			}
@@ -600,30 +652,30 @@ func yylex1(lex yyLexer, lval *yySymType) (char, token int) {
 	token = 0
 	char = lex.Lex(lval)
 	if char <= 0 {
-		token = yyTok1[0]
+		token = int(yyTok1[0])
 		goto out
 	}
 	if char < len(yyTok1) {
-		token = yyTok1[char]
+		token = int(yyTok1[char])
 		goto out
 	}
 	if char >= yyPrivate {
 		if char < yyPrivate+len(yyTok2) {
-			token = yyTok2[char-yyPrivate]
+			token = int(yyTok2[char-yyPrivate])
 			goto out
 		}
 	}
 	for i := 0; i < len(yyTok3); i += 2 {
-		token = yyTok3[i+0]
+		token = int(yyTok3[i+0])
 		if token == char {
-			token = yyTok3[i+1]
+			token = int(yyTok3[i+1])
 			goto out
 		}
 	}
 
 out:
 	if token == 0 {
-		token = yyTok2[1] /* unknown char */
+		token = int(yyTok2[1]) /* unknown char */
 	}
 	if yyDebug >= 3 {
 		__yyfmt__.Printf("lex %s(%d)\n", yyTokname(token), uint(char))
@@ -678,7 +730,7 @@ yystack:
 	yyS[yyp].yys = yystate
 
 yynewstate:
-	yyn = yyPact[yystate]
+	yyn = int(yyPact[yystate])
 	if yyn <= yyFlag {
 		goto yydefault /* simple state */
 	}
@@ -689,8 +741,8 @@ yynewstate:
 	if yyn < 0 || yyn >= yyLast {
 		goto yydefault
 	}
-	yyn = yyAct[yyn]
-	if yyChk[yyn] == yytoken { /* valid shift */
+	yyn = int(yyAct[yyn])
+	if int(yyChk[yyn]) == yytoken { /* valid shift */
 		yyrcvr.char = -1
 		yytoken = -1
 		yyVAL = yyrcvr.lval
@@ -703,7 +755,7 @@ yynewstate:
 
 yydefault:
 	/* default state action */
-	yyn = yyDef[yystate]
+	yyn = int(yyDef[yystate])
 	if yyn == -2 {
 		if yyrcvr.char < 0 {
 			yyrcvr.char, yytoken = yylex1(yylex, &yyrcvr.lval)
@@ -712,18 +764,18 @@ yydefault:
 		/* look through exception table */
 		xi := 0
 		for {
-			if yyExca[xi+0] == -1 && yyExca[xi+1] == yystate {
+			if yyExca[xi+0] == -1 && int(yyExca[xi+1]) == yystate {
 				break
 			}
 			xi += 2
 		}
 		for xi += 2; ; xi += 2 {
-			yyn = yyExca[xi+0]
+			yyn = int(yyExca[xi+0])
 			if yyn < 0 || yyn == yytoken {
 				break
 			}
 		}
-		yyn = yyExca[xi+1]
+		yyn = int(yyExca[xi+1])
 		if yyn < 0 {
 			goto ret0
 		}
@@ -745,10 +797,10 @@ yydefault:
 
 			/* find a state where "error" is a legal shift action */
 			for yyp >= 0 {
-				yyn = yyPact[yyS[yyp].yys] + yyErrCode
+				yyn = int(yyPact[yyS[yyp].yys]) + yyErrCode
 				if yyn >= 0 && yyn < yyLast {
-					yystate = yyAct[yyn] /* simulate a shift of "error" */
-					if yyChk[yystate] == yyErrCode {
+					yystate = int(yyAct[yyn]) /* simulate a shift of "error" */
+					if int(yyChk[yystate]) == yyErrCode {
 						goto yystack
 					}
 				}
@@ -784,7 +836,7 @@ yydefault:
 	yypt := yyp
 	_ = yypt // guard against "declared and not used"
 
-	yyp -= yyR2[yyn]
+	yyp -= int(yyR2[yyn])
 	// yyp is now the index of $0. Perform the default action. Iff the
 	// reduced production is ε, $1 is possibly out of range.
 	if yyp+1 >= len(yyS) {
@@ -795,37 +847,41 @@ yydefault:
 	yyVAL = yyS[yyp+1]
 
 	/* consult goto table to find next state */
-	yyn = yyR1[yyn]
-	yyg := yyPgo[yyn]
+	yyn = int(yyR1[yyn])
+	yyg := int(yyPgo[yyn])
 	yyj := yyg + yyS[yyp].yys + 1
 
 	if yyj >= yyLast {
-		yystate = yyAct[yyg]
+		yystate = int(yyAct[yyg])
 	} else {
-		yystate = yyAct[yyj]
-		if yyChk[yystate] != -yyn {
-			yystate = yyAct[yyg]
+		yystate = int(yyAct[yyj])
+		if int(yyChk[yystate]) != -yyn {
+			yystate = int(yyAct[yyg])
 		}
 	}
 	// dummy call; replaced with literal code
 	switch yynt {
 
 	case 1:
-		yyDollar = yyS[yypt-5 : yypt+1]
-//line parseidl.y:107
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line parseidl.y:110
 		{
 			yylex.(*IdlLex).globals.pidl.Comments = yyDollar[1].Comments
+			yylex.(*IdlLex).globals.pidl.Attributes = yyDollar[3].Attrs
 		}
 	case 4:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parseidl.y:116
+//line parseidl.y:120
 		{
 			//fmt.Printf("import \"%s\"\n", $2)
 			fpath := path.Join(yylex.(*IdlLex).globals.basedir, yyDollar[2].String)
 			fname := filepath.FromSlash(fpath)
 
 			f, err := os.Open(fname)
-			check(err, true, yylex)
+			if err != nil {
+				yylex.(*IdlLex).reportAt(yyDollar[2].Pos, idl.CodeOpen, "Fatal: "+err.Error())
+				panic(abort{yylex.(*IdlLex)})
+			}
 			defer f.Close()
 
 			var lexer IdlLex
@@ -840,87 +896,91 @@ yydefault:
 		}
 	case 7:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parseidl.y:141
+//line parseidl.y:148
 		{
 			// fmt.Printf("namespace %s \"%s\"\n", $2, $3)
 			check(yylex.(*IdlLex).globals.pidl.AddNamespace(yyDollar[2].Ident, yyDollar[3].String), false, yylex)
 		}
 	case 8:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parseidl.y:149
+//line parseidl.y:156
 		{
 			// fmt.Printf("namespace %s \"%s\"\n", $2, $3)
 			check(yylex.(*IdlLex).globals.pidl.AddDefaultNamespace(yyDollar[2].Ident, yyDollar[4].Ident), false, yylex)
 		}
 	case 9:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parseidl.y:157
+//line parseidl.y:164
 		{
 			yyVAL.Ident = yyDollar[1].Ident
 		}
 	case 10:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parseidl.y:161
+//line parseidl.y:168
 		{
 			yyVAL.Ident = yyDollar[1].Ident + "/" + yyDollar[3].Ident
 		}
 	case 14:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parseidl.y:172
+//line parseidl.y:179
 		{
 			//fmt.Printf("const %s {\n", $2)
 			var err error
 			yylex.(*IdlLex).globals.currentConst, err = yylex.(*IdlLex).globals.pidl.AddConst(yyDollar[3].Ident)
 			check(err, true, yylex)
 			yylex.(*IdlLex).globals.currentConst.Comments = yyDollar[1].Comments
+			yylex.(*IdlLex).globals.currentConst.Pos = yyDollar[3].Pos
 		}
 	case 15:
 		yyDollar = yyS[yypt-7 : yypt+1]
-//line parseidl.y:180
+//line parseidl.y:188
 		{
 			//fmt.Printf("}\n")
 			yylex.(*IdlLex).globals.currentConst = nil
 		}
 	case 16:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parseidl.y:185
+//line parseidl.y:193
 		{
 			//fmt.Printf("enum %s {\n", $2)
 			var err error
 			yylex.(*IdlLex).globals.currentEnum, err = yylex.(*IdlLex).globals.pidl.AddEnum(yyDollar[3].Ident)
 			check(err, true, yylex)
 			yylex.(*IdlLex).globals.currentEnum.Comments = yyDollar[1].Comments
+			yylex.(*IdlLex).globals.currentEnum.Pos = yyDollar[3].Pos
 		}
 	case 17:
 		yyDollar = yyS[yypt-7 : yypt+1]
-//line parseidl.y:193
+//line parseidl.y:202
 		{
 			//fmt.Printf("}\n")
 			yylex.(*IdlLex).globals.currentEnum = nil
 		}
 	case 18:
 		yyDollar = yyS[yypt-8 : yypt+1]
-//line parseidl.y:198
+//line parseidl.y:207
 		{
 			//fmt.Printf("struct %s extends %s {\n", $5, $7)
 			var err error
 			yylex.(*IdlLex).globals.currentStruct, err = yylex.(*IdlLex).globals.pidl.AddStruct(yyDollar[5].Ident)
 			check(err, true, yylex)
 			yylex.(*IdlLex).globals.currentStruct.Extends = yyDollar[7].Ident
+			yylex.(*IdlLex).globals.currentStruct.ExtendsPos = yyDollar[7].Pos
 			yylex.(*IdlLex).globals.currentStruct.Comments = yyDollar[1].Comments
 			yylex.(*IdlLex).globals.currentStruct.Attributes = yyDollar[2].Attrs
 			yylex.(*IdlLex).globals.currentStruct.Abstract = yyDollar[3].Bool
+			yylex.(*IdlLex).globals.currentStruct.Pos = yyDollar[5].Pos
 		}
 	case 19:
 		yyDollar = yyS[yypt-11 : yypt+1]
-//line parseidl.y:209
+//line parseidl.y:220
 		{
 			//fmt.Printf("}\n")
 			yylex.(*IdlLex).globals.currentStruct = nil
 		}
 	case 20:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line parseidl.y:214
+//line parseidl.y:225
 		{
 			//fmt.Printf("struct %s {\n", $5)
 			var err error
@@ -929,17 +989,18 @@ yydefault:
 			yylex.(*IdlLex).globals.currentStruct.Comments = yyDollar[1].Comments
 			yylex.(*IdlLex).globals.currentStruct.Attributes = yyDollar[2].Attrs
 			yylex.(*IdlLex).globals.currentStruct.Abstract = yyDollar[3].Bool
+			yylex.(*IdlLex).globals.currentStruct.Pos = yyDollar[5].Pos
 		}
 	case 21:
 		yyDollar = yyS[yypt-9 : yypt+1]
-//line parseidl.y:224
+//line parseidl.y:236
 		{
 			//fmt.Printf("}\n")
 			yylex.(*IdlLex).globals.currentStruct = nil
 		}
 	case 22:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parseidl.y:229
+//line parseidl.y:241
 		{
 			//fmt.Printf("struct %s {\n", $4)
 			var err error
@@ -947,92 +1008,111 @@ yydefault:
 			check(err, true, yylex)
 			yylex.(*IdlLex).globals.currentService.Comments = yyDollar[1].Comments
 			yylex.(*IdlLex).globals.currentService.Attributes = yyDollar[2].Attrs
+			yylex.(*IdlLex).globals.currentService.Pos = yyDollar[4].Pos
 		}
 	case 23:
 		yyDollar = yyS[yypt-8 : yypt+1]
-//line parseidl.y:238
+//line parseidl.y:251
 		{
 			//fmt.Printf("}\n")
 			yylex.(*IdlLex).globals.currentService = nil
 		}
 	case 24:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line parseidl.y:245
+//line parseidl.y:258
 		{
 			yyVAL.Bool = false
 		}
 	case 25:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parseidl.y:249
+//line parseidl.y:262
 		{
 			yyVAL.Bool = true
 		}
 	case 28:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parseidl.y:258
+//line parseidl.y:271
 		{
-			check(yylex.(*IdlLex).globals.currentConst.Add(yyDollar[1].Ident, yyDollar[3].Int, "int"), false, yylex)
+			if check(yylex.(*IdlLex).globals.currentConst.Add(yyDollar[1].Ident, yyDollar[3].Int, "int"), false, yylex) {
+				yylex.(*IdlLex).globals.currentConst.FindValue(yyDollar[1].Ident).Pos = yyDollar[1].Pos
+			}
 			// fmt.Printf("\t%s = %d\n", $1, $3)
 		}
 	case 29:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parseidl.y:263
+//line parseidl.y:278
 		{
-			check(yylex.(*IdlLex).globals.currentConst.Add(yyDollar[1].Ident, -yyDollar[4].Int, "int"), false, yylex)
+			if check(yylex.(*IdlLex).globals.currentConst.Add(yyDollar[1].Ident, -yyDollar[4].Int, "int"), false, yylex) {
+				yylex.(*IdlLex).globals.currentConst.FindValue(yyDollar[1].Ident).Pos = yyDollar[1].Pos
+			}
 			// fmt.Printf("\t%s = %d\n", $1, $3)
 		}
 	case 30:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parseidl.y:268
+//line parseidl.y:285
 		{
-			check(yylex.(*IdlLex).globals.currentConst.Add(yyDollar[1].Ident, yyDollar[3].Float, "float"), false, yylex)
+			if check(yylex.(*IdlLex).globals.currentConst.Add(yyDollar[1].Ident, yyDollar[3].Float, "float"), false, yylex) {
+				yylex.(*IdlLex).globals.currentConst.FindValue(yyDollar[1].Ident).Pos = yyDollar[1].Pos
+			}
 			//fmt.Printf("\t%s = %f\n", $1, $3)
 		}
 	case 31:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parseidl.y:273
+//line parseidl.y:292
 		{
-			check(yylex.(*IdlLex).globals.currentConst.Add(yyDollar[1].Ident, -yyDollar[4].Float, "float"), false, yylex)
+			if check(yylex.(*IdlLex).globals.currentConst.Add(yyDollar[1].Ident, -yyDollar[4].Float, "float"), false, yylex) {
+				yylex.(*IdlLex).globals.currentConst.FindValue(yyDollar[1].Ident).Pos = yyDollar[1].Pos
+			}
 			//fmt.Printf("\t%s = %f\n", $1, $3)
 		}
 	case 32:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parseidl.y:278
+//line parseidl.y:299
 		{
-			check(yylex.(*IdlLex).globals.currentConst.Add(yyDollar[1].Ident, yyDollar[3].String, "string"), false, yylex)
+			if check(yylex.(*IdlLex).globals.currentConst.Add(yyDollar[1].Ident, yyDollar[3].String, "string"), false, yylex) {
+				yylex.(*IdlLex).globals.currentConst.FindValue(yyDollar[1].Ident).Pos = yyDollar[1].Pos
+			}
 			//fmt.Printf("\t%s = \"%s\"\n", $1, $3)
 		}
 	case 33:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parseidl.y:283
+//line parseidl.y:306
 		{
-			check(yylex.(*IdlLex).globals.currentConst.Add(yyDollar[1].Ident, yyDollar[3].Bool, "bool"), false, yylex)
+			if check(yylex.(*IdlLex).globals.currentConst.Add(yyDollar[1].Ident, yyDollar[3].Bool, "bool"), false, yylex) {
+				yylex.(*IdlLex).globals.currentConst.FindValue(yyDollar[1].Ident).Pos = yyDollar[1].Pos
+			}
 			//fmt.Printf("\t%s = \"%s\"\n", $1, $3)
 		}
 	case 34:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parseidl.y:288
+//line parseidl.y:313
 		{
-			check(yylex.(*IdlLex).globals.currentConst.Add(yyDollar[1].Ident, yyDollar[3].Char, "char"), false, yylex)
+			if check(yylex.(*IdlLex).globals.currentConst.Add(yyDollar[1].Ident, yyDollar[3].Char, "char"), false, yylex) {
+				yylex.(*IdlLex).globals.currentConst.FindValue(yyDollar[1].Ident).Pos = yyDollar[1].Pos
+			}
 			//fmt.Printf("\t%s = \'%c\'\n", $1, $3)
 		}
 	case 37:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parseidl.y:298
+//line parseidl.y:325
 		{
 			//fmt.Printf("\t%s = %d\n", $1, $3)
-			check(yylex.(*IdlLex).globals.currentEnum.Add(yyDollar[1].Ident, yyDollar[3].Int), false, yylex)
+			if check(yylex.(*IdlLex).globals.currentEnum.Add(yyDollar[1].Ident, yyDollar[3].Int), false, yylex) {
+				yylex.(*IdlLex).globals.currentEnum.FindValue(yyDollar[1].Ident).Pos = yyDollar[1].Pos
+			}
 		}
 	case 38:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parseidl.y:303
+//line parseidl.y:332
 		{
 			//fmt.Printf("\t%s = %d\n", $1, $3)
-			check(yylex.(*IdlLex).globals.currentEnum.Add(yyDollar[1].Ident, -yyDollar[4].Int), false, yylex)
+			if check(yylex.(*IdlLex).globals.currentEnum.Add(yyDollar[1].Ident, -yyDollar[4].Int), false, yylex) {
+				yylex.(*IdlLex).globals.currentEnum.FindValue(yyDollar[1].Ident).Pos = yyDollar[1].Pos
+			}
 		}
 	case 41:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line parseidl.y:313
+//line parseidl.y:344
 		{
 			//fmt.Printf("\t%s %s\n", $3, $4)
 			yyDollar[3].DataType.Rename = yyDollar[4].Ident
@@ -1040,14 +1120,15 @@ yydefault:
 			if check(err, false, yylex) {
 				f.Comments = yyDollar[1].Comments
 				f.Attributes = yyDollar[2].Attrs
-				if yyDollar[5].Initializer != nil {
-					check(f.SetInitializer(yyDollar[5].Initializer.Value, yyDollar[5].Initializer.DataType), false, yylex)
+				f.Pos = yyDollar[4].Pos
+				if yyDollar[5].Initializer != nil && check(f.SetInitializer(yyDollar[5].Initializer.Value, yyDollar[5].Initializer.DataType), false, yylex) {
+					f.Initializer.Pos = yyDollar[5].Initializer.Pos
 				}
 			}
 		}
 	case 44:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parseidl.y:331
+//line parseidl.y:363
 		{
 			//fmt.Printf("\t%s %s\n", $3, $4)
 			var err error
@@ -1055,28 +1136,29 @@ yydefault:
 			check(err, true, yylex)
 			yylex.(*IdlLex).globals.currentMethod.Comments = yyDollar[1].Comments
 			yylex.(*IdlLex).globals.currentMethod.Attributes = yyDollar[2].Attrs
+			yylex.(*IdlLex).globals.currentMethod.Pos = yyDollar[4].Pos
 		}
 	case 45:
 		yyDollar = yyS[yypt-9 : yypt+1]
-//line parseidl.y:340
+//line parseidl.y:373
 		{
 			yylex.(*IdlLex).globals.currentMethod = nil
 		}
 	case 46:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parseidl.y:347
+//line parseidl.y:380
 		{
 			yyVAL.DataType = &idl.Type{Name: "void"}
 		}
 	case 47:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parseidl.y:351
+//line parseidl.y:384
 		{
 			yyVAL.DataType = yyDollar[1].DataType
 		}
 	case 50:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line parseidl.y:360
+//line parseidl.y:393
 		{
 			//fmt.Printf("\t%s %s\n", $3, $4)
 			yyDollar[3].DataType.Rename = yyDollar[4].Ident
@@ -1084,145 +1166,168 @@ yydefault:
 			if check(err, false, yylex) {
 				p.Comments = yyDollar[1].Comments
 				p.Attributes = yyDollar[2].Attrs
-				if yyDollar[5].Initializer != nil {
-					check(p.SetInitializer(yyDollar[5].Initializer.Value, yyDollar[5].Initializer.DataType), false, yylex)
+				p.Pos = yyDollar[4].Pos
+				if yyDollar[5].Initializer != nil && check(p.SetInitializer(yyDollar[5].Initializer.Value, yyDollar[5].Initializer.DataType), false, yylex) {
+					p.Initializer.Pos = yyDollar[5].Initializer.Pos
 				}
 			}
 		}
 	case 51:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parseidl.y:376
+//line parseidl.y:410
 		{
 			yyVAL.DataType = &idl.Type{Name: yyDollar[1].Ident}
 		}
 	case 52:
-		yyDollar = yyS[yypt-1 : yypt+1]
-//line parseidl.y:380
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parseidl.y:414
 		{
 			yyVAL.DataType = &idl.Type{Name: yyDollar[1].Ident}
+			check(yyVAL.DataType.SetPrecision(yyDollar[3].Int, 0), false, yylex)
 		}
 	case 53:
-		yyDollar = yyS[yypt-1 : yypt+1]
-//line parseidl.y:384
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line parseidl.y:419
 		{
 			yyVAL.DataType = &idl.Type{Name: yyDollar[1].Ident}
+			check(yyVAL.DataType.SetPrecision(yyDollar[3].Int, yyDollar[5].Int), false, yylex)
 		}
 	case 54:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parseidl.y:424
+		{
+			yyVAL.DataType = &idl.Type{Name: yyDollar[1].Ident, Pos: yyDollar[1].Pos}
+		}
+	case 55:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parseidl.y:428
+		{
+			yyVAL.DataType = &idl.Type{Name: yyDollar[1].Ident}
+		}
+	case 56:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parseidl.y:388
+//line parseidl.y:432
 		{
 			yyDollar[3].DataType.Rename = yyDollar[4].As
 			yyVAL.DataType = &idl.Type{Name: "list", ValueType: yyDollar[3].DataType}
 		}
-	case 55:
+	case 57:
 		yyDollar = yyS[yypt-8 : yypt+1]
-//line parseidl.y:393
+//line parseidl.y:437
 		{
 			yyDollar[6].DataType.Rename = yyDollar[7].As
 			yyVAL.DataType = &idl.Type{Name: "map", KeyType: &idl.Type{Name: yyDollar[3].Ident, Rename: yyDollar[4].As}, ValueType: yyDollar[6].DataType}
 		}
-	case 56:
+	case 58:
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line parseidl.y:442
+		{
+			// enum keys are checked once all of the types are known
+			yyDollar[6].DataType.Rename = yyDollar[7].As
+			yyVAL.DataType = &idl.Type{Name: "map", KeyType: &idl.Type{Name: yyDollar[3].Ident, Rename: yyDollar[4].As, Pos: yyDollar[3].Pos}, ValueType: yyDollar[6].DataType}
+		}
+	case 59:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line parseidl.y:400
+//line parseidl.y:450
 		{
 			yyVAL.As = ""
 		}
-	case 57:
+	case 60:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parseidl.y:404
+//line parseidl.y:454
 		{
 			yyVAL.As = yyDollar[2].String
 		}
-	case 58:
+	case 61:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line parseidl.y:410
+//line parseidl.y:460
 		{
 			yyVAL.Initializer = nil
 		}
-	case 59:
+	case 62:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parseidl.y:414
+//line parseidl.y:464
 		{
 			yyVAL.Initializer = &idl.Pair{Value: yyDollar[2].Int, DataType: "int"}
 		}
-	case 60:
+	case 63:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parseidl.y:418
+//line parseidl.y:468
 		{
 			yyVAL.Initializer = &idl.Pair{Value: -yyDollar[3].Int, DataType: "int"}
 		}
-	case 61:
+	case 64:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parseidl.y:422
+//line parseidl.y:472
 		{
 			yyVAL.Initializer = &idl.Pair{Value: yyDollar[2].Float, DataType: "float"}
 		}
-	case 62:
+	case 65:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parseidl.y:426
+//line parseidl.y:476
 		{
 			yyVAL.Initializer = &idl.Pair{Value: -yyDollar[3].Float, DataType: "float"}
 		}
-	case 63:
+	case 66:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parseidl.y:430
+//line parseidl.y:480
 		{
 			yyVAL.Initializer = &idl.Pair{Value: yyDollar[2].String, DataType: "string"}
 		}
-	case 64:
+	case 67:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parseidl.y:434
+//line parseidl.y:484
 		{
 			yyVAL.Initializer = &idl.Pair{Value: yyDollar[2].Bool, DataType: "bool"}
 		}
-	case 65:
+	case 68:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parseidl.y:438
+//line parseidl.y:488
 		{
 			yyVAL.Initializer = &idl.Pair{Value: yyDollar[2].Char, DataType: "char"}
 		}
-	case 66:
+	case 69:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parseidl.y:442
+//line parseidl.y:492
 		{
-			yyVAL.Initializer = &idl.Pair{Value: yyDollar[2].Ident + "." + yyDollar[4].Ident, DataType: "#ref"}
+			yyVAL.Initializer = &idl.Pair{Value: yyDollar[2].Ident + "." + yyDollar[4].Ident, DataType: "#ref", Pos: yyDollar[2].Pos}
 		}
-	case 67:
+	case 70:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line parseidl.y:448
+//line parseidl.y:498
 		{
 			yyVAL.Attrs = make([]*idl.Attribute, 0)
 		}
-	case 68:
+	case 71:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parseidl.y:452
+//line parseidl.y:502
 		{
 			for i, _ := range yyDollar[2].Attrs {
 				for j := i + 1; j < len(yyDollar[2].Attrs); j++ {
 					if strings.ToLower(yyDollar[2].Attrs[i].Name) == strings.ToLower(yyDollar[2].Attrs[j].Name) && yyDollar[2].Attrs[i].Scope == "" && yyDollar[2].Attrs[j].Scope == "" {
-						yylex.Error(fmt.Sprintf("Attribute used twice: %s", yyDollar[2].Attrs[j].Name))
+						yylex.(*IdlLex).report(idl.CodeDefinition, fmt.Sprintf("Attribute used twice: %s", yyDollar[2].Attrs[j].Name))
 					}
 				}
 			}
 			for _, a1 := range yyDollar[1].Attrs {
 				for _, a2 := range yyDollar[2].Attrs {
 					if strings.ToLower(a1.Name) == strings.ToLower(a2.Name) && a1.Scope == "" && a2.Scope == "" {
-						yylex.Error(fmt.Sprintf("Attribute used twice: %s", a2.Name))
+						yylex.(*IdlLex).report(idl.CodeDefinition, fmt.Sprintf("Attribute used twice: %s", a2.Name))
 					}
 				}
 			}
 			yyVAL.Attrs = append(yyDollar[1].Attrs, yyDollar[2].Attrs...)
 		}
-	case 69:
+	case 72:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parseidl.y:473
+//line parseidl.y:523
 		{
 			// fmt.Printf("]\n")
 			yyVAL.Attrs = yyDollar[2].Attrs
 		}
-	case 70:
+	case 73:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parseidl.y:478
+//line parseidl.y:528
 		{
 			// fmt.Printf("]\n")
 			for _, a := range yyDollar[4].Attrs {
@@ -1230,15 +1335,15 @@ yydefault:
 			}
 			yyVAL.Attrs = yyDollar[4].Attrs
 		}
-	case 71:
+	case 74:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line parseidl.y:488
+//line parseidl.y:538
 		{
 			yyVAL.Attrs = make([]*idl.Attribute, 0)
 		}
-	case 72:
+	case 75:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parseidl.y:492
+//line parseidl.y:542
 		{
 			//for _, a := range($1) {
 			//	if strings.ToLower(a.Name) == strings.ToLower($2.Name) && a.Scope == "" && $2.Scope == "" {
@@ -1247,172 +1352,172 @@ yydefault:
 			//}
 			yyVAL.Attrs = append(yyDollar[1].Attrs, yyDollar[2].Attr)
 		}
-	case 73:
+	case 76:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parseidl.y:504
+//line parseidl.y:554
 		{
 			//fmt.Printf("%s ", $1)
 			yyVAL.Attr = &idl.Attribute{Name: yyDollar[1].Ident, Parameters: make([]*idl.Pair, 0)}
 		}
-	case 74:
+	case 77:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parseidl.y:509
+//line parseidl.y:559
 		{
 			//fmt.Printf(") ")
 			yyVAL.Attr = &idl.Attribute{Name: yyDollar[1].Ident, Parameters: yyDollar[3].AttrVals}
 		}
-	case 75:
+	case 78:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parseidl.y:517
+//line parseidl.y:567
 		{
 			yyVAL.Ident = yyDollar[1].Ident
 		}
-	case 76:
+	case 79:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parseidl.y:521
+//line parseidl.y:571
 		{
 			yyVAL.Ident = yyDollar[1].Ident + "." + yyDollar[3].Ident
 		}
-	case 77:
+	case 80:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line parseidl.y:527
+//line parseidl.y:577
 		{
 			yyVAL.AttrVals = make([]*idl.Pair, 0)
 		}
-	case 78:
+	case 81:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parseidl.y:531
+//line parseidl.y:581
 		{
 			yyVAL.AttrVals = append(yyDollar[1].AttrVals, yyDollar[2].AttrVal)
 		}
-	case 79:
+	case 82:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parseidl.y:538
+//line parseidl.y:588
 		{
 			//fmt.Printf("%d ", $1)
 			yyVAL.AttrVal = &idl.Pair{Value: yyDollar[1].Int, DataType: "int"}
 		}
-	case 80:
+	case 83:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parseidl.y:543
+//line parseidl.y:593
 		{
 			//fmt.Printf("%d ", $1)
 			yyVAL.AttrVal = &idl.Pair{Value: -yyDollar[2].Int, DataType: "int"}
 		}
-	case 81:
+	case 84:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parseidl.y:548
+//line parseidl.y:598
 		{
 			//fmt.Printf("%f ", $1)
 			yyVAL.AttrVal = &idl.Pair{Value: yyDollar[1].Float, DataType: "float"}
 		}
-	case 82:
+	case 85:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parseidl.y:553
+//line parseidl.y:603
 		{
 			//fmt.Printf("%f ", $1)
 			yyVAL.AttrVal = &idl.Pair{Value: -yyDollar[2].Float, DataType: "float"}
 		}
-	case 83:
+	case 86:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parseidl.y:558
+//line parseidl.y:608
 		{
 			//fmt.Printf("\"%s\" ", $1)
 			yyVAL.AttrVal = &idl.Pair{Value: yyDollar[1].String, DataType: "string"}
 		}
-	case 84:
+	case 87:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parseidl.y:563
+//line parseidl.y:613
 		{
 			//fmt.Printf("\"%d\" ", $1)
 			yyVAL.AttrVal = &idl.Pair{Value: yyDollar[1].Bool, DataType: "bool"}
 		}
-	case 85:
+	case 88:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parseidl.y:568
+//line parseidl.y:618
 		{
 			//fmt.Printf("\"%d\" ", $1)
 			yyVAL.AttrVal = &idl.Pair{Value: yyDollar[1].Char, DataType: "char"}
 		}
-	case 86:
+	case 89:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parseidl.y:573
+//line parseidl.y:623
 		{
 			//fmt.Printf("\"%d\" ", $1)
 			yyVAL.AttrVal = &idl.Pair{Value: yyDollar[1].Ident, DataType: "#ref"}
 		}
-	case 87:
+	case 90:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parseidl.y:578
+//line parseidl.y:628
 		{
 			//fmt.Printf("%s = %d ", $1, $3)
 			yyVAL.AttrVal = &idl.Pair{Name: yyDollar[1].Ident, Value: yyDollar[3].Int, DataType: "int"}
 		}
-	case 88:
+	case 91:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parseidl.y:583
+//line parseidl.y:633
 		{
 			//fmt.Printf("%s = %d ", $1, $3)
 			yyVAL.AttrVal = &idl.Pair{Name: yyDollar[1].Ident, Value: -yyDollar[4].Int, DataType: "int"}
 		}
-	case 89:
+	case 92:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parseidl.y:588
+//line parseidl.y:638
 		{
 			//fmt.Printf("%s = %f ", $1, $3)
 			yyVAL.AttrVal = &idl.Pair{Name: yyDollar[1].Ident, Value: yyDollar[3].Float, DataType: "float"}
 		}
-	case 90:
+	case 93:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parseidl.y:593
+//line parseidl.y:643
 		{
 			//fmt.Printf("%s = %f ", $1, $3)
 			yyVAL.AttrVal = &idl.Pair{Name: yyDollar[1].Ident, Value: -yyDollar[4].Float, DataType: "float"}
 		}
-	case 91:
+	case 94:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parseidl.y:598
+//line parseidl.y:648
 		{
 			//fmt.Printf("%s = \"%s\" ", $1, $3)
 			yyVAL.AttrVal = &idl.Pair{Name: yyDollar[1].Ident, Value: yyDollar[3].String, DataType: "string"}
 		}
-	case 92:
+	case 95:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parseidl.y:603
+//line parseidl.y:653
 		{
 			//fmt.Printf("%s = \"%d\" ", $1, $3)
 			yyVAL.AttrVal = &idl.Pair{Name: yyDollar[1].Ident, Value: yyDollar[3].Bool, DataType: "bool"}
 		}
-	case 93:
+	case 96:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parseidl.y:608
+//line parseidl.y:658
 		{
 			//fmt.Printf("%s = \"%d\" ", $1, $3)
 			yyVAL.AttrVal = &idl.Pair{Name: yyDollar[1].Ident, Value: yyDollar[3].Char, DataType: "char"}
 		}
-	case 94:
+	case 97:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parseidl.y:613
+//line parseidl.y:663
 		{
 			//fmt.Printf("%s = \"%d\" ", $1, $3)
 			yyVAL.AttrVal = &idl.Pair{Name: yyDollar[1].Ident, Value: yyDollar[3].Ident, DataType: "#ref"}
 		}
-	case 100:
+	case 103:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line parseidl.y:623
+//line parseidl.y:673
 		{
 			yyVAL.Comments = make([]string, 0)
 		}
-	case 101:
+	case 104:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parseidl.y:627
+//line parseidl.y:677
 		{
 			yyVAL.Comments = append(yyDollar[1].Comments, yyDollar[2].Comment)
 			// fmt.Printf("*** %s\n", $2)
 		}
-	case 102:
+	case 105:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parseidl.y:634
+//line parseidl.y:684
 		{
 			//fmt.Printf(" %s\n", $1)
 		}
@@ -14,6 +14,7 @@ package parser
 
 import (
 	"github.com/babelrpc/babel/idl"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -54,6 +55,7 @@ type globalData struct {
 	AttrVals    []*idl.Pair
 	Initializer *idl.Pair
 	As          string
+	Pos         idl.Pos
 }
 
 %token<Ident> IDENT
@@ -101,11 +103,13 @@ type globalData struct {
 IDL :
 	DocComments
 	Imports
+	AttrLists
 	DefaultNamespace
 	Namespaces
 	Definitions
 	{
 		yylex.(*IdlLex).globals.pidl.Comments = $1
+		yylex.(*IdlLex).globals.pidl.Attributes = $3
 	}
 	;
 
@@ -119,7 +123,10 @@ Import :
 		fname := filepath.FromSlash(fpath)
 
 		f, err := os.Open(fname)
-		check(err, true, yylex)
+		if err != nil {
+			yylex.(*IdlLex).reportAt($<Pos>2, idl.CodeOpen, "Fatal: " + err.Error())
+			panic(abort{yylex.(*IdlLex)})
+		}
 		defer f.Close()
 
 		var lexer IdlLex
@@ -175,6 +182,7 @@ Definition :
 		yylex.(*IdlLex).globals.currentConst, err = yylex.(*IdlLex).globals.pidl.AddConst($3)
 		check(err, true, yylex)
 		yylex.(*IdlLex).globals.currentConst.Comments = $1
+		yylex.(*IdlLex).globals.currentConst.Pos = $<Pos>3
 	}
 	Constants '}'
 	{
@@ -188,6 +196,7 @@ Definition :
 		yylex.(*IdlLex).globals.currentEnum, err = yylex.(*IdlLex).globals.pidl.AddEnum($3)
 		check(err, true, yylex)
 		yylex.(*IdlLex).globals.currentEnum.Comments = $1
+		yylex.(*IdlLex).globals.currentEnum.Pos = $<Pos>3
 	}
 	Enums '}'
 	{
@@ -201,9 +210,11 @@ Definition :
 		yylex.(*IdlLex).globals.currentStruct, err = yylex.(*IdlLex).globals.pidl.AddStruct($5)
 		check(err, true, yylex)
 		yylex.(*IdlLex).globals.currentStruct.Extends = $7
+		yylex.(*IdlLex).globals.currentStruct.ExtendsPos = $<Pos>7
 		yylex.(*IdlLex).globals.currentStruct.Comments = $1
  		yylex.(*IdlLex).globals.currentStruct.Attributes = $2
  		yylex.(*IdlLex).globals.currentStruct.Abstract = $3
+		yylex.(*IdlLex).globals.currentStruct.Pos = $<Pos>5
  	}
  	Fields '}'
  	{
@@ -219,6 +230,7 @@ Definition :
 		yylex.(*IdlLex).globals.currentStruct.Comments = $1
   		yylex.(*IdlLex).globals.currentStruct.Attributes = $2
  		yylex.(*IdlLex).globals.currentStruct.Abstract = $3
+		yylex.(*IdlLex).globals.currentStruct.Pos = $<Pos>5
 	}
  	Fields '}'
  	{
@@ -233,6 +245,7 @@ Definition :
 		check(err, true, yylex)
 		yylex.(*IdlLex).globals.currentService.Comments = $1
   		yylex.(*IdlLex).globals.currentService.Attributes = $2
+		yylex.(*IdlLex).globals.currentService.Pos = $<Pos>4
 	}
  	Methods '}'
  	{
@@ -256,37 +269,51 @@ Constants : | Constants Constant ;
 Constant :
 	IDENT '=' INT CommaSemiOptional
 	{
-		check(yylex.(*IdlLex).globals.currentConst.Add($1, $3, "int"), false, yylex)
+		if check(yylex.(*IdlLex).globals.currentConst.Add($1, $3, "int"), false, yylex) {
+			yylex.(*IdlLex).globals.currentConst.FindValue($1).Pos = $<Pos>1
+		}
 		// fmt.Printf("\t%s = %d\n", $1, $3)
 	}
 	| IDENT '=' '-' INT CommaSemiOptional
 	{
-		check(yylex.(*IdlLex).globals.currentConst.Add($1, -$4, "int"), false, yylex)
+		if check(yylex.(*IdlLex).globals.currentConst.Add($1, -$4, "int"), false, yylex) {
+			yylex.(*IdlLex).globals.currentConst.FindValue($1).Pos = $<Pos>1
+		}
 		// fmt.Printf("\t%s = %d\n", $1, $3)
 	}
 	| IDENT '=' FLOAT CommaSemiOptional
 	{
-		check(yylex.(*IdlLex).globals.currentConst.Add($1, $3, "float"), false, yylex)
+		if check(yylex.(*IdlLex).globals.currentConst.Add($1, $3, "float"), false, yylex) {
+			yylex.(*IdlLex).globals.currentConst.FindValue($1).Pos = $<Pos>1
+		}
 		//fmt.Printf("\t%s = %f\n", $1, $3)
 	}
 	| IDENT '=' '-' FLOAT CommaSemiOptional
 	{
-		check(yylex.(*IdlLex).globals.currentConst.Add($1, -$4, "float"), false, yylex)
+		if check(yylex.(*IdlLex).globals.currentConst.Add($1, -$4, "float"), false, yylex) {
+			yylex.(*IdlLex).globals.currentConst.FindValue($1).Pos = $<Pos>1
+		}
 		//fmt.Printf("\t%s = %f\n", $1, $3)
 	}
 	| IDENT '=' STRING CommaSemiOptional
 	{
-		check(yylex.(*IdlLex).globals.currentConst.Add($1, $3, "string"), false, yylex)
+		if check(yylex.(*IdlLex).globals.currentConst.Add($1, $3, "string"), false, yylex) {
+			yylex.(*IdlLex).globals.currentConst.FindValue($1).Pos = $<Pos>1
+		}
 		//fmt.Printf("\t%s = \"%s\"\n", $1, $3)
 	}
 	| IDENT '=' BOOL CommaSemiOptional
 	{
-		check(yylex.(*IdlLex).globals.currentConst.Add($1, $3, "bool"), false, yylex)
+		if check(yylex.(*IdlLex).globals.currentConst.Add($1, $3, "bool"), false, yylex) {
+			yylex.(*IdlLex).globals.currentConst.FindValue($1).Pos = $<Pos>1
+		}
 		//fmt.Printf("\t%s = \"%s\"\n", $1, $3)
 	}
 	| IDENT '=' CHAR CommaSemiOptional
 	{
-		check(yylex.(*IdlLex).globals.currentConst.Add($1, $3, "char"), false, yylex)
+		if check(yylex.(*IdlLex).globals.currentConst.Add($1, $3, "char"), false, yylex) {
+			yylex.(*IdlLex).globals.currentConst.FindValue($1).Pos = $<Pos>1
+		}
 		//fmt.Printf("\t%s = \'%c\'\n", $1, $3)
 	}
 	;
@@ -297,12 +324,16 @@ Enum :
 	IDENT '=' INT CommaOptional
 	{
 		//fmt.Printf("\t%s = %d\n", $1, $3)
-		check(yylex.(*IdlLex).globals.currentEnum.Add($1, $3), false, yylex)	
+		if check(yylex.(*IdlLex).globals.currentEnum.Add($1, $3), false, yylex) {
+			yylex.(*IdlLex).globals.currentEnum.FindValue($1).Pos = $<Pos>1
+		}
 	}
 	| IDENT '=' '-' INT CommaOptional
 	{
 		//fmt.Printf("\t%s = %d\n", $1, $3)
-		check(yylex.(*IdlLex).globals.currentEnum.Add($1, -$4), false, yylex)	
+		if check(yylex.(*IdlLex).globals.currentEnum.Add($1, -$4), false, yylex) {
+			yylex.(*IdlLex).globals.currentEnum.FindValue($1).Pos = $<Pos>1
+		}
 	}
 	;
 
@@ -317,8 +348,9 @@ Field :
 		if check(err, false, yylex) {
 			f.Comments = $1
 			f.Attributes = $2
-			if $5 != nil {
-				check(f.SetInitializer($5.Value, $5.DataType), false, yylex)
+			f.Pos = $<Pos>4
+			if $5 != nil && check(f.SetInitializer($5.Value, $5.DataType), false, yylex) {
+				f.Initializer.Pos = $5.Pos
 			}
 		}
 	}
@@ -335,6 +367,7 @@ Method :
 		check(err, true, yylex)
 		yylex.(*IdlLex).globals.currentMethod.Comments = $1
 		yylex.(*IdlLex).globals.currentMethod.Attributes = $2
+		yylex.(*IdlLex).globals.currentMethod.Pos = $<Pos>4
 	}
 	Parameters ')' CommaSemiOptional
 	{
@@ -364,8 +397,9 @@ Parameter :
 		if check(err, false, yylex) {
 			p.Comments = $1
 			p.Attributes = $2
-			if $5 != nil {
-				check(p.SetInitializer($5.Value, $5.DataType), false, yylex)
+			p.Pos = $<Pos>4
+			if $5 != nil && check(p.SetInitializer($5.Value, $5.DataType), false, yylex) {
+				p.Initializer.Pos = $5.Pos
 			}
 		}
 	}
@@ -376,9 +410,19 @@ Type :
 	{
 		$$ = &idl.Type{Name: $1}
 	}
-	| IDENT
+	| BASETYPE '(' INT ')'
 	{
 		$$ = &idl.Type{Name: $1}
+		check($$.SetPrecision($3, 0), false, yylex)
+	}
+	| BASETYPE '(' INT ',' INT ')'
+	{
+		$$ = &idl.Type{Name: $1}
+		check($$.SetPrecision($3, $5), false, yylex)
+	}
+	| IDENT
+	{
+		$$ = &idl.Type{Name: $1, Pos: $<Pos>1}
 	}
 	| BINARY
 	{
@@ -394,6 +438,12 @@ Type :
 		$6.Rename = $7
 		$$ = &idl.Type{Name: "map", KeyType: &idl.Type{Name: $3, Rename: $4}, ValueType: $6}
 	}
+	| MAP '<' IDENT OptionalAs ',' Type OptionalAs '>'
+	{
+		// enum keys are checked once all of the types are known
+		$6.Rename = $7
+		$$ = &idl.Type{Name: "map", KeyType: &idl.Type{Name: $3, Rename: $4, Pos: $<Pos>3}, ValueType: $6}
+	}
 	;
 
 OptionalAs :
@@ -440,7 +490,7 @@ OptInitializer :
 	}
 	| '=' IDENT '.' IDENT
 	{
-		$$ = &idl.Pair{Value: $2 + "." + $4, DataType: "#ref"}
+		$$ = &idl.Pair{Value: $2 + "." + $4, DataType: "#ref", Pos: $<Pos>2}
 	}
 	;
 
@@ -453,14 +503,14 @@ AttrLists :
 		for i, _ := range($2) {
 			for j := i + 1; j < len($2); j++ {
 				if strings.ToLower($2[i].Name) == strings.ToLower($2[j].Name) && $2[i].Scope == "" && $2[j].Scope == "" {
-					yylex.Error(fmt.Sprintf("Attribute used twice: %s", $2[j].Name))
+					yylex.(*IdlLex).report(idl.CodeDefinition, fmt.Sprintf("Attribute used twice: %s", $2[j].Name))
 				}
 			}
 		}
 		for _, a1 := range($1) {
 			for _, a2 := range($2) {
 				if strings.ToLower(a1.Name) == strings.ToLower(a2.Name) && a1.Scope == "" && a2.Scope == "" {
-					yylex.Error(fmt.Sprintf("Attribute used twice: %s", a2.Name))
+					yylex.(*IdlLex).report(idl.CodeDefinition, fmt.Sprintf("Attribute used twice: %s", a2.Name))
 				}
 			}
 		}
@@ -642,7 +692,7 @@ DocComment : COMMENT
 type IdlLex struct {
 	s        scanner.Scanner
 	Filename string
-	Errors   []string
+	Errors   idl.ErrorList
 	globals  globalData
 }
 
@@ -656,6 +706,7 @@ again:
 	if tok == scanner.EOF {
 		return 0
 	}
+	yylval.Pos = idl.Pos{Line: lex.s.Position.Line, Column: lex.s.Position.Column}
 	// fmt.Printf("lex: %s %s\n", scanner.TokenString(tok), lex.s.TokenText())
 	switch scanner.TokenString(tok) {
 	case "Ident":
@@ -681,7 +732,7 @@ again:
 			return VOID;
 		case "service":
 			return SERVICE
-		case "bool", "byte", "int8", "int16", "int32", "int64", "float32", "float64", "string", "datetime", "decimal", "char":
+		case "bool", "byte", "int8", "int16", "int32", "int64", "float32", "float64", "string", "datetime", "decimal", "char", "date", "time", "duration", "uuid":
 			return BASETYPE
 		case "binary":
 			return BINARY
@@ -748,8 +799,25 @@ again:
 
 // Error is called when a parsing error occcurs. Errors are collected in an array.
 func (lex *IdlLex) Error(s string) {
+	lex.report(idl.CodeSyntax, s)
+}
+
+// report adds an error with the given code at the current position.
+func (lex *IdlLex) report(code int, s string) {
 	p := lex.s.Pos()
-	lex.Errors = append(lex.Errors, fmt.Sprintf("Line %d, col %d of %s: %s", p.Line, p.Column, lex.Filename, s))
+	lex.reportAt(idl.Pos{Line: p.Line, Column: p.Column}, code, s)
+}
+
+// reportAt adds an error with the given code at a position.
+func (lex *IdlLex) reportAt(p idl.Pos, code int, s string) {
+	lex.Errors = append(lex.Errors, &idl.Error{
+		Source:   lex.Filename,
+		Line:     p.Line,
+		Column:   p.Column,
+		Category: "Parsing",
+		Code:     code,
+		Message:  errors.New(s),
+	})
 }
 
 // Init prepares the lexer for use.
@@ -757,17 +825,22 @@ func (lex *IdlLex) Init(src io.Reader, fname string) {
 	lex.s.Init(src)
 	lex.s.Mode = scanner.ScanChars | scanner.ScanInts | scanner.ScanFloats | scanner.ScanStrings | scanner.ScanIdents | scanner.ScanComments
 	lex.Filename = fname
-	lex.Errors = make([]string, 0)
+	lex.Errors = make(idl.ErrorList, 0)
+}
+
+// abort is the panic raised by check to stop parsing after a fatal error.
+type abort struct {
+	lex *IdlLex
 }
 
 // check tests for errors logs them to lex's Error function. Fatal
-// errors result in a panic.
+// errors result in a panic, which ParseIdl recovers.
 func check(err error, fatal bool, lex yyLexer) bool {
 	if err != nil {
 		m := map[bool]string { false: "Error", true: "Fatal" }
-		lex.Error(m[fatal] + ": " + err.Error())
+		lex.(*IdlLex).report(idl.CodeDefinition, m[fatal] + ": " + err.Error())
 		if fatal {
-			panic(fmt.Errorf("%s", strings.Join(lex.(*IdlLex).Errors, "\n")))
+			panic(abort{lex.(*IdlLex)})
 		}
 		return false
 	} else {
@@ -776,11 +849,12 @@ func check(err error, fatal bool, lex yyLexer) bool {
 }
 
 // ParseIdl parses the idl in the given file with tests for the given
-// language. The Idl object is returned unless an error occured.
-func ParseIdl(fileName, lang string) (*idl.Idl, error) {
+// language. The Idl object is returned unless an error occured. Parsing
+// errors are returned as an idl.ErrorList and other errors as an *idl.Error.
+func ParseIdl(fileName, lang string) (pidl *idl.Idl, err error) {
 	f, err := os.Open(fileName)
 	if err != nil {
-		return nil, fmt.Errorf("Error opening input file: %s", err)
+		return nil, &idl.Error{Source: fileName, Category: "Parsing", Code: idl.CodeOpen, Message: fmt.Errorf("Error opening input file: %s", err)}
 	}
 	defer f.Close()
 
@@ -791,14 +865,35 @@ func ParseIdl(fileName, lang string) (*idl.Idl, error) {
 	lexer.globals.pidl.Filename = filepath.ToSlash(fileName)
 	lexer.globals.basedir = filepath.ToSlash(filepath.Dir(fileName))
 
+	// a fatal error in an import stops parsing before its errors are added
+	defer func() {
+		if r := recover(); r != nil {
+			a, ok := r.(abort)
+			if !ok {
+				panic(r)
+			}
+			if a.lex != &lexer {
+				lexer.Errors = append(lexer.Errors, a.lex.Errors...)
+			}
+			pidl, err = nil, lexer.Errors
+		}
+	}()
+
 	yyParse(&lexer)
 	if len(lexer.Errors) > 0 {
-		return nil, fmt.Errorf("%s\n%d parsing errors, exiting", strings.Join(lexer.Errors, "\n"), len(lexer.Errors))
+		return nil, lexer.Errors
+	}
+
+	// wire names don't depend on the checks of Validate, so that they are
+	// known even by callers that combine files without validating them
+	err = lexer.globals.pidl.ResolveWireNames()
+	if err != nil {
+		return nil, err
 	}
 
 	err = lexer.globals.pidl.Validate(lang)
 	if err != nil {
-		return nil, fmt.Errorf("IDL does not validate: %s", err)
+		return nil, err
 	}
 
 	return lexer.globals.pidl, nil
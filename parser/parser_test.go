@@ -1,11 +1,18 @@
 package parser
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
+
+	// registers the naming rules of the languages for the collision checks
+	_ "github.com/babelrpc/babel/generator"
+	"github.com/babelrpc/babel/idl"
 )
 
 var (
@@ -61,3 +68,714 @@ func TestBabelFiles(t *testing.T) {
 		}
 	}
 }
+
+func TestNameCollisions(t *testing.T) {
+	tests := []struct {
+		file string
+		lang string
+		line int
+	}{
+		{"field_case.babel", "go", 6},
+		{"field_case.babel", "csharp", 6},
+		{"method_type.babel", "go", 9},
+	}
+
+	for _, tc := range tests {
+		pidl, err := ParseIdl(filepath.Join("test", "collisions", tc.file), "test")
+		if err != nil {
+			t.Errorf("The parser failed file \"%s\" which should have succeeded: %s", tc.file, err)
+			continue
+		}
+		err = pidl.Validate(tc.lang)
+		e, ok := err.(*idl.Error)
+		if !ok {
+			t.Errorf("Expected a collision in \"%s\" for %s, got: %v", tc.file, tc.lang, err)
+			continue
+		}
+		if e.Code != idl.CodeNameCollision || e.Line != tc.line {
+			t.Errorf("Expected error %d on line %d of \"%s\" for %s, got: %s", idl.CodeNameCollision, tc.line, tc.file, tc.lang, e)
+		}
+	}
+}
+
+func TestWireNames(t *testing.T) {
+	pidl, err := ParseIdl(filepath.Join("test", "wire_names.babel"), "test")
+	if err != nil {
+		t.Fatalf("The parser failed file \"wire_names.babel\" which should have succeeded: %s", err)
+	}
+	s := pidl.FindStruct("User")
+	if s.Fields[0].WireName() != "user_id" || s.Fields[1].WireName() != "LegacyName" {
+		t.Errorf("User has the wire names %s and %s", s.Fields[0].WireName(), s.Fields[1].WireName())
+	}
+
+	// a file that no longer validates keeps the wire names it was parsed with
+	s.Fields[1].Attributes[0].Parameters[0].Value = "Legacy Name"
+	if err = pidl.Validate("test"); err == nil {
+		t.Errorf("The invalid wire name was not reported")
+	}
+	if s.Fields[1].WireName() != "LegacyName" {
+		t.Errorf("The wire name of User.displayName was lost: %s", s.Fields[1].WireName())
+	}
+}
+
+func TestScopes(t *testing.T) {
+	tests := []struct {
+		scopes   string
+		structs  int
+		fields   int
+		services int
+		methods  int
+	}{
+		{"", 1, 1, 1, 1},
+		{"internal", 2, 2, 1, 2},
+		{"admin", 2, 1, 2, 1},
+		{"Internal,admin", 2, 2, 2, 2},
+	}
+
+	for _, tc := range tests {
+		pidl, err := ParseIdl(filepath.Join("test", "scopes", "api.babel"), "test")
+		if err != nil {
+			t.Fatalf("The parser failed file \"api.babel\" which should have succeeded: %s", err)
+		}
+		err = pidl.Prune(idl.ParseScopes(tc.scopes))
+		if err != nil {
+			t.Errorf("Pruning for scopes \"%s\" failed: %s", tc.scopes, err)
+			continue
+		}
+		if len(pidl.Structs) != tc.structs || len(pidl.Structs[0].Fields) != tc.fields || len(pidl.Services) != tc.services || len(pidl.Services[0].Methods) != tc.methods {
+			t.Errorf("Wrong definitions left for scopes \"%s\"", tc.scopes)
+		}
+	}
+
+	pidl, err := ParseIdl(filepath.Join("test", "scopes", "leak.babel"), "test")
+	if err != nil {
+		t.Fatalf("The parser failed file \"leak.babel\" which should have succeeded: %s", err)
+	}
+	err = pidl.Prune(nil)
+	e, ok := err.(*idl.Error)
+	if !ok || e.Code != idl.CodeScopeMismatch || e.Line != 9 {
+		t.Errorf("Expected error %d on line 9 of \"leak.babel\", got: %v", idl.CodeScopeMismatch, err)
+	}
+
+	// removing the internal AuditInfo must not affect the one of another namespace
+	var midl idl.Idl
+	midl.Init()
+	for _, name := range []string{"api.babel", "other.babel"} {
+		pidl, err := ParseIdl(filepath.Join("test", "scopes", name), "test")
+		if err != nil {
+			t.Fatalf("The parser failed file \"%s\" which should have succeeded: %s", name, err)
+		}
+		midl.Imports = append(midl.Imports, pidl)
+	}
+	if err = midl.Prune(nil); err != nil {
+		t.Errorf("Pruning a struct of another namespace failed: %s", err)
+	}
+	if midl.FindStruct("company.com/Test/Other.AuditInfo") == nil || midl.FindStruct("company.com/Test/Scopes.AuditInfo") != nil {
+		t.Errorf("Wrong structs left after pruning two namespaces")
+	}
+}
+
+func TestReachable(t *testing.T) {
+	tests := []struct {
+		roots   []string
+		structs string
+		enums   string
+		consts  string
+	}{
+		{[]string{"Catalog.List"}, "Common.Circle,Common.Shape,Common.Square,Reach.Item,Reach.Page", "Common.Status", "Common.Limits"},
+		{[]string{"Reports"}, "Reach.Report", "", ""},
+		{[]string{"catalog"}, "Common.Circle,Common.Shape,Common.Square,Reach.Item,Reach.Page,Reach.Report", "Common.Status", "Common.Limits"},
+	}
+
+	// the keys are written without "company.com/Test/"
+	keys := func(m map[string]bool) string {
+		arr := make([]string, 0)
+		for k := range m {
+			arr = append(arr, strings.TrimPrefix(k, "company.com/Test/"))
+		}
+		sort.Strings(arr)
+		return strings.Join(arr, ",")
+	}
+
+	for _, tc := range tests {
+		pidl, err := ParseIdl(filepath.Join("test", "reach", "api.babel"), "test")
+		if err != nil {
+			t.Fatalf("The parser failed file \"api.babel\" which should have succeeded: %s", err)
+		}
+		r, err := pidl.Reachable(tc.roots...)
+		if err != nil {
+			t.Errorf("Reachable(%v) failed: %s", tc.roots, err)
+			continue
+		}
+		if keys(r.Structs) != tc.structs || keys(r.Enums) != tc.enums || keys(r.Consts) != tc.consts {
+			t.Errorf("Reachable(%v) = %s; %s; %s", tc.roots, keys(r.Structs), keys(r.Enums), keys(r.Consts))
+		}
+		pidl.Retain(r)
+		if err = pidl.Validate("go"); err != nil {
+			t.Errorf("IDL retained for %v does not validate: %s", tc.roots, err)
+		}
+	}
+
+	pidl, err := ParseIdl(filepath.Join("test", "reach", "api.babel"), "test")
+	if err != nil {
+		t.Fatalf("The parser failed file \"api.babel\" which should have succeeded: %s", err)
+	}
+	if _, err = pidl.Reachable("Catalog.Missing"); err == nil {
+		t.Error("Reachable allowed a missing method")
+	}
+
+	// the set is computed once for several files that share an import
+	api, err := ParseIdl(filepath.Join("test", "reach", "api.babel"), "test")
+	if err != nil {
+		t.Fatalf("The parser failed file \"api.babel\" which should have succeeded: %s", err)
+	}
+	extra, err := ParseIdl(filepath.Join("test", "reach", "extra.babel"), "test")
+	if err != nil {
+		t.Fatalf("The parser failed file \"extra.babel\" which should have succeeded: %s", err)
+	}
+	r, err := idl.ReachableIn([]*idl.Idl{api, extra}, "Reports", "Catalog.List")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Common.Circle,Common.Shape,Common.Square,Extra.Triangle,Reach.Item,Reach.Page,Reach.Report"
+	if keys(r.Structs) != expected {
+		t.Errorf("ReachableIn = %s instead of %s", keys(r.Structs), expected)
+	}
+	api.Retain(r)
+	extra.Retain(r)
+	if len(extra.Structs) != 1 || extra.Structs[0].Name != "Triangle" || len(extra.Services) != 0 {
+		t.Errorf("extra.babel retained %d structs and %d services", len(extra.Structs), len(extra.Services))
+	}
+	if len(api.Services) != 2 || len(api.Services[0].Methods) != 1 {
+		t.Errorf("api.babel retained %d services", len(api.Services))
+	}
+	for _, i := range []*idl.Idl{api, extra} {
+		if common := i.Imports[0]; len(common.Structs) != 3 || len(common.Enums) != 1 || len(common.Consts) != 1 {
+			t.Errorf("%s retained %d structs of common.babel", i.Filename, len(common.Structs))
+		}
+	}
+	if _, err = idl.ReachableIn([]*idl.Idl{api, extra}, "Missing"); err == nil {
+		t.Error("ReachableIn allowed a missing service")
+	}
+}
+
+func TestBundle(t *testing.T) {
+	// imports are printed relative to the importing file, even when they are
+	// in a parent folder
+	name := filepath.Join("test", "bundle", "api", "accounts.babel")
+	pidl, err := ParseIdl(name, "test")
+	if err != nil {
+		t.Fatalf("The parser failed file \"%s\" which should have succeeded: %s", name, err)
+	}
+	f, err := ioutil.TempFile(filepath.Dir(name), "print")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	err = pidl.Print(f)
+	f.Close()
+	if err != nil {
+		t.Fatalf("Print failed: %s", err)
+	}
+	pidl, err = ParseIdl(f.Name(), "test")
+	if err != nil {
+		t.Fatalf("The parser failed the printed file: %s", err)
+	}
+	if len(pidl.Imports) != 1 || filepath.Base(pidl.Imports[0].Filename) != "common.babel" || pidl.FindStruct("Audit") == nil {
+		t.Errorf("The import of the parent folder was not kept")
+	}
+
+	files := make([]*idl.Idl, 0)
+	for _, name := range []string{"users.babel", "orders.babel"} {
+		pidl, err := ParseIdl(filepath.Join("test", "bundle", name), "test")
+		if err != nil {
+			t.Fatalf("The parser failed file \"%s\" which should have succeeded: %s", name, err)
+		}
+		files = append(files, pidl)
+	}
+	b, err := idl.Bundle(files...)
+	if err != nil {
+		t.Fatalf("Bundle failed: %s", err)
+	}
+
+	f, err = ioutil.TempFile("", "bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	err = b.Print(f)
+	f.Close()
+	if err != nil {
+		t.Fatalf("Print failed: %s", err)
+	}
+
+	for _, lang := range languages {
+		pidl, err := ParseIdl(f.Name(), lang)
+		if err != nil {
+			t.Fatalf("The parser failed the bundle for %s: %s", lang, err)
+		}
+		if len(pidl.Imports) != 0 || len(pidl.Structs) != 5 || len(pidl.Services) != 2 {
+			t.Errorf("Bundle has %d imports, %d structs and %d services", len(pidl.Imports), len(pidl.Structs), len(pidl.Services))
+		}
+		s := pidl.FindStruct("Order")
+		if s == nil || s.Fields[2].Type.ValueType.ValueType.Name != "OrdersAddress" || s.Fields[3].Type.Name != "OrdersAddress" {
+			t.Errorf("References to the colliding Address were not renamed")
+		}
+		if s = pidl.FindStruct("Audit"); s == nil || s.Fields[0].WireName() != "created_at" {
+			t.Errorf("Wire names from the Naming attribute were not kept")
+		}
+		if s = pidl.FindStruct("User"); s == nil || s.Fields[0].WireName() != "login" || s.Fields[2].Type.ValueType.Rename != "Home" {
+			t.Errorf("Attributes of User were not kept")
+		}
+		if pidl.Namespaces["java"] != "com.company.users" {
+			t.Errorf("Namespace of the first file was not used: %s", pidl.Namespaces["java"])
+		}
+	}
+
+	// services are not renamed, since that changes their URLs
+	files = make([]*idl.Idl, 0)
+	for _, name := range []string{"users.babel", filepath.Join("api", "accounts.babel")} {
+		pidl, err := ParseIdl(filepath.Join("test", "bundle", name), "test")
+		if err != nil {
+			t.Fatalf("The parser failed file \"%s\" which should have succeeded: %s", name, err)
+		}
+		files = append(files, pidl)
+	}
+	if _, err = idl.Bundle(files...); err == nil || !strings.Contains(err.Error(), "Service Users") {
+		t.Errorf("Bundle did not report the colliding services: %v", err)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	files := make([]*idl.Idl, 0)
+	for _, name := range []string{"users.babel", "orders.babel"} {
+		pidl, err := ParseIdl(filepath.Join("test", "bundle", name), "test")
+		if err != nil {
+			t.Fatalf("The parser failed file \"%s\" which should have succeeded: %s", name, err)
+		}
+		files = append(files, pidl)
+	}
+	x := idl.NewIndex(files...)
+
+	tests := []struct {
+		name string
+		refs string
+	}{
+		{"Status", "common.babel(5,6) orders.babel(12,6) orders.babel(17,19) users.babel(17,2) users.babel(17,17) users.babel(27,26) users.babel(27,41)"},
+		{"audit", "common.babel(16,8) orders.babel(9,22) users.babel(13,21)"},
+		{"Limits", "common.babel(10,7) orders.babel(10,16)"},
+		{"Order.Total", "orders.babel(11,16)"},
+		{"Users", "users.babel(21,9)"},
+	}
+	for _, tc := range tests {
+		syms := x.Lookup(tc.name)
+		if len(syms) != 1 {
+			t.Errorf("Lookup(%s) found %d symbols", tc.name, len(syms))
+			continue
+		}
+		refs := make([]string, 0)
+		for _, r := range syms[0].Refs {
+			refs = append(refs, fmt.Sprintf("%s(%d,%d)", filepath.Base(r.Filename), r.Pos.Line, r.Pos.Column))
+		}
+		if strings.Join(refs, " ") != tc.refs {
+			t.Errorf("References to %s are %s", tc.name, strings.Join(refs, " "))
+		}
+	}
+
+	if syms := x.Lookup("Address"); len(syms) != 2 {
+		t.Errorf("Lookup(Address) found %d symbols", len(syms))
+	}
+	sym := x.Lookup("Audit.CreatedBy")[0]
+	if err := x.CheckRename(sym, "Count"); err == nil {
+		t.Error("CheckRename allowed a field that collides with a field of a derived struct")
+	}
+	if err := x.CheckRename(sym, "Creator"); err != nil {
+		t.Errorf("CheckRename failed: %s", err)
+	}
+	if err := x.CheckRename(x.Lookup("Limits")[0], "Address"); err == nil {
+		t.Error("CheckRename allowed a name that is already defined")
+	}
+}
+
+func TestSymbols(t *testing.T) {
+	pidl, err := ParseIdl(filepath.Join("test", "bundle", "orders.babel"), "test")
+	if err != nil {
+		t.Fatalf("The parser failed file \"orders.babel\" which should have succeeded: %s", err)
+	}
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"Order", "orders.babel"},
+		{"status", "common.babel"},
+		{"company.com/Test/Common.Limits", "common.babel"},
+		{"COMPANY.COM/test/orders.orders", "orders.babel"},
+		{"company.com/Test/Orders.Status", ""},
+		{"Missing", ""},
+	}
+	for _, tc := range tests {
+		d := pidl.Resolve(tc.name)
+		if d == nil && tc.file != "" {
+			t.Errorf("Resolve(%s) did not find a definition", tc.name)
+		} else if d != nil && filepath.Base(d.Idl.Filename) != tc.file {
+			t.Errorf("Resolve(%s) found a definition in %s", tc.name, d.Idl.Filename)
+		}
+	}
+	if pidl.NamespaceOf("Audit", "java") != "com.company.test.common" {
+		t.Errorf("NamespaceOf(Audit) = %s", pidl.NamespaceOf("Audit", "java"))
+	}
+
+	// a definition in the file hides those of other kinds in its imports
+	var local idl.Idl
+	local.Init()
+	local.Filename = "local.babel"
+	local.AddDefaultNamespace("company.com", "Test/Local")
+	local.AddNamespace("java", "com.company.test.local")
+	imported, _ := local.AddImport("common.babel")
+	imported.AddDefaultNamespace("company.com", "Test/Common")
+	imported.AddNamespace("java", "com.company.test.common")
+	imported.AddConst("Item")
+	imported.AddEnum("Kind")
+	local.AddStruct("Item")
+	local.AddService("Kind")
+	for _, name := range []string{"Item", "kind"} {
+		if d := local.Resolve(name); d == nil || d.Idl != &local {
+			t.Errorf("Resolve(%s) did not find the definition in the file", name)
+		}
+		if ns := local.NamespaceOf(name, "java"); ns != "com.company.test.local" {
+			t.Errorf("NamespaceOf(%s) = %s", name, ns)
+		}
+	}
+	if d := local.Resolve("company.com/Test/Common.Item"); d == nil || d.Const == nil {
+		t.Error("Resolve did not find the const by its qualified name")
+	}
+
+	// lookups see imports that change after the first lookup
+	if local.FindStruct("Order") != nil || local.FindEnum("Level") != nil {
+		t.Error("Lookups found definitions that were not added yet")
+	}
+	imported.AddStruct("Order")
+	extra, _ := local.AddImport("extra.babel")
+	extra.AddEnum("Level")
+	imported.Consts[0].Name = "Entry"
+	imported.BuildSymbols()
+	if local.FindStruct("Order") == nil || local.FindEnum("Level") == nil || local.FindConst("Entry") == nil {
+		t.Error("Lookups did not see definitions added to or renamed in imports")
+	}
+
+	// lookups see definitions removed after the table was built
+	r, err := pidl.Reachable("Orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	delete(r.Structs, "company.com/Test/Orders.Address")
+	r.Methods = map[string]bool{}
+	pidl.Retain(r)
+	if pidl.FindStruct("Address") != nil || pidl.FindService("Orders") != nil {
+		t.Error("Lookups found definitions that were removed")
+	}
+}
+
+// countVisitor counts the nodes visited by idl.Walk and checks that Leave
+// calls match Enter calls.
+type countVisitor struct {
+	t      *testing.T
+	counts map[string]int
+	stack  []interface{}
+	skip   string
+}
+
+func (v *countVisitor) Enter(node interface{}) bool {
+	kind := fmt.Sprintf("%T", node)
+	v.counts[kind]++
+	if kind == v.skip {
+		return false
+	}
+	v.stack = append(v.stack, node)
+	return true
+}
+
+func (v *countVisitor) Leave(node interface{}) {
+	if len(v.stack) == 0 || v.stack[len(v.stack)-1] != node {
+		v.t.Errorf("Leave(%T) does not match Enter", node)
+		return
+	}
+	v.stack = v.stack[:len(v.stack)-1]
+}
+
+func TestWalk(t *testing.T) {
+	pidl, err := ParseIdl(filepath.Join("test", "bundle", "users.babel"), "test")
+	if err != nil {
+		t.Fatalf("The parser failed file \"users.babel\" which should have succeeded: %s", err)
+	}
+
+	tests := []struct {
+		skip   string
+		counts string
+	}{
+		{"", "*idl.Attribute=2 *idl.Const=1 *idl.Enum=1 *idl.Field=10 *idl.Idl=2 *idl.Method=2 *idl.Pair=9 *idl.Service=1 *idl.Struct=3 *idl.Type=13"},
+		{"*idl.Struct", "*idl.Attribute=1 *idl.Const=1 *idl.Enum=1 *idl.Field=3 *idl.Idl=2 *idl.Method=2 *idl.Pair=7 *idl.Service=1 *idl.Struct=3 *idl.Type=5"},
+		{"*idl.Idl", "*idl.Idl=2"},
+	}
+	for _, tc := range tests {
+		v := &countVisitor{t: t, counts: make(map[string]int), skip: tc.skip}
+		idl.Walk(v, pidl)
+		counts := make([]string, 0)
+		for k, n := range v.counts {
+			counts = append(counts, fmt.Sprintf("%s=%d", k, n))
+		}
+		sort.Strings(counts)
+		if strings.Join(counts, " ") != tc.counts {
+			t.Errorf("Walk skipping %q visited %s", tc.skip, strings.Join(counts, " "))
+		}
+		if len(v.stack) != 0 {
+			t.Errorf("Walk skipping %q did not leave %d nodes", tc.skip, len(v.stack))
+		}
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "builder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.ToSlash(filepath.Join(dir, "users.babel"))
+
+	// the import is written relative to the built file
+	data, err := ioutil.ReadFile(filepath.Join("test", "bundle", "common.babel"))
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(dir, "common.babel"), data, 0644)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	common, err := ParseIdl(filepath.Join(dir, "common.babel"), "test")
+	if err != nil {
+		t.Fatalf("The parser failed file \"common.babel\" which should have succeeded: %s", err)
+	}
+	b := idl.NewBuilder(fname, "company.com/Test/Users")
+	b.Comment("Built users.", "Second line.").Import(common).Namespace("java", "com.company.users")
+	b.Const("Defaults").Value("Name", "guest").Value("Ratio", 0.5).Value("Mark", idl.Char('*')).Value("Max", -10)
+	b.Enum("Role").Comment("A role.").Value("Admin", 1).Value("Guest", 2)
+	u := b.Struct("User").Extends("Audit").Attr("", "Table", "users", idl.NamedValue{Name: "Schema", Value: "dbo"})
+	u.Field("string", "Name").Comment("The login name.").Attr(idl.BabelScope, idl.WireNameAttribute, "login")
+	u.Field("Role", "Role").Default(idl.ValueRef("Role.Guest"))
+	u.Field("int32", "Limit").Default(idl.ValueRef("Limits.MaxItems"))
+	u.Field("decimal(10, 2)", "Balance")
+	u.Field("map<Status, list<string>>", "Tags")
+	s := b.Service("Users").Comment("User accounts.")
+	s.Method("User", "Lookup").Param("string", "name").Param("bool", "active")
+	s.Method("void", "Close").Parameter("Status", "state").Comment("The new state.").Default(idl.ValueRef("Status.Closed"))
+
+	f, err := os.Create(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Write(f)
+	f.Close()
+	if err != nil {
+		t.Fatalf("Builder failed: %s", err)
+	}
+
+	for _, lang := range languages {
+		pidl, err := ParseIdl(fname, lang)
+		if err != nil {
+			data, _ := ioutil.ReadFile(fname)
+			t.Fatalf("The parser failed the built file for %s: %s\n%s", lang, err, data)
+		}
+		var buf1, buf2 strings.Builder
+		pidl.Print(&buf1)
+		b.Write(&buf2)
+		if buf1.String() != buf2.String() {
+			t.Errorf("Printing the parsed file gives:\n%s\ninstead of:\n%s", buf1.String(), buf2.String())
+		}
+	}
+
+	bad := []func(b *idl.Builder){
+		func(b *idl.Builder) { b.Struct("A"); b.Struct("a") },
+		func(b *idl.Builder) { b.Struct("struct") },
+		func(b *idl.Builder) { b.Struct("A").Field("list<int32", "X") },
+		func(b *idl.Builder) { b.Struct("A").Field("string(10)", "X") },
+		func(b *idl.Builder) { b.Struct("A").Field("int32", "X").Default("x") },
+		func(b *idl.Builder) { b.Struct("A").Field("Missing", "X") },
+		func(b *idl.Builder) { b.Struct("A").Extends("Missing") },
+		func(b *idl.Builder) { b.Const("C").Value("X", []int{1}) },
+		func(b *idl.Builder) { b.Enum("E").Value("X", 1).Value("x", 2) },
+		func(b *idl.Builder) { b.Service("S").Method("void", "M").Param("int32", "a").Param("int32", "A") },
+		func(b *idl.Builder) { b.Comment("a */ b") },
+	}
+	for i, fn := range bad {
+		b := idl.NewBuilder("bad.babel", "company.com/Test/Bad")
+		fn(b)
+		if _, err := b.Idl(); err == nil {
+			t.Errorf("Builder case %d should have failed", i)
+		}
+	}
+}
+
+func TestBuilderRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "builder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.ToSlash(filepath.Join(dir, "orders.babel"))
+
+	b := idl.NewBuilder(fname, "company.com/Test/Orders")
+	o := b.Struct("Order")
+	o.Field("string", "Name")
+	o.Field("list<int64>", "Items")
+	o.Field("map<string, decimal(10,2)>", "Prices")
+	b.Service("Orders").Method("Order", "Get").Param("string", "name")
+	var built strings.Builder
+	if err := b.Write(&built); err != nil {
+		t.Fatalf("Builder failed: %s", err)
+	}
+	if strings.Contains(built.String(), " as ") {
+		t.Errorf("The built file renames types:\n%s", built.String())
+	}
+	if err := ioutil.WriteFile(fname, []byte(built.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pidl, err := ParseIdl(fname, "test")
+	if err != nil {
+		t.Fatalf("The parser failed the built file: %s\n%s", err, built.String())
+	}
+	var parsed strings.Builder
+	if err := pidl.Print(&parsed); err != nil {
+		t.Fatal(err)
+	}
+	if parsed.String() != built.String() {
+		t.Errorf("Printing the parsed file gives:\n%s\ninstead of:\n%s", parsed.String(), built.String())
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		file string
+		code int
+		line int
+	}{
+		{"wrong_order_bad.babel", idl.CodeSyntax, 6},
+		{"decimal_max_bad.babel", idl.CodeDefinition, 4},
+		{"deny_dupl_attr_bad.babel", idl.CodeDefinition, 4},
+		{"import_missing_bad.babel", idl.CodeOpen, 1},
+		{"duplicate1_bad.babel", idl.CodeDefinition, 9},
+		{"duplicate_field_bad.babel", idl.CodeBadStruct, 11},
+		{"undefined_map_key_bad.babel", idl.CodeUndefinedType, 4},
+		{"not_there.babel", idl.CodeOpen, 0},
+	}
+
+	d, err := idl.NewDiagnostics("babel", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range tests {
+		_, err := ParseIdl(filepath.Join("test", tc.file), "test")
+		var e *idl.Error
+		switch x := err.(type) {
+		case idl.ErrorList:
+			e = x[0]
+		case *idl.Error:
+			e = x
+		}
+		if e == nil || e.Code != tc.code || e.Line != tc.line || e.Source != filepath.Join("test", tc.file) {
+			t.Errorf("Expected error %d on line %d of \"%s\", got: %v", tc.code, tc.line, tc.file, err)
+		}
+		d.Add(err, "Parsing", idl.CodeSyntax)
+	}
+	_, err = ParseIdl(filepath.Join("test", "collisions", "import_redefined_bad.babel"), "test")
+	if e, ok := err.(*idl.Error); !ok || e.Code != idl.CodeRedefined {
+		t.Errorf("Expected error %d for a name defined in an import, got: %v", idl.CodeRedefined, err)
+	}
+	d.Warn(fmt.Errorf("No files match"), "Command line", idl.CodeNoFiles)
+	if !d.HasErrors() || len(d.Records) != len(tests)+1 {
+		t.Fatalf("Expected %d diagnostics, got %d", len(tests)+1, len(d.Records))
+	}
+
+	var buf strings.Builder
+	d.Write(&buf)
+	var out struct {
+		Diagnostics []struct {
+			File     string
+			Line     int
+			Severity string
+			Code     int
+		}
+	}
+	if err := json.Unmarshal([]byte(buf.String()), &out); err != nil {
+		t.Fatalf("Invalid JSON diagnostics: %s\n%s", err, buf.String())
+	}
+	last := out.Diagnostics[len(out.Diagnostics)-1]
+	if out.Diagnostics[0].Code != idl.CodeSyntax || out.Diagnostics[0].Line != 6 || last.Severity != "warning" || last.File != "" {
+		t.Errorf("Wrong JSON diagnostics:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	d.Format = "sarif"
+	d.Write(&buf)
+	var log struct {
+		Version string
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct{ ID string }
+				}
+			}
+			Results []struct {
+				RuleID    string
+				Level     string
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ URI string }
+						Region           struct{ StartLine int }
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(buf.String()), &log); err != nil {
+		t.Fatalf("Invalid SARIF diagnostics: %s\n%s", err, buf.String())
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != len(tests)+1 || len(log.Runs[0].Tool.Driver.Rules) != 6 {
+		t.Fatalf("Wrong SARIF diagnostics:\n%s", buf.String())
+	}
+	r := log.Runs[0].Results[0]
+	if r.RuleID != "201" || r.Level != "error" || r.Locations[0].PhysicalLocation.ArtifactLocation.URI != "test/wrong_order_bad.babel" || r.Locations[0].PhysicalLocation.Region.StartLine != 6 {
+		t.Errorf("Wrong SARIF result: %+v", r)
+	}
+
+	if _, err := idl.NewDiagnostics("babel", "xml"); err == nil {
+		t.Errorf("NewDiagnostics allowed an unknown format")
+	}
+
+	// the tools write text without a format, and records with one
+	buf.Reset()
+	text := idl.TextDiagnostics("babel", &buf)
+	text.Errorf(fmt.Errorf("No files"), "Command line", idl.CodeNoFiles, "Error: %s\n", "No files")
+	text.Notef("Already processed %s\n", "a.babel")
+	text.Flush()
+	if buf.String() != "Error: No files\nAlready processed a.babel\n" {
+		t.Errorf("Wrong text diagnostics:\n%s", buf.String())
+	}
+	warning := idl.Warning
+	defer func() { idl.Warning = warning }()
+	buf.Reset()
+	d, err = idl.StartDiagnostics("babel", "msbuild", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Errorf(fmt.Errorf("No files"), "Command line", idl.CodeNoFiles, "Error: %s\n", "No files")
+	d.Notef("Already processed %s\n", "a.babel")
+	if buf.Len() != 0 {
+		t.Errorf("Diagnostics were written before Flush:\n%s", buf.String())
+	}
+	d.Flush()
+	if buf.String() != "babel: Command line error 102: No files\n" || len(d.Records) != 0 {
+		t.Errorf("Wrong msbuild diagnostics:\n%s", buf.String())
+	}
+	if _, err := idl.StartDiagnostics("babel", "xml", &buf); err == nil {
+		t.Errorf("StartDiagnostics allowed an unknown format")
+	}
+}
@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Client calls the methods of Babel services over HTTP. Generated clients use
+// it to send their requests. A Client may be shared by several services and
+// used by several goroutines at once, as long as it is not changed.
+type Client struct {
+	BaseURL    string       // address of the folder holding the services, such as "http://localhost:8080/api"
+	HTTPClient *http.Client // client used to send requests; http.DefaultClient when nil
+	Header     http.Header  // headers added to every request
+}
+
+// NewClient creates a client for the services at baseURL.
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: baseURL}
+}
+
+// Call invokes a method of a service. req is encoded as the JSON body of the
+// request and the body of a successful response is decoded into rsp. A
+// ServiceError returned by the server is returned as a *ServiceError.
+func (c *Client) Call(service, method string, req, rsp interface{}) error {
+	return c.CallContext(context.Background(), service, method, req, rsp)
+}
+
+// CallContext is like Call, but the request is sent with the given context so
+// it can be cancelled or given a deadline.
+func (c *Client) CallContext(ctx context.Context, service, method string, req, rsp interface{}) error {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", service, method, err)
+	}
+	httpreq, err := http.NewRequestWithContext(ctx, "POST", c.url(service, method), bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	for k, v := range c.Header {
+		for _, vi := range v {
+			httpreq.Header.Add(k, vi)
+		}
+	}
+	httpreq.Header.Set("Content-Type", "application/json")
+	httpreq.Header.Set("Accept", "application/json")
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	httprsp, err := hc.Do(httpreq)
+	if err != nil {
+		return err
+	}
+	defer httprsp.Body.Close()
+	body, err := ioutil.ReadAll(httprsp.Body)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", service, method, err)
+	}
+
+	if httprsp.StatusCode < 200 || httprsp.StatusCode > 299 {
+		var se ServiceError
+		if json.Unmarshal(body, &se) == nil && (len(se.Errors) > 0 || se.Details != "") {
+			return &se
+		}
+		return fmt.Errorf("%s.%s: %s", service, method, httprsp.Status)
+	}
+	if rsp != nil && len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, rsp); err != nil {
+			return fmt.Errorf("%s.%s: invalid response: %w", service, method, err)
+		}
+	}
+	return nil
+}
+
+// url returns the address of a method.
+func (c *Client) url(service, method string) string {
+	return strings.TrimSuffix(c.BaseURL, "/") + "/" + service + "/" + method
+}
@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type lookupRequest struct {
+	Name *string `json:"name,omitempty"`
+}
+
+type lookupResponse struct {
+	Value *string `json:"Value,omitempty"`
+}
+
+func TestCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Wrong request: %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if r.Header.Get("X-Trace") != "abc" {
+			t.Errorf("Missing header: %v", r.Header)
+		}
+		var req lookupRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		switch r.URL.Path {
+		case "/api/Users/Lookup":
+			w.Write([]byte(`{"Value":"Hello ` + *req.Name + `"}`))
+		case "/api/Users/Fail":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"Errors":[{"Code":"NotFound","Message":"No such user"}],"Details":"trace"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL + "/api/")
+	c.Header = http.Header{"X-Trace": []string{"abc"}}
+	name := "Bob"
+
+	var rsp lookupResponse
+	if err := c.Call("Users", "Lookup", &lookupRequest{&name}, &rsp); err != nil {
+		t.Fatal(err)
+	}
+	if rsp.Value == nil || *rsp.Value != "Hello Bob" {
+		t.Errorf("Wrong response: %v", rsp.Value)
+	}
+
+	err := c.Call("Users", "Fail", &lookupRequest{&name}, &rsp)
+	var se *ServiceError
+	if !errors.As(err, &se) {
+		t.Fatalf("Expected a ServiceError, got %v", err)
+	}
+	if se.Error() != "NotFound: No such user" || se.Details != "trace" {
+		t.Errorf("Wrong service error: %s (%s)", se, se.Details)
+	}
+
+	err = c.Call("Users", "Missing", &lookupRequest{&name}, &rsp)
+	if err == nil || errors.As(err, &se) {
+		t.Errorf("Expected a status error, got %v", err)
+	}
+}
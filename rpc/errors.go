@@ -0,0 +1,64 @@
+/*
+	Package rpc is the runtime used by generated Go code to call and serve Babel
+	services over HTTP.
+
+	A Babel method is called by POSTing a JSON object holding its parameters to
+	<base URL>/<Service>/<Method>. A successful call returns a JSON object with
+	the result in its Value field; a failed call returns a ServiceError, the
+	error format defined in babeltemplates/error.babel.
+
+	Client sends the requests of generated clients. Server is an http.Handler
+	that dispatches requests to the invokers generated for a service:
+
+		s := rpc.NewServer()
+		s.Use(logCalls)
+		users.RegisterUsers(s, &usersImpl{})
+		http.ListenAndServe(":8080", s)
+*/
+package rpc
+
+import (
+	"strings"
+	"time"
+)
+
+// Error defines a single error message and code that might be localized and
+// displayed to a caller.
+type Error struct {
+	Code    string   `json:"Code,omitempty"`    // service-specific error code
+	Message string   `json:"Message,omitempty"` // text of the error in US-English
+	Params  []string `json:"Params,omitempty"`  // parameters to the error message, for localization
+}
+
+// ServiceError defines the error response generated by Babel for service
+// failures.
+type ServiceError struct {
+	Time    *time.Time                   `json:"Time,omitempty"`    // time of the error
+	Tags    []string                     `json:"Tags,omitempty"`    // tags to categorize the error
+	Errors  []*Error                     `json:"Errors,omitempty"`  // errors that occurred
+	Context map[string]map[string]string `json:"Context,omitempty"` // additional error details and context
+	Details string                       `json:"Details,omitempty"` // server-specific details, such as a stack trace
+	Inner   *ServiceError                `json:"Inner,omitempty"`   // service error propagated from another tier
+}
+
+// Implement the error interface
+func (e *ServiceError) Error() string {
+	s := make([]string, 0, len(e.Errors))
+	for _, x := range e.Errors {
+		if x == nil {
+			continue
+		}
+		if x.Code != "" {
+			s = append(s, x.Code+": "+x.Message)
+		} else {
+			s = append(s, x.Message)
+		}
+	}
+	if len(s) == 0 {
+		if e.Details != "" {
+			return e.Details
+		}
+		return "service error"
+	}
+	return strings.Join(s, "; ")
+}
@@ -0,0 +1,149 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Call is a method call handled by a Server.
+type Call struct {
+	Service     string        // name of the service
+	Method      string        // name of the method
+	Request     interface{}   // decoded request, such as *UsersLookupRequest
+	HTTPRequest *http.Request // HTTP request the call was received in
+
+	// Context is passed to methods generated with the ctx option. It starts as
+	// the context of the HTTP request; middleware can replace it to add
+	// deadlines or request-scoped values.
+	Context context.Context
+}
+
+// Invoker invokes the method of a call and returns its response, such as
+// *UsersLookupResponse.
+type Invoker func(c *Call) (interface{}, error)
+
+// Middleware wraps an Invoker, for example to check credentials, log calls or
+// recover from panics. It can inspect and change the call before passing it to
+// next, or return without calling next.
+type Middleware func(next Invoker) Invoker
+
+// method is a registered method.
+type method struct {
+	newRequest func() interface{}
+	invoke     Invoker
+}
+
+// Server is an http.Handler that routes POST requests for <Service>/<Method>
+// to the methods registered with it. Generated Register<Service> functions
+// register the methods of a service. The last two elements of the request
+// path are used, so the server can be mounted under any prefix.
+type Server struct {
+	mu         sync.RWMutex
+	methods    map[string]*method
+	middleware []Middleware
+}
+
+// NewServer creates a server without any methods.
+func NewServer() *Server {
+	return &Server{methods: make(map[string]*method)}
+}
+
+// Use adds middleware to the server. The first middleware added is the
+// outermost one, called first for each call.
+func (s *Server) Use(m ...Middleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.middleware = append(s.middleware, m...)
+}
+
+// Register adds a method to the server. newRequest creates the request that
+// the body of an HTTP request is decoded into, and invoke calls the method.
+func (s *Server) Register(service, name string, newRequest func() interface{}, invoke Invoker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods[service+"/"+name] = &method{newRequest: newRequest, invoke: invoke}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+	elems := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(elems) < 2 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no service method in %s", r.URL.Path))
+		return
+	}
+	c := &Call{Service: elems[len(elems)-2], Method: elems[len(elems)-1], HTTPRequest: r, Context: r.Context()}
+
+	s.mu.RLock()
+	m, ok := s.methods[c.Service+"/"+c.Method]
+	invoke := m.chain(s.middleware)
+	s.mu.RUnlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%s.%s is not a known method", c.Service, c.Method))
+		return
+	}
+
+	c.Request = m.newRequest()
+	if err := json.NewDecoder(r.Body).Decode(c.Request); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	rsp, err := invoke(c)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rsp)
+}
+
+// chain wraps the method's invoker in the middleware.
+func (m *method) chain(middleware []Middleware) Invoker {
+	if m == nil {
+		return nil
+	}
+	invoke := m.invoke
+	for i := len(middleware) - 1; i >= 0; i-- {
+		invoke = middleware[i](invoke)
+	}
+	return invoke
+}
+
+// ToServiceError returns err as a ServiceError. A *ServiceError, even when
+// wrapped, is returned as is; other errors become a ServiceError holding their
+// message.
+func ToServiceError(err error) *ServiceError {
+	var se *ServiceError
+	if errors.As(err, &se) {
+		return se
+	}
+	now := time.Now().UTC()
+	return &ServiceError{Time: &now, Errors: []*Error{{Message: err.Error()}}}
+}
+
+// writeError writes an error as a ServiceError.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ToServiceError(err))
+}
+
+// writeJSON writes a value as the JSON body of a response.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		status = http.StatusInternalServerError
+		b, _ = json.Marshal(ToServiceError(err))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+}
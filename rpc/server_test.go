@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	s := NewServer()
+	s.Register("Users", "Lookup", func() interface{} { return new(lookupRequest) }, func(c *Call) (interface{}, error) {
+		req := c.Request.(*lookupRequest)
+		if req.Name == nil {
+			return nil, errors.New("name is required")
+		}
+		v := "Hello " + *req.Name
+		return &lookupResponse{&v}, nil
+	})
+	s.Register("Users", "Fail", func() interface{} { return new(lookupRequest) }, func(c *Call) (interface{}, error) {
+		return nil, &ServiceError{Errors: []*Error{{Code: "NotFound", Message: "No such user"}}}
+	})
+	calls := make([]string, 0)
+	s.Use(func(next Invoker) Invoker {
+		return func(c *Call) (interface{}, error) {
+			calls = append(calls, "outer "+c.Service+"."+c.Method)
+			return next(c)
+		}
+	}, func(next Invoker) Invoker {
+		return func(c *Call) (interface{}, error) {
+			calls = append(calls, "inner")
+			if c.HTTPRequest.Header.Get("X-Trace") == "deny" {
+				return nil, errors.New("denied")
+			}
+			return next(c)
+		}
+	})
+	srv := httptest.NewServer(http.StripPrefix("/api", s))
+	defer srv.Close()
+
+	c := NewClient(srv.URL + "/api")
+	name := "Bob"
+	var rsp lookupResponse
+	if err := c.Call("Users", "Lookup", &lookupRequest{&name}, &rsp); err != nil {
+		t.Fatal(err)
+	}
+	if rsp.Value == nil || *rsp.Value != "Hello Bob" {
+		t.Errorf("Wrong response: %v", rsp.Value)
+	}
+	if strings.Join(calls, ",") != "outer Users.Lookup,inner" {
+		t.Errorf("Wrong middleware calls: %v", calls)
+	}
+
+	tests := []struct {
+		method, header string
+		req            interface{}
+		expected       string
+	}{
+		{"Lookup", "", &lookupRequest{}, "name is required"},
+		{"Fail", "", &lookupRequest{}, "NotFound: No such user"},
+		{"Lookup", "deny", &lookupRequest{&name}, "denied"},
+		{"Missing", "", &lookupRequest{}, "Users.Missing is not a known method"},
+		{"Lookup", "", "bad", "invalid request: json: cannot unmarshal string into Go value of type rpc.lookupRequest"},
+	}
+	for _, tc := range tests {
+		c.Header = http.Header{"X-Trace": []string{tc.header}}
+		err := c.Call("Users", tc.method, tc.req, &rsp)
+		var se *ServiceError
+		if !errors.As(err, &se) || se.Error() != tc.expected {
+			t.Errorf("Calling %s returned %v instead of %q", tc.method, err, tc.expected)
+		}
+	}
+
+	r := httptest.NewRecorder()
+	s.ServeHTTP(r, httptest.NewRequest("GET", "/Users/Lookup", nil))
+	if r.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET returned %d", r.Code)
+	}
+}
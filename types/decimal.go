@@ -0,0 +1,101 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// CheckDecimal verifies that the decimal string s fits in precision digits, of
+// which at most scale are after the decimal point. Generated code and the
+// Babel proxy use it to enforce decimal(precision, scale) declarations.
+func CheckDecimal(s string, precision, scale int) error {
+	// big.Rat also accepts fractions and base prefixes, which are not decimals
+	if strings.Trim(s, "+-0123456789.eE") != "" {
+		return fmt.Errorf("invalid decimal %q", s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return fmt.Errorf("invalid decimal %q", s)
+	}
+	return CheckRat(r, precision, scale)
+}
+
+// CheckRat verifies that r fits in precision digits, of which at most scale
+// are after the decimal point. A nil value is always valid.
+func CheckRat(r *big.Rat, precision, scale int) error {
+	if r == nil {
+		return nil
+	}
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(scale)))
+	if !scaled.IsInt() {
+		return fmt.Errorf("%s has more than %d digits after the decimal point", r.FloatString(scale+1), scale)
+	}
+	if new(big.Int).Abs(scaled.Num()).Cmp(pow10(precision)) >= 0 {
+		return fmt.Errorf("%s has more than %d digits", r.FloatString(scale), precision)
+	}
+	return nil
+}
+
+// RoundDecimal returns r rounded to scale digits after the decimal point, with
+// halves rounded away from zero. A nil value is returned as nil.
+func RoundDecimal(r *big.Rat, scale int) *big.Rat {
+	if r == nil {
+		return nil
+	}
+	p := pow10(scale)
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(p))
+	// round |num/den| by computing (2*|num| + den) / (2*den)
+	num := new(big.Int).Abs(scaled.Num())
+	den := scaled.Denom()
+	num.Add(num.Lsh(num, 1), den)
+	num.Quo(num, new(big.Int).Lsh(den, 1))
+	if r.Sign() < 0 {
+		num.Neg(num)
+	}
+	return new(big.Rat).SetFrac(num, p)
+}
+
+// RoundDecimalString returns the decimal string s rounded to scale digits after
+// the decimal point. Empty and invalid strings are returned unchanged, so that
+// CheckDecimal can report them.
+func RoundDecimalString(s string, scale int) string {
+	if s == "" || strings.Trim(s, "+-0123456789.eE") != "" {
+		return s
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return s
+	}
+	return RoundDecimal(r, scale).FloatString(scale)
+}
+
+// CheckFloat verifies that f fits in precision digits, of which at most scale
+// are after the decimal point. A nil value is always valid.
+func CheckFloat(f *big.Float, precision, scale int) error {
+	if f == nil {
+		return nil
+	}
+	if f.IsInf() {
+		return fmt.Errorf("%s is not a decimal", f)
+	}
+	r, _ := f.Rat(nil)
+	return CheckRat(r, precision, scale)
+}
+
+// RoundFloat returns f rounded to scale digits after the decimal point, with
+// halves rounded away from zero. The result has the precision of f, so it is
+// the closest value that f's precision can hold. A nil or infinite value is
+// returned unchanged.
+func RoundFloat(f *big.Float, scale int) *big.Float {
+	if f == nil || f.IsInf() {
+		return f
+	}
+	r, _ := f.Rat(nil)
+	return new(big.Float).SetPrec(f.Prec()).SetRat(RoundDecimal(r, scale))
+}
+
+// pow10 returns 10 to the power n.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
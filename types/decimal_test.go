@@ -0,0 +1,83 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestCheckDecimal(t *testing.T) {
+	good := []string{"0", "123.45", "-999.99", "1e2", "0.10", "+5"}
+	for _, s := range good {
+		if err := CheckDecimal(s, 5, 2); err != nil {
+			t.Errorf("CheckDecimal(%q, 5, 2) failed: %v", s, err)
+		}
+	}
+	bad := []string{"1000", "-1000.00", "0.001", "1e3", "1/2", "0x10", "", "abc"}
+	for _, s := range bad {
+		if err := CheckDecimal(s, 5, 2); err == nil {
+			t.Errorf("CheckDecimal(%q, 5, 2) should have failed", s)
+		}
+	}
+	if err := CheckRat(nil, 5, 2); err != nil {
+		t.Errorf("CheckRat(nil) failed: %v", err)
+	}
+}
+
+func TestRoundDecimal(t *testing.T) {
+	cases := map[string]string{
+		"1.005":  "1.01",
+		"-1.005": "-1.01",
+		"1.004":  "1.00",
+		"2.5":    "2.50",
+		"-0.001": "0.00",
+	}
+	for in, want := range cases {
+		r, _ := new(big.Rat).SetString(in)
+		if got := RoundDecimal(r, 2).FloatString(2); got != want {
+			t.Errorf("RoundDecimal(%s, 2) = %s; want %s", in, got, want)
+		}
+	}
+	r, _ := new(big.Rat).SetString("12.5")
+	if got := RoundDecimal(r, 0).FloatString(0); got != "13" {
+		t.Errorf("RoundDecimal(12.5, 0) = %s; want 13", got)
+	}
+	if RoundDecimal(nil, 2) != nil {
+		t.Error("RoundDecimal(nil) should be nil")
+	}
+}
+
+func TestDecimalStrings(t *testing.T) {
+	cases := map[string]string{
+		"1.005": "1.01",
+		"-2.5":  "-2.50",
+		"1e2":   "100.00",
+		"":      "",
+		"abc":   "abc",
+	}
+	for in, want := range cases {
+		if got := RoundDecimalString(in, 2); got != want {
+			t.Errorf("RoundDecimalString(%q, 2) = %q; want %q", in, got, want)
+		}
+	}
+}
+
+func TestFloats(t *testing.T) {
+	f := big.NewFloat(1.125)
+	if got := RoundFloat(f, 2).Text('f', 2); got != "1.13" {
+		t.Errorf("RoundFloat(1.125, 2) = %s; want 1.13", got)
+	}
+	if RoundFloat(f, 2).Prec() != f.Prec() {
+		t.Errorf("RoundFloat changed the precision")
+	}
+	if err := CheckFloat(big.NewFloat(999.5), 5, 2); err != nil {
+		t.Errorf("CheckFloat(999.5, 5, 2) failed: %v", err)
+	}
+	for _, x := range []float64{1000, 0.001} {
+		if err := CheckFloat(big.NewFloat(x), 5, 2); err == nil {
+			t.Errorf("CheckFloat(%v, 5, 2) should have failed", x)
+		}
+	}
+	if RoundFloat(nil, 2) != nil || CheckFloat(nil, 5, 2) != nil {
+		t.Error("A nil float should be left alone")
+	}
+}
@@ -0,0 +1,283 @@
+/*
+	Package types provides Go representations of the Babel primitive types that
+	have no standard library equivalent with the Babel wire format. Generated Go
+	code uses these types for the date, time, duration and uuid primitives.
+
+	All of the types are serialized as JSON strings:
+
+		date      "2006-01-02"                              ISO 8601 calendar date
+		time      "15:04:05" or "15:04:05.999999999"        ISO 8601 time of day, no zone
+		duration  "PT1H30M", "P2DT4.5S" or "-PT5S"          ISO 8601 duration in days, hours, minutes and seconds
+		uuid      "7d444840-9dc0-11d1-b245-5ffdce74fad2"    RFC 4122 UUID in lower case
+
+	The package also has helpers to check and round decimals declared with a
+	precision and scale, such as decimal(18,2).
+*/
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Date is a calendar date without a time or time zone.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// DateOf returns the date of the given time in its location.
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{Year: y, Month: m, Day: d}
+}
+
+// ParseDate parses a date in the format "2006-01-02".
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q", s)
+	}
+	return DateOf(t), nil
+}
+
+// String returns the date in the format "2006-01-02".
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// In returns the time at midnight on the date in the given location.
+func (d Date) In(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Date) UnmarshalText(b []byte) error {
+	v, err := ParseDate(string(b))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// Time is a time of day without a date or time zone.
+type Time struct {
+	Hour       int
+	Minute     int
+	Second     int
+	Nanosecond int
+}
+
+// TimeOf returns the time of day of the given time in its location.
+func TimeOf(t time.Time) Time {
+	return Time{Hour: t.Hour(), Minute: t.Minute(), Second: t.Second(), Nanosecond: t.Nanosecond()}
+}
+
+// ParseTime parses a time of day in the format "15:04:05" with optional
+// fractional seconds.
+func ParseTime(s string) (Time, error) {
+	t, err := time.Parse("15:04:05.999999999", s)
+	if err != nil {
+		return Time{}, fmt.Errorf("invalid time %q", s)
+	}
+	return TimeOf(t), nil
+}
+
+// String returns the time in the format "15:04:05", including fractional
+// seconds only when they are not zero.
+func (t Time) String() string {
+	s := fmt.Sprintf("%02d:%02d:%02d", t.Hour, t.Minute, t.Second)
+	if t.Nanosecond != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%09d", t.Nanosecond), "0")
+	}
+	return s
+}
+
+// On returns the time of day on the given date in the given location.
+func (t Time) On(d Date, loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, t.Hour, t.Minute, t.Second, t.Nanosecond, loc)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (t Time) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *Time) UnmarshalText(b []byte) error {
+	v, err := ParseTime(string(b))
+	if err != nil {
+		return err
+	}
+	*t = v
+	return nil
+}
+
+// Duration is an elapsed time. Unlike time.Duration, it is serialized as an
+// ISO 8601 duration. Days are taken to be exactly 24 hours; years and months
+// are not supported because their length varies.
+type Duration time.Duration
+
+// ParseDuration parses an ISO 8601 duration such as "PT1H30M" or "-P1DT0.5S".
+func ParseDuration(s string) (Duration, error) {
+	bad := fmt.Errorf("invalid duration %q", s)
+	str := s
+	neg := strings.HasPrefix(str, "-")
+	if neg {
+		str = str[1:]
+	}
+	if !strings.HasPrefix(str, "P") || len(str) < 3 {
+		return 0, bad
+	}
+	str = str[1:]
+	var total time.Duration
+	inTime := false
+	units := "D"
+	for str != "" {
+		if str[0] == 'T' {
+			if inTime || len(str) == 1 {
+				return 0, bad
+			}
+			inTime = true
+			units = "HMS"
+			str = str[1:]
+			continue
+		}
+		i := strings.IndexAny(str, "DHMS")
+		if i <= 0 {
+			return 0, bad
+		}
+		u := strings.IndexByte(units, str[i])
+		if u < 0 {
+			return 0, bad
+		}
+		units = units[u+1:]
+		var unit time.Duration
+		switch str[i] {
+		case 'D':
+			unit = 24 * time.Hour
+		case 'H':
+			unit = time.Hour
+		case 'M':
+			unit = time.Minute
+		case 'S':
+			unit = time.Second
+		}
+		if str[i] == 'S' {
+			f, err := strconv.ParseFloat(str[:i], 64)
+			if err != nil || strings.Trim(str[:i], "0123456789.") != "" {
+				return 0, bad
+			}
+			total += time.Duration(f * float64(unit))
+		} else {
+			n, err := strconv.ParseUint(str[:i], 10, 32)
+			if err != nil {
+				return 0, bad
+			}
+			total += time.Duration(n) * unit
+		}
+		str = str[i+1:]
+	}
+	if neg {
+		total = -total
+	}
+	return Duration(total), nil
+}
+
+// String returns the duration in ISO 8601 format using hours, minutes and
+// seconds, for example "PT26H3M0.5S". A zero duration is "PT0S".
+func (d Duration) String() string {
+	if d == 0 {
+		return "PT0S"
+	}
+	s := "PT"
+	v := time.Duration(d)
+	if v < 0 {
+		s = "-PT"
+		v = -v
+	}
+	if h := v / time.Hour; h > 0 {
+		s += strconv.FormatInt(int64(h), 10) + "H"
+		v -= h * time.Hour
+	}
+	if m := v / time.Minute; m > 0 {
+		s += strconv.FormatInt(int64(m), 10) + "M"
+		v -= m * time.Minute
+	}
+	if v > 0 {
+		sec := strconv.FormatInt(int64(v/time.Second), 10)
+		if ns := v % time.Second; ns != 0 {
+			sec += strings.TrimRight(fmt.Sprintf(".%09d", ns), "0")
+		}
+		s += sec + "S"
+	}
+	return s
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := ParseDuration(string(b))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// UUID is a universally unique identifier as defined by RFC 4122.
+type UUID [16]byte
+
+// ParseUUID parses a UUID in the format "7d444840-9dc0-11d1-b245-5ffdce74fad2".
+// Upper and lower case hexadecimal digits are accepted.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf("invalid uuid %q", s)
+	}
+	hex := strings.Replace(s, "-", "", -1)
+	if len(hex) != 32 {
+		return u, fmt.Errorf("invalid uuid %q", s)
+	}
+	for i := range u {
+		b, err := strconv.ParseUint(hex[i*2:i*2+2], 16, 8)
+		if err != nil {
+			return UUID{}, fmt.Errorf("invalid uuid %q", s)
+		}
+		u[i] = byte(b)
+	}
+	return u, nil
+}
+
+// String returns the UUID in lower case with hyphens.
+func (u UUID) String() string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (u *UUID) UnmarshalText(b []byte) error {
+	v, err := ParseUUID(string(b))
+	if err != nil {
+		return err
+	}
+	*u = v
+	return nil
+}
@@ -0,0 +1,78 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type allTypes struct {
+	D  Date              `json:"d"`
+	T  Time              `json:"t"`
+	Du Duration          `json:"du"`
+	U  UUID              `json:"u"`
+	M  map[Date]Duration `json:"m"`
+}
+
+func TestJSON(t *testing.T) {
+	in := `{"d":"2015-03-04","t":"13:04:05.25","du":"PT26H3M0.5S","u":"7d444840-9dc0-11d1-b245-5ffdce74fad2","m":{"2015-03-05":"-PT5S"}}`
+	var v allTypes
+	if err := json.Unmarshal([]byte(in), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.D != (Date{2015, time.March, 4}) {
+		t.Errorf("Wrong date: %v", v.D)
+	}
+	if v.T != (Time{13, 4, 5, 250000000}) {
+		t.Errorf("Wrong time: %v", v.T)
+	}
+	if time.Duration(v.Du) != 26*time.Hour+3*time.Minute+500*time.Millisecond {
+		t.Errorf("Wrong duration: %v", time.Duration(v.Du))
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != in {
+		t.Errorf("Round trip failed:\n got: %s\nwant: %s", b, in)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	good := map[string]time.Duration{
+		"PT0S":     0,
+		"P1D":      24 * time.Hour,
+		"P1DT2H":   26 * time.Hour,
+		"PT1.5S":   1500 * time.Millisecond,
+		"-PT1M30S": -90 * time.Second,
+	}
+	for s, want := range good {
+		d, err := ParseDuration(s)
+		if err != nil || time.Duration(d) != want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v", s, time.Duration(d), err, want)
+		}
+	}
+	for _, s := range []string{"", "P", "PT", "P1Y", "P1M", "PT1S2M", "PT1H1H", "P1H", "PT1D", "PT1e3S", "PTInfS", "1H"} {
+		if _, err := ParseDuration(s); err == nil {
+			t.Errorf("ParseDuration(%q) should have failed", s)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, err := ParseDate("2015-02-30"); err == nil {
+		t.Error("ParseDate allowed an invalid day")
+	}
+	if _, err := ParseTime("25:00:00"); err == nil {
+		t.Error("ParseTime allowed an invalid hour")
+	}
+	if _, err := ParseUUID("7d444840-9dc0-11d1-b245-5ffdce74fad"); err == nil {
+		t.Error("ParseUUID allowed a short uuid")
+	}
+	if _, err := ParseUUID("7d444840-9dc0-11d1-b245-5ffdce74-ad2"); err == nil {
+		t.Error("ParseUUID allowed an extra hyphen")
+	}
+	if u, err := ParseUUID("7D444840-9DC0-11D1-B245-5FFDCE74FAD2"); err != nil || u.String() != "7d444840-9dc0-11d1-b245-5ffdce74fad2" {
+		t.Errorf("ParseUUID failed for upper case: %v %v", u, err)
+	}
+}